@@ -0,0 +1,194 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"jobs/types"
+
+	"github.com/go-playground/validator/v10"
+)
+
+// Kind classifies domain errors so that handlers can map them to HTTP statuses
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindValidation
+	KindConflict
+	KindUnavailable
+	KindUnauthenticated
+	KindForbidden
+	KindPreconditionFailed
+)
+
+// Error is a domain error whose Code and Message are safe to show to clients.
+// The wrapped cause is only meant for logs and traces.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []types.FieldError
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Is matches errors with the same code, so that errors.Is(err, ErrX) holds for wrapped copies of ErrX
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// Wrap returns a copy of the error with cause attached
+func (e *Error) Wrap(cause error) *Error {
+	c := *e
+	c.Err = cause
+	return &c
+}
+
+// WithMessage returns a copy of the error with a more specific client message
+func (e *Error) WithMessage(format string, args ...interface{}) *Error {
+	c := *e
+	c.Message = fmt.Sprintf(format, args...)
+	return &c
+}
+
+func NotFound(code, message string) *Error {
+	return &Error{Kind: KindNotFound, Code: code, Message: message}
+}
+
+func Validation(code, message string, fields ...types.FieldError) *Error {
+	return &Error{Kind: KindValidation, Code: code, Message: message, Fields: fields}
+}
+
+func Conflict(code, message string) *Error {
+	return &Error{Kind: KindConflict, Code: code, Message: message}
+}
+
+func Unavailable(code, message string) *Error {
+	return &Error{Kind: KindUnavailable, Code: code, Message: message}
+}
+
+func Unauthenticated(code, message string) *Error {
+	return &Error{Kind: KindUnauthenticated, Code: code, Message: message}
+}
+
+func Forbidden(code, message string) *Error {
+	return &Error{Kind: KindForbidden, Code: code, Message: message}
+}
+
+func PreconditionFailed(code, message string) *Error {
+	return &Error{Kind: KindPreconditionFailed, Code: code, Message: message}
+}
+
+// InvalidParameter reports a single malformed path or query parameter
+func InvalidParameter(field, message string) *Error {
+	return Validation("invalid_parameter", "Invalid "+field+" parameter", types.FieldError{
+		Field:   field,
+		Code:    "format",
+		Message: message,
+	})
+}
+
+// From returns the domain error in err's chain, if any
+func From(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+// FromValidation converts validator.ValidationErrors into a validation error with one entry per field.
+// Other errors are returned unchanged.
+func FromValidation(err error) error {
+	var verrs validator.ValidationErrors
+	if !errors.As(err, &verrs) {
+		return err
+	}
+	fields := make([]types.FieldError, 0, len(verrs))
+	for _, fe := range verrs {
+		fields = append(fields, types.FieldError{
+			Field:   fieldPath(fe),
+			Code:    fe.Tag(),
+			Message: fieldMessage(fe),
+		})
+	}
+	return &Error{
+		Kind:    KindValidation,
+		Code:    "validation_failed",
+		Message: "The request body failed validation",
+		Fields:  fields,
+		Err:     err,
+	}
+}
+
+// fieldPath drops the struct name from the namespace, e.g. "SubscribeInput.job_titles[0]" becomes "job_titles[0]"
+func fieldPath(fe validator.FieldError) string {
+	if _, path, ok := strings.Cut(fe.Namespace(), "."); ok {
+		return path
+	}
+	return fe.Field()
+}
+
+func fieldMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_if":
+		field, value, _ := strings.Cut(fe.Param(), " ")
+		return "is required when " + snakeCase(field) + " is " + value
+	case "email":
+		return "must be a valid email address"
+	case "enum":
+		return "must be one of: " + strings.Join(types.Vocabularies[fe.Param()], ", ")
+	case "oneof":
+		return "must be one of: " + strings.ReplaceAll(fe.Param(), " ", ", ")
+	case "wildcard":
+		return "cannot combine " + types.Wildcard + " with other values"
+	case "iso4217":
+		return "must be an ISO 4217 currency code"
+	case "url":
+		return "must be a URL"
+	case "gtefield":
+		return "must be greater than or equal to " + snakeCase(fe.Param())
+	case "min", "max":
+		bound := map[string]string{"min": "at least", "max": "at most"}[fe.Tag()]
+		switch fe.Kind() {
+		case reflect.Slice, reflect.Map:
+			return "must have " + bound + " " + fe.Param() + " items"
+		case reflect.String:
+			return "must be " + bound + " " + fe.Param() + " characters long"
+		}
+		return "must be " + bound + " " + fe.Param()
+	default:
+		return "failed the " + fe.Tag() + " validation"
+	}
+}
+
+// snakeCase names a struct field like its JSON field, e.g. "SalaryMin" becomes "salary_min"
+func snakeCase(name string) string {
+	var b strings.Builder
+	for i, r := range name {
+		if unicode.IsUpper(r) {
+			if i > 0 {
+				b.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
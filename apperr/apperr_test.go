@@ -0,0 +1,67 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"jobs/types"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorIsMatchesWrappedCopies(t *testing.T) {
+	errNotFound := NotFound("subscriber_not_found", "Subscriber not found")
+	cause := errors.New("sql: no rows in result set")
+	err := fmt.Errorf("could not get subscriber: %w", errNotFound.Wrap(cause))
+
+	assert.ErrorIs(t, err, errNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, NotFound("job_not_found", "Job not found"))
+
+	e, ok := From(err)
+	assert.True(t, ok)
+	assert.Equal(t, KindNotFound, e.Kind)
+	assert.Equal(t, "Subscriber not found: sql: no rows in result set", e.Error())
+
+	_, ok = From(cause)
+	assert.False(t, ok)
+}
+
+func TestFromValidation(t *testing.T) {
+	type input struct {
+		Email   string   `json:"email" validate:"required,email"`
+		Titles  []string `json:"job_titles" validate:"required,min=1,dive,oneof=A B"`
+		Name    string   `json:"name" validate:"max=3"`
+		Country string   `json:"country" validate:"enum=country"`
+		Min     int64    `json:"salary_min"`
+		Max     int64    `json:"salary_max" validate:"gtefield=Min"`
+		Code    string   `json:"salary_currency" validate:"iso4217"`
+		All     []string `json:"countries" validate:"wildcard"`
+	}
+	v := validator.New()
+	v.RegisterTagNameFunc(func(f reflect.StructField) string { return f.Tag.Get("json") })
+	_ = v.RegisterValidation("enum", func(validator.FieldLevel) bool { return false })
+	_ = v.RegisterValidation("wildcard", func(validator.FieldLevel) bool { return false })
+
+	err := FromValidation(v.Struct(input{Email: "jane", Titles: []string{"C"}, Name: "Jane", Country: "Chile", Min: 2, Max: 1, Code: "usd", All: []string{"ALL", "UK"}}))
+
+	e, ok := From(err)
+	assert.True(t, ok)
+	assert.Equal(t, KindValidation, e.Kind)
+	assert.Equal(t, "validation_failed", e.Code)
+	assert.Equal(t, []types.FieldError{
+		{Field: "email", Code: "email", Message: "must be a valid email address"},
+		{Field: "job_titles[0]", Code: "oneof", Message: "must be one of: A, B"},
+		{Field: "name", Code: "max", Message: "must be at most 3 characters long"},
+		{Field: "country", Code: "enum", Message: "must be one of: Argentina, Australia, USA, UK"},
+		{Field: "salary_max", Code: "gtefield", Message: "must be greater than or equal to min"},
+		{Field: "salary_currency", Code: "iso4217", Message: "must be an ISO 4217 currency code"},
+		{Field: "countries", Code: "wildcard", Message: "cannot combine ALL with other values"},
+	}, e.Fields)
+
+	other := errors.New("boom")
+	assert.Equal(t, other, FromValidation(other))
+}
@@ -0,0 +1,353 @@
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-playground/validator/v10"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the full application configuration.
+//
+// Values are loaded from a YAML file, environment variables and CLI flags, in that precedence.
+// Every leaf field declares its env var (`env`) and flag name (`flag`); fields tagged `secret`
+// are redacted when the config is printed. Fields without a flag, such as the per-route rate limits,
+// can only be set in the YAML file.
+type Config struct {
+	Server    ServerConfig    `yaml:"server"`
+	Database  DatabaseConfig  `yaml:"database"`
+	Log       LogConfig       `yaml:"log"`
+	Tracing   TracingConfig   `yaml:"tracing"`
+	Pyroscope PyroscopeConfig `yaml:"pyroscope"`
+	External  ExternalConfig  `yaml:"external"`
+	Auth      AuthConfig      `yaml:"auth"`
+	Mail      MailConfig      `yaml:"mail"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Jobs      JobsConfig      `yaml:"jobs"`
+}
+
+type ServerConfig struct {
+	Port      string `yaml:"port" env:"SERVER_PORT" flag:"server.port" usage:"HTTP listen address" validate:"required"`
+	PprofPort string `yaml:"pprof_port" env:"PPROF_PORT" flag:"server.pprof-port" usage:"pprof listen address, empty to disable"`
+	// TrustedProxies lists the IPs and CIDR ranges of the proxies whose X-Forwarded-For tells the client IP
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" flag:"server.trusted-proxies" usage:"comma separated IPs or CIDRs of the proxies whose X-Forwarded-For is honored" validate:"dive,ip|cidr"`
+	// ValidateRequests rejects requests that do not match the embedded OpenAPI spec
+	ValidateRequests bool `yaml:"validate_requests" env:"OPENAPI_VALIDATE_REQUESTS" flag:"server.validate-requests" usage:"validate requests against the OpenAPI spec"`
+	// ValidateResponses logs responses that do not match the spec, meant for development and tests
+	ValidateResponses bool `yaml:"validate_responses" env:"OPENAPI_VALIDATE_RESPONSES" flag:"server.validate-responses" usage:"log responses that do not match the OpenAPI spec, requires validate_requests"`
+}
+
+type DatabaseConfig struct {
+	Host     string `yaml:"host" env:"POSTGRES_HOST" flag:"db.host" usage:"Postgres host" validate:"required"`
+	Port     int    `yaml:"port" env:"POSTGRES_PORT" flag:"db.port" usage:"Postgres port" validate:"required,min=1,max=65535"`
+	User     string `yaml:"user" env:"POSTGRES_USER" flag:"db.user" usage:"Postgres user" validate:"required"`
+	Password string `yaml:"password" env:"POSTGRES_PASSWORD" flag:"db.password" usage:"Postgres password" secret:"true" validate:"required"`
+	DBName   string `yaml:"name" env:"POSTGRES_DB" flag:"db.name" usage:"Postgres database" validate:"required"`
+	SSLMode  string `yaml:"ssl_mode" env:"POSTGRES_SSL_MODE" flag:"db.ssl-mode" usage:"Postgres sslmode" validate:"omitempty,oneof=disable allow prefer require verify-ca verify-full"`
+}
+
+type LogConfig struct {
+	Environment string   `yaml:"environment" env:"ENVIRONMENT" flag:"environment" usage:"dev or prod profile" validate:"oneof=dev prod"`
+	Level       string   `yaml:"level" env:"LOG_LEVEL" flag:"log.level" usage:"log level, overrides the profile level"`
+	Encoding    string   `yaml:"encoding" env:"LOG_ENCODING" flag:"log.encoding" usage:"json or console" validate:"omitempty,oneof=json console"`
+	Sampling    bool     `yaml:"sampling" env:"LOG_SAMPLING" flag:"log.sampling" usage:"enable log sampling"`
+	OutputPaths []string `yaml:"output_paths" env:"LOG_OUTPUT_PATHS" flag:"log.output-paths" usage:"comma separated log outputs"`
+	RedactPII   bool     `yaml:"redact_pii" env:"LOG_REDACT_PII" flag:"log.redact-pii" usage:"mask PII in logs"`
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"OTEL_TRACES_EXPORTER" flag:"tracing.exporter" usage:"none, stdout or otlp" validate:"oneof=none stdout otlp"`
+	ServiceName  string  `yaml:"service_name" env:"OTEL_SERVICE_NAME" flag:"tracing.service-name" usage:"service name reported in spans" validate:"required"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"OTEL_EXPORTER_OTLP_ENDPOINT" flag:"tracing.otlp-endpoint" usage:"OTLP/HTTP endpoint URL" validate:"omitempty,url"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"OTEL_TRACES_SAMPLER_ARG" flag:"tracing.sample-ratio" usage:"trace sampling ratio" validate:"min=0,max=1"`
+}
+
+type PyroscopeConfig struct {
+	Enabled         bool   `yaml:"enabled" env:"PYROSCOPE_ENABLED" flag:"pyroscope.enabled" usage:"enable continuous profiling"`
+	ServerAddress   string `yaml:"server_address" env:"PYROSCOPE_SERVER_ADDRESS" flag:"pyroscope.server-address" usage:"Pyroscope server URL" validate:"required_if=Enabled true,omitempty,url"`
+	ApplicationName string `yaml:"application_name" env:"PYROSCOPE_APPLICATION_NAME" flag:"pyroscope.application-name" usage:"Pyroscope application name" validate:"required_if=Enabled true"`
+}
+
+type ExternalConfig struct {
+	BaseURL string        `yaml:"base_url" env:"EXTERNAL_JOBS_URL" flag:"external.base-url" usage:"external jobs API base URL" validate:"required,url"`
+	Timeout time.Duration `yaml:"timeout" env:"EXTERNAL_JOBS_TIMEOUT" flag:"external.timeout" usage:"external jobs API timeout" validate:"min=0"`
+	// IngestInterval, when set, stores the external jobs in the database and lists them from there
+	IngestInterval time.Duration `yaml:"ingest_interval" env:"EXTERNAL_JOBS_INGEST_INTERVAL" flag:"external.ingest-interval" usage:"how often external jobs are ingested into the database, 0 fetches them on every request instead" validate:"min=0"`
+	MaxAge         time.Duration `yaml:"max_age" env:"EXTERNAL_JOBS_MAX_AGE" flag:"external.max-age" usage:"ingested external jobs not served for that long are no longer listed, 0 keeps listing them" validate:"min=0"`
+}
+
+type AuthConfig struct {
+	BootstrapAdminKey string        `yaml:"bootstrap_admin_key" env:"BOOTSTRAP_ADMIN_API_KEY" flag:"auth.bootstrap-admin-key" usage:"admin API key created at startup if missing" secret:"true" validate:"omitempty,startswith=jsk_,min=24"`
+	SessionSecret     string        `yaml:"session_secret" env:"SESSION_SECRET" flag:"auth.session-secret" usage:"HMAC secret signing subscriber session tokens, empty disables sessions" secret:"true" validate:"omitempty,min=32"`
+	SessionTTL        time.Duration `yaml:"session_ttl" env:"SESSION_TTL" flag:"auth.session-ttl" usage:"subscriber session token lifetime" validate:"min=0"`
+	MagicLinkTTL      time.Duration `yaml:"magic_link_ttl" env:"MAGIC_LINK_TTL" flag:"auth.magic-link-ttl" usage:"sign-in link lifetime" validate:"min=0"`
+	MagicLinkURL      string        `yaml:"magic_link_url" env:"MAGIC_LINK_URL" flag:"auth.magic-link-url" usage:"page the emailed sign-in link points to" validate:"required,url"`
+}
+
+type MailConfig struct {
+	SMTPAddr     string `yaml:"smtp_addr" env:"SMTP_ADDR" flag:"mail.smtp-addr" usage:"SMTP server host:port, empty logs emails instead" validate:"omitempty,hostname_port"`
+	From         string `yaml:"from" env:"MAIL_FROM" flag:"mail.from" usage:"sender address" validate:"required,email"`
+	SMTPUser     string `yaml:"smtp_user" env:"SMTP_USER" flag:"mail.smtp-user" usage:"SMTP username"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" flag:"mail.smtp-password" usage:"SMTP password" secret:"true"`
+}
+
+type RateLimitConfig struct {
+	Enabled     bool    `yaml:"enabled" env:"RATE_LIMIT_ENABLED" flag:"ratelimit.enabled" usage:"enable rate limiting"`
+	Store       string  `yaml:"store" env:"RATE_LIMIT_STORE" flag:"ratelimit.store" usage:"memory, or postgres to share limits between replicas" validate:"oneof=memory postgres"`
+	PerKeyRate  float64 `yaml:"per_key_rate" env:"RATE_LIMIT_PER_KEY_RATE" flag:"ratelimit.per-key-rate" usage:"default requests per second per API key or subscriber, 0 disables" validate:"min=0"`
+	PerKeyBurst int     `yaml:"per_key_burst" env:"RATE_LIMIT_PER_KEY_BURST" flag:"ratelimit.per-key-burst" usage:"default burst per API key or subscriber" validate:"min=0"`
+	PerIPRate   float64 `yaml:"per_ip_rate" env:"RATE_LIMIT_PER_IP_RATE" flag:"ratelimit.per-ip-rate" usage:"default requests per second per client IP, 0 disables" validate:"min=0"`
+	PerIPBurst  int     `yaml:"per_ip_burst" env:"RATE_LIMIT_PER_IP_BURST" flag:"ratelimit.per-ip-burst" usage:"default burst per client IP" validate:"min=0"`
+	// Routes overrides the default limits for a route template, e.g. /V1/subscribe
+	Routes map[string]RateLimitPolicy `yaml:"routes" validate:"dive"`
+}
+
+type JobsConfig struct {
+	SweepInterval time.Duration `yaml:"sweep_interval" env:"JOBS_SWEEP_INTERVAL" flag:"jobs.sweep-interval" usage:"how often open jobs past expires_at are expired, 0 disables the sweeper" validate:"min=0"`
+	// MaxAge expires open jobs without expires_at once they are that old
+	MaxAge time.Duration `yaml:"max_age" env:"JOBS_MAX_AGE" flag:"jobs.max-age" usage:"age at which open jobs without expires_at expire, 0 keeps them open" validate:"min=0"`
+	// ExchangeRatesFile is a YAML table of exchange rates, see currency/rates.yml for the format
+	ExchangeRatesFile string `yaml:"exchange_rates_file" env:"EXCHANGE_RATES_FILE" flag:"jobs.exchange-rates-file" usage:"YAML exchange-rate table used to compare salaries, empty uses the embedded rates"`
+}
+
+// RateLimitPolicy holds the limits of one route, a zero rate disables that limit
+type RateLimitPolicy struct {
+	PerKey RateLimit `yaml:"per_key"`
+	PerIP  RateLimit `yaml:"per_ip"`
+}
+
+// RateLimit is a token bucket refilled at Rate tokens per second up to Burst tokens
+type RateLimit struct {
+	Rate  float64 `yaml:"rate" validate:"min=0"`
+	Burst int     `yaml:"burst" validate:"min=0"`
+}
+
+// Default returns the configuration used when nothing else is set
+func Default() Config {
+	return Config{
+		Server: ServerConfig{Port: ":8080", PprofPort: ":6060", ValidateRequests: true},
+		Database: DatabaseConfig{
+			Port:    5432,
+			SSLMode: "disable",
+		},
+		Log: LogConfig{
+			Environment: "dev",
+			RedactPII:   true,
+		},
+		Tracing: TracingConfig{
+			Exporter:    "none",
+			ServiceName: "job-seeker-jobs",
+			SampleRatio: 1,
+		},
+		Pyroscope: PyroscopeConfig{
+			Enabled:         true,
+			ServerAddress:   "http://pyroscope:4040",
+			ApplicationName: "job-seeker-jobs",
+		},
+		External: ExternalConfig{
+			BaseURL: "http://localhost:8081",
+			Timeout: 10 * time.Second,
+			MaxAge:  24 * time.Hour,
+		},
+		Auth: AuthConfig{
+			SessionTTL:   time.Hour,
+			MagicLinkTTL: 15 * time.Minute,
+			MagicLinkURL: "http://localhost:8080/login",
+		},
+		Mail: MailConfig{
+			From: "no-reply@job-seeker.local",
+		},
+		RateLimit: RateLimitConfig{
+			Enabled:     true,
+			Store:       "memory",
+			PerKeyRate:  10,
+			PerKeyBurst: 20,
+			PerIPRate:   20,
+			PerIPBurst:  40,
+			Routes: map[string]RateLimitPolicy{
+				"/V1/subscribe": {
+					PerKey: RateLimit{Rate: 1, Burst: 5},
+					PerIP:  RateLimit{Rate: 2, Burst: 10},
+				},
+				"/V1/subscribers:batch": {
+					PerKey: RateLimit{Rate: 0.1, Burst: 2},
+				},
+				"/V2/subscribers": {
+					PerKey: RateLimit{Rate: 1, Burst: 5},
+					PerIP:  RateLimit{Rate: 2, Burst: 10},
+				},
+				"/V1/auth/magic-link": {
+					// Per email rather than per client, the route is public
+					PerKey: RateLimit{Rate: 1.0 / 600, Burst: 3},
+					PerIP:  RateLimit{Rate: 1.0 / 60, Burst: 5},
+				},
+				"/V1/auth/token": {
+					PerIP: RateLimit{Rate: 0.2, Burst: 10},
+				},
+			},
+		},
+		Jobs: JobsConfig{
+			SweepInterval: 5 * time.Minute,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the YAML file given by -config (or CONFIG_FILE),
+// environment variables and CLI flags, then validates it
+func Load(args []string) (Config, error) {
+	cfg := Default()
+
+	fs := flag.NewFlagSet("jobs", flag.ContinueOnError)
+	configFile := fs.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML config file")
+	overrides := map[string]*string{}
+	forEachField(&cfg, func(field reflect.StructField, _ reflect.Value) {
+		name := field.Tag.Get("flag")
+		overrides[name] = new(string)
+		store := func(v string) error {
+			*overrides[name] = v
+			return nil
+		}
+		if field.Type.Kind() == reflect.Bool {
+			fs.BoolFunc(name, field.Tag.Get("usage"), store)
+			return
+		}
+		fs.Func(name, field.Tag.Get("usage"), store)
+	})
+	if err := fs.Parse(args); err != nil {
+		return Config{}, err
+	}
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	if *configFile != "" {
+		if err := loadFile(*configFile, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	var errs []error
+	forEachField(&cfg, func(field reflect.StructField, value reflect.Value) {
+		if env, ok := os.LookupEnv(field.Tag.Get("env")); ok && env != "" {
+			if err := setValue(value, env); err != nil {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", field.Tag.Get("env"), err))
+			}
+		}
+	})
+	forEachField(&cfg, func(field reflect.StructField, value reflect.Value) {
+		name := field.Tag.Get("flag")
+		if set[name] {
+			if err := setValue(value, *overrides[name]); err != nil {
+				errs = append(errs, fmt.Errorf("invalid -%s: %w", name, err))
+			}
+		}
+	})
+	if len(errs) > 0 {
+		return Config{}, errors.Join(errs...)
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// Validate checks every section of the configuration
+func (c Config) Validate() error {
+	if err := validator.New().Struct(c); err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML with secrets redacted
+func (c Config) Print(w io.Writer) error {
+	redacted := c
+	forEachField(&redacted, func(field reflect.StructField, value reflect.Value) {
+		if field.Tag.Get("secret") == "true" && !value.IsZero() {
+			value.SetString("*****")
+		}
+	})
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(redacted); err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+	return enc.Close()
+}
+
+func loadFile(path string, cfg *Config) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("could not open config file: %w", err)
+	}
+	defer f.Close()
+
+	dec := yaml.NewDecoder(f)
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("could not parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+// forEachField calls fn for every leaf field of the config sections that has a flag
+func forEachField(cfg *Config, fn func(reflect.StructField, reflect.Value)) {
+	root := reflect.ValueOf(cfg).Elem()
+	for i := 0; i < root.NumField(); i++ {
+		section := root.Field(i)
+		for j := 0; j < section.NumField(); j++ {
+			if field := section.Type().Field(j); field.Tag.Get("flag") != "" {
+				fn(field, section.Field(j))
+			}
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, raw string) error {
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(raw)
+	case reflect.Int:
+		i, err := strconv.Atoi(raw)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(i))
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		v.SetBool(b)
+	case reflect.Float64:
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return err
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		v.Set(reflect.ValueOf(strings.Split(raw, ",")))
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package config
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfigFile(t *testing.T, content string) string {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad(t *testing.T) {
+	file := writeConfigFile(t, `
+server:
+  port: ":9000"
+database:
+  host: file-host
+  user: file-user
+  password: file-secret
+  name: jobs
+log:
+  level: warn
+external:
+  timeout: 3s
+rate_limit:
+  routes:
+    /V1/jobs:
+      per_key: {rate: 5, burst: 10}
+`)
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		args          []string
+		check         func(t *testing.T, cfg Config)
+		expectedError string
+	}{
+		{
+			name: "File values override defaults",
+			args: []string{"-config", file},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, ":9000", cfg.Server.Port)
+				assert.Equal(t, "file-host", cfg.Database.Host)
+				assert.Equal(t, 5432, cfg.Database.Port)
+				assert.Equal(t, "warn", cfg.Log.Level)
+				assert.Equal(t, 3*time.Second, cfg.External.Timeout)
+				assert.Equal(t, RateLimitPolicy{PerKey: RateLimit{Rate: 5, Burst: 10}}, cfg.RateLimit.Routes["/V1/jobs"])
+				assert.Contains(t, cfg.RateLimit.Routes, "/V1/subscribe")
+			},
+		},
+		{
+			name: "Env overrides file and flags override env",
+			env: map[string]string{
+				"CONFIG_FILE":   file,
+				"POSTGRES_HOST": "env-host",
+				"POSTGRES_PORT": "5433",
+				"SERVER_PORT":   ":7000",
+			},
+			args: []string{"-server.port", ":7001", "-log.sampling"},
+			check: func(t *testing.T, cfg Config) {
+				assert.Equal(t, "env-host", cfg.Database.Host)
+				assert.Equal(t, 5433, cfg.Database.Port)
+				assert.Equal(t, ":7001", cfg.Server.Port)
+				assert.True(t, cfg.Log.Sampling)
+			},
+		},
+		{
+			name:          "Invalid env value",
+			env:           map[string]string{"CONFIG_FILE": file, "POSTGRES_PORT": "not-a-port"},
+			expectedError: "invalid POSTGRES_PORT",
+		},
+		{
+			name:          "Validation fails without database settings",
+			args:          []string{},
+			expectedError: "Field validation for 'Host' failed on the 'required' tag",
+		},
+		{
+			name:          "Validation fails on unknown exporter",
+			args:          []string{"-config", file, "-tracing.exporter", "zipkin"},
+			expectedError: "Field validation for 'Exporter' failed on the 'oneof' tag",
+		},
+		{
+			name:          "Validation fails on an invalid trusted proxy",
+			args:          []string{"-config", file, "-server.trusted-proxies", "10.0.0.0/8,proxy.local"},
+			expectedError: "Field validation for 'TrustedProxies[1]' failed on the 'ip|cidr' tag",
+		},
+		{
+			name:          "Unknown field in file",
+			args:          []string{"-config", writeConfigFile(t, "server:\n  prot: 1\n")},
+			expectedError: "field prot not found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+
+			cfg, err := Load(tt.args)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Database.Password = "super-secret"
+
+	var buf bytes.Buffer
+	assert.NoError(t, cfg.Print(&buf))
+	assert.NotContains(t, buf.String(), "super-secret")
+	assert.Contains(t, buf.String(), "password: '*****'")
+	assert.Contains(t, buf.String(), "timeout: 10s")
+	assert.Equal(t, "super-secret", cfg.Database.Password)
+}
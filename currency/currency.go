@@ -0,0 +1,105 @@
+// Package currency converts amounts between currencies with an exchange-rate table.
+package currency
+
+import (
+	_ "embed"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed rates.yml
+var defaultRates []byte
+
+// Rates converts amounts between currencies
+type Rates interface {
+	// Rate returns what one unit of from is worth in to, false when either currency is unknown
+	Rate(from, to string) (float64, bool)
+	// Currencies lists the known currencies, sorted
+	Currencies() []string
+}
+
+// Table is an exchange-rate table relative to a base currency
+type Table struct {
+	Base string `yaml:"base"`
+	// Rates are the units of each currency one unit of Base is worth
+	Rates map[string]float64 `yaml:"rates"`
+}
+
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Default returns the rates embedded in the binary
+func Default() *Table {
+	t, err := Parse(defaultRates)
+	if err != nil {
+		panic(fmt.Sprintf("invalid embedded exchange rates: %v", err))
+	}
+	return t
+}
+
+// Load reads a YAML rate table from path, the embedded rates are used when path is empty
+func Load(path string) (*Table, error) {
+	if path == "" {
+		return Default(), nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read exchange rates: %w", err)
+	}
+	t, err := Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid exchange rates %s: %w", path, err)
+	}
+	return t, nil
+}
+
+// Parse decodes and checks a YAML rate table, the base currency is worth 1 of itself
+func Parse(data []byte) (*Table, error) {
+	var t Table
+	if err := yaml.Unmarshal(data, &t); err != nil {
+		return nil, err
+	}
+	if !codePattern.MatchString(t.Base) {
+		return nil, fmt.Errorf("base %q is not an ISO 4217 code", t.Base)
+	}
+	if t.Rates == nil {
+		t.Rates = map[string]float64{}
+	}
+	if rate, ok := t.Rates[t.Base]; ok && rate != 1 {
+		return nil, fmt.Errorf("the base currency %s must have a rate of 1", t.Base)
+	}
+	t.Rates[t.Base] = 1
+	for code, rate := range t.Rates {
+		if !codePattern.MatchString(code) {
+			return nil, fmt.Errorf("%q is not an ISO 4217 code", code)
+		}
+		if rate <= 0 {
+			return nil, fmt.Errorf("the rate of %s must be positive", code)
+		}
+	}
+	return &t, nil
+}
+
+func (t *Table) Rate(from, to string) (float64, bool) {
+	fromRate, ok := t.Rates[from]
+	if !ok {
+		return 0, false
+	}
+	toRate, ok := t.Rates[to]
+	if !ok {
+		return 0, false
+	}
+	return toRate / fromRate, true
+}
+
+func (t *Table) Currencies() []string {
+	codes := make([]string, 0, len(t.Rates))
+	for code := range t.Rates {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	return codes
+}
@@ -0,0 +1,69 @@
+package currency
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefault(t *testing.T) {
+	rates := Default()
+
+	rate, ok := rates.Rate("USD", "ARS")
+	assert.True(t, ok)
+	assert.Equal(t, 950.0, rate)
+
+	rate, ok = rates.Rate("GBP", "GBP")
+	assert.True(t, ok)
+	assert.Equal(t, 1.0, rate)
+
+	_, ok = rates.Rate("USD", "XYZ")
+	assert.False(t, ok)
+	assert.Contains(t, rates.Currencies(), "EUR")
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		expectedError string
+	}{
+		{name: "Base is added", data: "base: EUR\nrates:\n  USD: 1.1\n"},
+		{name: "Invalid base", data: "base: euro\n", expectedError: `base "euro" is not an ISO 4217 code`},
+		{name: "Base rate must be 1", data: "base: EUR\nrates:\n  EUR: 2\n", expectedError: "the base currency EUR must have a rate of 1"},
+		{name: "Invalid code", data: "base: EUR\nrates:\n  us: 1.1\n", expectedError: `"us" is not an ISO 4217 code`},
+		{name: "Rates are positive", data: "base: EUR\nrates:\n  USD: 0\n", expectedError: "the rate of USD must be positive"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := Parse([]byte(tt.data))
+			if tt.expectedError != "" {
+				assert.EqualError(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"EUR", "USD"}, table.Currencies())
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.yml")
+	assert.NoError(t, os.WriteFile(path, []byte("base: GBP\nrates:\n  USD: 1.25\n"), 0o600))
+
+	table, err := Load(path)
+	assert.NoError(t, err)
+	rate, ok := table.Rate("USD", "GBP")
+	assert.True(t, ok)
+	assert.Equal(t, 0.8, rate)
+
+	table, err = Load("")
+	assert.NoError(t, err)
+	assert.Equal(t, "USD", table.Base)
+
+	_, err = Load(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,170 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrAPIKeyNotFound is returned when no API key matches the lookup
+var ErrAPIKeyNotFound = apperr.NotFound("api_key_not_found", "API key not found")
+
+// APIKeyStore defines the persistence operations for API keys
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *types.APIKey, hash string) error
+	// UseAPIKey looks up a key by hash and records its usage
+	UseAPIKey(ctx context.Context, hash string) (types.APIKey, error)
+	ListAPIKeys(ctx context.Context) ([]types.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	// RotateAPIKey stores the replacement key and expires the old one at oldExpiresAt, atomically
+	RotateAPIKey(ctx context.Context, oldID uuid.UUID, oldExpiresAt time.Time, key *types.APIKey, hash string) error
+}
+
+const apiKeyColumns = `id, name, prefix, scopes, rotated_from, created_at, expires_at, revoked_at, last_used_at`
+
+// CreateAPIKey stores a new API key, filling in its ID and creation time
+func (db *DBConnector) CreateAPIKey(ctx context.Context, key *types.APIKey, hash string) error {
+	ctx, span := startSpan(ctx, "DBConnector.CreateAPIKey", "INSERT", "api_keys")
+	defer span.End()
+
+	if err := insertAPIKey(ctx, db.DB, key, hash); err != nil {
+		recordError(span, err)
+		return err
+	}
+	return nil
+}
+
+// UseAPIKey returns the key matching hash and updates its last_used_at
+func (db *DBConnector) UseAPIKey(ctx context.Context, hash string) (types.APIKey, error) {
+	ctx, span := startSpan(ctx, "DBConnector.UseAPIKey", "UPDATE", "api_keys")
+	defer span.End()
+
+	query := `
+		UPDATE api_keys
+		SET last_used_at = $2
+		WHERE key_hash = $1
+		RETURNING ` + apiKeyColumns
+	key, err := scanAPIKey(db.DB.QueryRowxContext(ctx, query, hash, time.Now().UTC()))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.APIKey{}, ErrAPIKeyNotFound
+		}
+		recordError(span, err)
+		return types.APIKey{}, fmt.Errorf("error looking up api key: %w", err)
+	}
+	return key, nil
+}
+
+// ListAPIKeys returns every API key, newest first
+func (db *DBConnector) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ListAPIKeys", "SELECT", "api_keys")
+	defer span.End()
+
+	rows, err := db.DB.QueryxContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY created_at DESC`)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []types.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey marks the key as revoked; revoking twice keeps the first revocation time
+func (db *DBConnector) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DBConnector.RevokeAPIKey", "UPDATE", "api_keys")
+	defer span.End()
+
+	const query = `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`
+	res, err := db.DB.ExecContext(ctx, query, id, time.Now().UTC())
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error revoking api key: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+// RotateAPIKey inserts the replacement key and shortens the old key's lifetime in one transaction
+func (db *DBConnector) RotateAPIKey(ctx context.Context, oldID uuid.UUID, oldExpiresAt time.Time, key *types.APIKey, hash string) error {
+	ctx, span := startSpan(ctx, "DBConnector.RotateAPIKey", "UPDATE", "api_keys")
+	defer span.End()
+
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const query = `
+		UPDATE api_keys
+		SET expires_at = LEAST(COALESCE(expires_at, $2), $2)
+		WHERE id = $1 AND revoked_at IS NULL
+		RETURNING name, scopes`
+	var scopes []string
+	err = tx.QueryRowxContext(ctx, query, oldID, oldExpiresAt).Scan(&key.Name, pq.Array(&scopes))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrAPIKeyNotFound
+		}
+		recordError(span, err)
+		return fmt.Errorf("error expiring api key: %w", err)
+	}
+	key.Scopes = scopes
+	key.RotatedFrom = &oldID
+
+	if err := insertAPIKey(ctx, tx, key, hash); err != nil {
+		recordError(span, err)
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error committing api key rotation: %w", err)
+	}
+	return nil
+}
+
+func insertAPIKey(ctx context.Context, q sqlx.QueryerContext, key *types.APIKey, hash string) error {
+	const query = `
+		INSERT INTO api_keys (name, prefix, key_hash, scopes, rotated_from, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id, created_at`
+	err := q.QueryRowxContext(ctx, query, key.Name, key.Prefix, hash, pq.Array(key.Scopes), key.RotatedFrom, key.ExpiresAt).
+		Scan(&key.ID, &key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("error inserting api key: %w", err)
+	}
+	return nil
+}
+
+func scanAPIKey(row interface{ Scan(...interface{}) error }) (types.APIKey, error) {
+	var key types.APIKey
+	err := row.Scan(&key.ID, &key.Name, &key.Prefix, pq.Array(&key.Scopes), &key.RotatedFrom,
+		&key.CreatedAt, &key.ExpiresAt, &key.RevokedAt, &key.LastUsedAt)
+	return key, err
+}
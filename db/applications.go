@@ -0,0 +1,274 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+var (
+	// ErrApplicationNotFound is returned when no application has the given ID
+	ErrApplicationNotFound = apperr.NotFound("application_not_found", "Application not found")
+	// ErrApplicationModified is returned when an application changed status since it was read
+	ErrApplicationModified = apperr.Conflict("application_modified", "The application was modified since it was read")
+)
+
+const applicationColumns = `
+	id,
+	subscriber_id,
+	COALESCE(job_id, external_job_id),
+	source,
+	title,
+	COALESCE(company, ''),
+	country,
+	status,
+	notes,
+	applied_at,
+	created_at,
+	updated_at`
+
+// CreateApplication records an application along with the event of its creation, a second application of a
+// subscriber to the same job is a conflict
+func (db *DBConnector) CreateApplication(ctx context.Context, app types.Application) (types.Application, error) {
+	ctx, span := startSpan(ctx, "DBConnector.CreateApplication", "INSERT", "applications")
+	defer span.End()
+
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	// Rolling back after a commit is a no-op
+	defer func() { _ = tx.Rollback() }()
+
+	var internalID, externalID *uuid.UUID
+	if app.Source == types.SourceInternal {
+		internalID = app.JobID
+	} else {
+		externalID = app.JobID
+	}
+	const query = `
+		INSERT INTO applications (subscriber_id, source, job_id, external_job_id, title, company, country, status, notes, applied_at)
+		VALUES ($1, $2, $3, $4, $5, NULLIF($6, ''), $7, $8, $9, $10)
+		RETURNING ` + applicationColumns
+	var created types.Application
+	err = tx.QueryRowContext(ctx, query, app.SubscriberID, app.Source, internalID, externalID, app.Title, app.Company, app.Country,
+		app.Status, app.Notes, utc(app.AppliedAt)).Scan(applicationFields(&created)...)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error creating application: %w", classify(err, nil))
+	}
+	if err := insertApplicationEvent(ctx, tx, created.ID, "", created.Status, ""); err != nil {
+		recordError(span, err)
+		return types.Application{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error committing application: %w", err)
+	}
+	return created, nil
+}
+
+// GetApplication returns the application with the given ID or ErrApplicationNotFound
+func (db *DBConnector) GetApplication(ctx context.Context, id uuid.UUID) (types.Application, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetApplication", "SELECT", "applications")
+	defer span.End()
+
+	var app types.Application
+	err := db.DB.QueryRowContext(ctx, `SELECT `+applicationColumns+` FROM applications WHERE id = $1`, id).Scan(applicationFields(&app)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Application{}, classify(err, ErrApplicationNotFound.WithMessage("Application %s not found", id))
+		}
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error getting application: %w", err)
+	}
+	return app, nil
+}
+
+// ListApplications returns the applications of a subscriber, in status when set, most recently updated first
+func (db *DBConnector) ListApplications(ctx context.Context, subscriberID uuid.UUID, status string) ([]types.Application, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ListApplications", "SELECT", "applications")
+	defer span.End()
+
+	const query = `
+		SELECT ` + applicationColumns + `
+		FROM applications
+		WHERE subscriber_id = $1 AND ($2 = '' OR status::text = $2)
+		ORDER BY updated_at DESC, id`
+	rows, err := db.DB.QueryContext(ctx, query, subscriberID, status)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing applications: %w", err)
+	}
+	defer rows.Close()
+
+	apps := []types.Application{}
+	for rows.Next() {
+		var app types.Application
+		if err := rows.Scan(applicationFields(&app)...); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning application: %w", err)
+		}
+		apps = append(apps, app)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over applications: %w", err)
+	}
+	return apps, nil
+}
+
+// UpdateApplication overwrites the status, notes and application date of an application still in fromStatus,
+// otherwise ErrApplicationModified is returned. The status change is recorded with note when the status changes.
+func (db *DBConnector) UpdateApplication(ctx context.Context, fromStatus string, app types.Application, note string) (types.Application, error) {
+	ctx, span := startSpan(ctx, "DBConnector.UpdateApplication", "UPDATE", "applications")
+	defer span.End()
+
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	const query = `
+		UPDATE applications SET status = $3, notes = $4, applied_at = $5
+		WHERE id = $1 AND status = $2
+		RETURNING ` + applicationColumns
+	var updated types.Application
+	err = tx.QueryRowContext(ctx, query, app.ID, fromStatus, app.Status, app.Notes, utc(app.AppliedAt)).Scan(applicationFields(&updated)...)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = missingApplication(ctx, tx, app.ID)
+	}
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error updating application: %w", classify(err, nil))
+	}
+	if updated.Status != fromStatus {
+		if err := insertApplicationEvent(ctx, tx, updated.ID, fromStatus, updated.Status, note); err != nil {
+			recordError(span, err)
+			return types.Application{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("error committing application: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteApplication removes an application and its history
+func (db *DBConnector) DeleteApplication(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DBConnector.DeleteApplication", "DELETE", "applications")
+	defer span.End()
+
+	res, err := db.DB.ExecContext(ctx, `DELETE FROM applications WHERE id = $1`, id)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error deleting application: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrApplicationNotFound.WithMessage("Application %s not found", id)
+	}
+	return nil
+}
+
+// ListApplicationEvents returns the status history of an application, oldest first
+func (db *DBConnector) ListApplicationEvents(ctx context.Context, id uuid.UUID) ([]types.ApplicationEvent, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ListApplicationEvents", "SELECT", "application_events")
+	defer span.End()
+
+	const query = `
+		SELECT id, application_id, COALESCE(from_status::text, ''), to_status, note, created_at
+		FROM application_events
+		WHERE application_id = $1
+		ORDER BY created_at, id`
+	rows, err := db.DB.QueryContext(ctx, query, id)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing application events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []types.ApplicationEvent{}
+	for rows.Next() {
+		var event types.ApplicationEvent
+		if err := rows.Scan(&event.ID, &event.ApplicationID, &event.FromStatus, &event.ToStatus, &event.Note, &event.CreatedAt); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning application event: %w", err)
+		}
+		events = append(events, event)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over application events: %w", err)
+	}
+	return events, nil
+}
+
+// CountApplications returns the number of applications of a subscriber by status, statuses without any are missing
+func (db *DBConnector) CountApplications(ctx context.Context, subscriberID uuid.UUID) (map[string]int, error) {
+	ctx, span := startSpan(ctx, "DBConnector.CountApplications", "SELECT", "applications")
+	defer span.End()
+
+	rows, err := db.DB.QueryContext(ctx, `SELECT status, count(*) FROM applications WHERE subscriber_id = $1 GROUP BY status`, subscriberID)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error counting applications: %w", err)
+	}
+	defer rows.Close()
+
+	counts := map[string]int{}
+	for rows.Next() {
+		var (
+			status string
+			count  int
+		)
+		if err := rows.Scan(&status, &count); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning application count: %w", err)
+		}
+		counts[status] = count
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over application counts: %w", err)
+	}
+	return counts, nil
+}
+
+func insertApplicationEvent(ctx context.Context, tx *sqlx.Tx, id uuid.UUID, from, to, note string) error {
+	const query = `INSERT INTO application_events (application_id, from_status, to_status, note) VALUES ($1, NULLIF($2, '')::application_status, $3, $4)`
+	if _, err := tx.ExecContext(ctx, query, id, from, to, note); err != nil {
+		return fmt.Errorf("error recording application event: %w", classify(err, nil))
+	}
+	return nil
+}
+
+// missingApplication tells apart an update that found no application from one that found it in another status
+func missingApplication(ctx context.Context, tx *sqlx.Tx, id uuid.UUID) error {
+	var exists bool
+	if err := tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM applications WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking application: %w", err)
+	}
+	if exists {
+		return ErrApplicationModified
+	}
+	return ErrApplicationNotFound.WithMessage("Application %s not found", id)
+}
+
+// applicationFields lists the scan destinations of applicationColumns
+func applicationFields(app *types.Application) []interface{} {
+	return []interface{}{
+		&app.ID, &app.SubscriberID, &app.JobID, &app.Source, &app.Title, &app.Company, &app.Country, &app.Status, &app.Notes,
+		&app.AppliedAt, &app.CreatedAt, &app.UpdatedAt,
+	}
+}
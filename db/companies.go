@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// ErrCompanyNotFound is returned when no company has the given ID
+var ErrCompanyNotFound = apperr.NotFound("company_not_found", "Company not found")
+
+const companyColumns = `id, name, COALESCE(website, '') AS website, COALESCE(description, '') AS description, created_at, updated_at`
+
+// CreateCompany records a company, a company with the same name is a conflict
+func (db *DBConnector) CreateCompany(ctx context.Context, input types.CompanyInput) (types.Company, error) {
+	ctx, span := startSpan(ctx, "DBConnector.CreateCompany", "INSERT", "companies")
+	defer span.End()
+
+	const query = `
+		INSERT INTO companies (name, website, description)
+		VALUES ($1, NULLIF($2, ''), NULLIF($3, ''))
+		RETURNING ` + companyColumns
+	var company types.Company
+	err := db.DB.QueryRowxContext(ctx, query, input.Name, input.Website, input.Description).StructScan(&company)
+	if err != nil {
+		recordError(span, err)
+		return types.Company{}, fmt.Errorf("error creating company: %w", classify(err, nil))
+	}
+	return company, nil
+}
+
+// GetCompany returns the company with the given ID or ErrCompanyNotFound
+func (db *DBConnector) GetCompany(ctx context.Context, id uuid.UUID) (types.Company, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetCompany", "SELECT", "companies")
+	defer span.End()
+
+	var company types.Company
+	err := db.DB.QueryRowxContext(ctx, `SELECT `+companyColumns+` FROM companies WHERE id = $1`, id).StructScan(&company)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Company{}, classify(err, ErrCompanyNotFound.WithMessage("Company %s not found", id))
+		}
+		recordError(span, err)
+		return types.Company{}, fmt.Errorf("error getting company: %w", err)
+	}
+	return company, nil
+}
+
+// ListCompanies returns the companies with the given IDs, or every company when ids is nil, by name
+func (db *DBConnector) ListCompanies(ctx context.Context, ids []uuid.UUID) ([]types.Company, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ListCompanies", "SELECT", "companies")
+	defer span.End()
+
+	var (
+		rows *sqlx.Rows
+		err  error
+	)
+	if ids == nil {
+		rows, err = db.DB.QueryxContext(ctx, `SELECT `+companyColumns+` FROM companies ORDER BY name`)
+	} else {
+		rows, err = db.DB.QueryxContext(ctx, `SELECT `+companyColumns+` FROM companies WHERE id = ANY($1) ORDER BY name`, pq.Array(ids))
+	}
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing companies: %w", err)
+	}
+	defer rows.Close()
+
+	companies := []types.Company{}
+	for rows.Next() {
+		var company types.Company
+		if err := rows.StructScan(&company); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning company: %w", err)
+		}
+		companies = append(companies, company)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over companies: %w", err)
+	}
+	return companies, nil
+}
+
+// ReplaceCompany overwrites every field of a company
+func (db *DBConnector) ReplaceCompany(ctx context.Context, id uuid.UUID, input types.CompanyInput) (types.Company, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ReplaceCompany", "UPDATE", "companies")
+	defer span.End()
+
+	const query = `
+		UPDATE companies
+		SET name = $2, website = NULLIF($3, ''), description = NULLIF($4, '')
+		WHERE id = $1
+		RETURNING ` + companyColumns
+	var company types.Company
+	err := db.DB.QueryRowxContext(ctx, query, id, input.Name, input.Website, input.Description).StructScan(&company)
+	if err != nil {
+		recordError(span, err)
+		return types.Company{}, fmt.Errorf("error replacing company: %w", classify(err, ErrCompanyNotFound.WithMessage("Company %s not found", id)))
+	}
+	return company, nil
+}
+
+// DeleteCompany removes a company, its jobs are kept without a company
+func (db *DBConnector) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DBConnector.DeleteCompany", "DELETE", "companies")
+	defer span.End()
+
+	res, err := db.DB.ExecContext(ctx, `DELETE FROM companies WHERE id = $1`, id)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error deleting company: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrCompanyNotFound.WithMessage("Company %s not found", id)
+	}
+	return nil
+}
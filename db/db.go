@@ -3,22 +3,77 @@ package db
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
+	"jobs/logging"
 	"jobs/types"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer("jobs/db")
+
 // Database defines the interface for database operations
 type Database interface {
-	RecordSubscriber(ctx context.Context, input *types.SubscribeInput) (uuid.UUID, error)
-	GetInternalJobs(ctx context.Context, input *types.JobsInput) ([]uuid.UUID, error)
+	SaveSubscriber(ctx context.Context, input types.SubscriberInput) (types.Subscriber, bool, error)
+	SaveSubscribers(ctx context.Context, inputs []types.SubscriberInput, chunkSize int, atomic bool) ([]SubscriberSaveResult, error)
+	GetSubscriber(ctx context.Context, id uuid.UUID) (types.Subscriber, error)
+	GetInternalJobs(ctx context.Context, query types.JobQuery) ([]types.Job, error)
+	CreateJob(ctx context.Context, input types.JobInput) (types.Job, error)
+	GetJob(ctx context.Context, id uuid.UUID) (types.Job, error)
+	// ReplaceJob, PatchJob and DeleteJob only apply to the job updated at version, when set
+	ReplaceJob(ctx context.Context, id uuid.UUID, input types.JobInput, version *time.Time) (types.Job, error)
+	PatchJob(ctx context.Context, id uuid.UUID, patch types.JobPatch, version *time.Time) (types.Job, error)
+	DeleteJob(ctx context.Context, id uuid.UUID, version *time.Time) error
+	// ExpireJobs expires the open jobs past their expiration, or posted maxAge ago when maxAge is set
+	ExpireJobs(ctx context.Context, now time.Time, maxAge time.Duration) (int64, error)
+	CreateCompany(ctx context.Context, input types.CompanyInput) (types.Company, error)
+	GetCompany(ctx context.Context, id uuid.UUID) (types.Company, error)
+	// ListCompanies returns the companies with the given IDs, or every company when ids is nil
+	ListCompanies(ctx context.Context, ids []uuid.UUID) ([]types.Company, error)
+	ReplaceCompany(ctx context.Context, id uuid.UUID, input types.CompanyInput) (types.Company, error)
+	DeleteCompany(ctx context.Context, id uuid.UUID) error
+	CreateSavedSearch(ctx context.Context, subscriberID uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, id uuid.UUID) (types.SavedSearch, error)
+	ListSavedSearches(ctx context.Context, subscriberID uuid.UUID) ([]types.SavedSearch, error)
+	ReplaceSavedSearch(ctx context.Context, id uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id uuid.UUID) error
+	// AcknowledgeSubscriberJobs and AcknowledgeSavedSearchJobs advance a high-water mark to seenUntil, never back,
+	// and return the resulting mark
+	AcknowledgeSubscriberJobs(ctx context.Context, id uuid.UUID, seenUntil time.Time) (time.Time, error)
+	AcknowledgeSavedSearchJobs(ctx context.Context, id uuid.UUID, seenUntil time.Time) (time.Time, error)
+	CreateApplication(ctx context.Context, app types.Application) (types.Application, error)
+	GetApplication(ctx context.Context, id uuid.UUID) (types.Application, error)
+	// ListApplications returns the applications of a subscriber, in status when set
+	ListApplications(ctx context.Context, subscriberID uuid.UUID, status string) ([]types.Application, error)
+	// UpdateApplication only applies to the application still in fromStatus, the status change is recorded with note
+	UpdateApplication(ctx context.Context, fromStatus string, app types.Application, note string) (types.Application, error)
+	DeleteApplication(ctx context.Context, id uuid.UUID) error
+	ListApplicationEvents(ctx context.Context, id uuid.UUID) ([]types.ApplicationEvent, error)
+	CountApplications(ctx context.Context, subscriberID uuid.UUID) (map[string]int, error)
+	// SaveJobAction bookmarks or dismisses a job, action is types.ActionBookmark or types.ActionDismiss
+	SaveJobAction(ctx context.Context, action string, a types.JobAction) (types.JobAction, error)
+	DeleteJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) error
+	ListJobActions(ctx context.Context, action string, subscriberID uuid.UUID) ([]types.JobAction, error)
+	// ListExternalSearches returns the titles and countries subscribers care about
+	ListExternalSearches(ctx context.Context) ([]types.ExternalSearch, error)
+	// UpsertExternalJobs stores the jobs a source served under their ExternalJobID
+	UpsertExternalJobs(ctx context.Context, source string, jobs []types.Job) (int64, error)
+	GetExternalJob(ctx context.Context, id uuid.UUID) (types.Job, error)
+	// GetExternalJobs returns the stored external jobs matching the query titles, countries and high-water mark,
+	// last seen after seenAfter
+	GetExternalJobs(ctx context.Context, query types.JobQuery, seenAfter time.Time) ([]types.Job, error)
 	Close() error
 }
 
@@ -27,141 +82,436 @@ type DBConnector struct {
 	Logger *zap.Logger
 }
 
-// RecordSubscriber records a new subscriber in the database.
+const subscriberColumns = `
+	id,
+	user_name,
+	email,
+	COALESCE(job_titles, '{}'),
+	COALESCE(preferred_countries, '{}'),
+	COALESCE(salary_min, 0),
+	salary_currency,
+	salary_period,
+	followed_companies,
+	blocked_companies,
+	locations,
+	last_seen_at,
+	created_at,
+	updated_at`
+
+// SaveSubscriber records a new subscriber or replaces the preferences of the one with the same email.
 //
-// It takes a context and a SubscribeInput struct as parameters.
-// The SubscribeInput struct contains the subscriber's name, email, job titles, and salary minimum.
-// It returns the ID of the newly recorded subscriber and an error if any.
-func (db *DBConnector) RecordSubscriber(ctx context.Context, input *types.SubscribeInput) (uuid.UUID, error) {
-	now := time.Now().UTC()
+// It returns the stored subscriber and whether it was created rather than updated.
+func (db *DBConnector) SaveSubscriber(ctx context.Context, input types.SubscriberInput) (types.Subscriber, bool, error) {
+	ctx, span := startSpan(ctx, "DBConnector.SaveSubscriber", "INSERT", "subscribers")
+	defer span.End()
 
-	jobTitles := fmt.Sprintf(`{"%s"}`, strings.Join(input.JobTitles, `","`))
-	if len(input.JobTitles) == 0 {
-		jobTitles = "{}"
-	}
-	countries := fmt.Sprintf(`{"%s"}`, strings.Join(input.PreferredCountries, `","`))
-	if len(input.PreferredCountries) == 0 {
-		countries = "{}"
-	}
+	now := time.Now().UTC()
+	// xmax is only set on rows that were updated by the upsert
 	const query = `
-        INSERT INTO subscribers (user_name, email, job_titles, salary_min, preferred_countries, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO subscribers (user_name, email, job_titles, salary_min, preferred_countries, created_at, updated_at, followed_companies, blocked_companies,
+            salary_currency, salary_period, locations)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)
         ON CONFLICT (email)
         DO UPDATE SET
             user_name = EXCLUDED.user_name,
             job_titles = EXCLUDED.job_titles,
 			salary_min = EXCLUDED.salary_min,
             updated_at = EXCLUDED.updated_at,
-			preferred_countries = EXCLUDED.preferred_countries
-        RETURNING id;
+			preferred_countries = EXCLUDED.preferred_countries,
+			followed_companies = EXCLUDED.followed_companies,
+			blocked_companies = EXCLUDED.blocked_companies,
+			salary_currency = EXCLUDED.salary_currency,
+			salary_period = EXCLUDED.salary_period,
+			locations = EXCLUDED.locations
+        RETURNING ` + subscriberColumns + `, (xmax = 0);
     `
-	var id uuid.UUID
-	err := db.DB.QueryRowContext(ctx, query, input.Name, input.Email, jobTitles, input.SalaryMin, countries, now, now).Scan(&id)
+	locations, err := locationsJSON(input.Locations)
 	if err != nil {
-		return uuid.Nil, fmt.Errorf("error upserting subscriber: %w", err)
+		recordError(span, err)
+		return types.Subscriber{}, false, fmt.Errorf("error encoding locations: %w", err)
 	}
+	var (
+		sub     types.Subscriber
+		created bool
+	)
+	row := db.DB.QueryRowContext(ctx, query, input.Name, input.Email, pq.Array(input.JobTitles), input.SalaryMin, pq.Array(input.Countries), now, now,
+		uuidArray(input.FollowedCompanies), uuidArray(input.BlockedCompanies), input.SalaryCurrency, input.SalaryPeriod, locations)
+	err = row.Scan(append(subscriberFields(&sub), &created)...)
+	if err != nil {
+		recordError(span, err)
+		return types.Subscriber{}, false, fmt.Errorf("error upserting subscriber: %w", classify(err, nil))
+	}
+
+	return sub, created, nil
+}
 
-	return id, nil
+// SubscriberSaveResult is the outcome of one subscriber of SaveSubscribers
+type SubscriberSaveResult struct {
+	ID      uuid.UUID
+	Created bool
+	Err     error
 }
 
-func (db *DBConnector) GetInternalJobs(ctx context.Context, input *types.JobsInput) ([]uuid.UUID, error) {
-	originalJobTitles := input.JobTitles
-	originalCountries := input.PreferredCountries
+// SaveSubscribers upserts subscribers in chunks of chunkSize inside one transaction. Emails must be unique.
+//
+// A failing chunk is retried row by row so that only the failing rows report an error, the others are still saved.
+// With atomic set, nothing is saved if any row failed. The returned error is only set when the transaction failed.
+func (db *DBConnector) SaveSubscribers(ctx context.Context, inputs []types.SubscriberInput, chunkSize int, atomic bool) ([]SubscriberSaveResult, error) {
+	ctx, span := startSpan(ctx, "DBConnector.SaveSubscribers", "INSERT", "subscribers")
+	defer span.End()
+	span.SetAttributes(attribute.Int("db.batch.size", len(inputs)))
 
-	if err := getUserInfo(ctx, db.DB, input); err != nil {
-		return nil, fmt.Errorf("error getting user info: %w", err)
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error starting transaction: %w", err)
 	}
-	batchSize := 20
-	if originalJobTitles != nil {
-		input.JobTitles = originalJobTitles
+	// Rolling back after a commit is a no-op
+	defer func() { _ = tx.Rollback() }()
+
+	results := make([]SubscriberSaveResult, len(inputs))
+	failed := 0
+	for start := 0; start < len(inputs); start += chunkSize {
+		end := min(start+chunkSize, len(inputs))
+		if err := upsertSubscribers(ctx, tx, inputs[start:end], results[start:end]); err == nil {
+			continue
+		}
+		for i := start; i < end; i++ {
+			if err := upsertSubscribers(ctx, tx, inputs[i:i+1], results[i:i+1]); err != nil {
+				results[i] = SubscriberSaveResult{Err: classify(err, nil)}
+				failed++
+			}
+		}
 	}
-	if originalCountries != nil {
-		input.PreferredCountries = originalCountries
+	span.SetAttributes(attribute.Int("db.batch.failed", failed))
+
+	if atomic && failed > 0 {
+		return results, nil
 	}
-	db.Logger.Sugar().Infof("input %v", input)
-	i, err := getInternalJobs(ctx, db.DB, input, batchSize)
-	if err != nil {
-		return nil, fmt.Errorf("error getting internal jobs: %w", err)
+	if err := tx.Commit(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error committing subscribers: %w", err)
 	}
-	db.Logger.Sugar().Infof("Retrieved %v internal jobs", i)
-	return i, nil
+	return results, nil
 }
 
-// getUserInfo retrieves user information from the subscribers table based on the provided input ID.
-//
-// It takes a context, a database connection, and a JobsInput struct as parameters.
-// Returns an error if the user information cannot be retrieved.
-func getUserInfo(ctx context.Context, db *sqlx.DB, input *types.JobsInput) error {
-	jobTitles := []string{}
-	countries := []string{}
-	const query = `
-		SELECT 
-			COALESCE(job_titles, '{}'),
-			COALESCE(preferred_countries, '{}')
-		FROM 
-			subscribers
-		WHERE id = $1
-	`
-	err := db.QueryRowxContext(ctx, query, input.UserID).Scan(pq.Array(&jobTitles), pq.Array(&countries))
+// subscriberParams is the number of query parameters of one upserted subscriber
+const subscriberParams = 12
+
+// upsertSubscribers saves one chunk with a single statement inside a savepoint, so that a failure
+// leaves the transaction usable
+func upsertSubscribers(ctx context.Context, tx *sqlx.Tx, inputs []types.SubscriberInput, results []SubscriberSaveResult) error {
+	now := time.Now().UTC()
+	values := make([]string, 0, len(inputs))
+	args := make([]interface{}, 0, len(inputs)*subscriberParams)
+	for i, input := range inputs {
+		params := make([]string, subscriberParams)
+		for j := range params {
+			params[j] = fmt.Sprintf("$%d", i*subscriberParams+j+1)
+		}
+		values = append(values, "("+strings.Join(params, ", ")+")")
+		locations, err := locationsJSON(input.Locations)
+		if err != nil {
+			return fmt.Errorf("error encoding locations: %w", err)
+		}
+		args = append(args, input.Name, input.Email, pq.Array(input.JobTitles), input.SalaryMin, pq.Array(input.Countries), now, now,
+			uuidArray(input.FollowedCompanies), uuidArray(input.BlockedCompanies), input.SalaryCurrency, input.SalaryPeriod, locations)
+	}
+	query := `
+        INSERT INTO subscribers (user_name, email, job_titles, salary_min, preferred_countries, created_at, updated_at, followed_companies, blocked_companies,
+            salary_currency, salary_period, locations)
+        VALUES ` + strings.Join(values, ", ") + `
+        ON CONFLICT (email)
+        DO UPDATE SET
+            user_name = EXCLUDED.user_name,
+            job_titles = EXCLUDED.job_titles,
+			salary_min = EXCLUDED.salary_min,
+            updated_at = EXCLUDED.updated_at,
+			preferred_countries = EXCLUDED.preferred_countries,
+			followed_companies = EXCLUDED.followed_companies,
+			blocked_companies = EXCLUDED.blocked_companies,
+			salary_currency = EXCLUDED.salary_currency,
+			salary_period = EXCLUDED.salary_period,
+			locations = EXCLUDED.locations
+        RETURNING email, id, (xmax = 0);
+    `
+
+	if _, err := tx.ExecContext(ctx, "SAVEPOINT subscribers_chunk"); err != nil {
+		return err
+	}
+	saved, err := scanSavedSubscribers(ctx, tx, query, args)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("user with ID %v not found", input.UserID)
+		if _, rbErr := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT subscribers_chunk"); rbErr != nil {
+			return fmt.Errorf("%w (rollback failed: %v)", err, rbErr)
 		}
-		return fmt.Errorf("error getting user info: %w", err)
+		return err
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT subscribers_chunk"); err != nil {
+		return err
 	}
 
-	input.JobTitles = jobTitles
-	input.PreferredCountries = countries
+	for i, input := range inputs {
+		results[i] = saved[input.Email]
+	}
 	return nil
 }
 
-func getInternalJobs(ctx context.Context, db *sqlx.DB, input *types.JobsInput, batchSize int) ([]uuid.UUID, error) {
-	var allJobIDs []uuid.UUID
-	offset := 0
+// scanSavedSubscribers runs the upsert and indexes the returned rows by email
+func scanSavedSubscribers(ctx context.Context, tx *sqlx.Tx, query string, args []interface{}) (map[string]SubscriberSaveResult, error) {
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	saved := map[string]SubscriberSaveResult{}
+	for rows.Next() {
+		var (
+			email  string
+			result SubscriberSaveResult
+		)
+		if err := rows.Scan(&email, &result.ID, &result.Created); err != nil {
+			return nil, err
+		}
+		saved[email] = result
+	}
+	return saved, rows.Err()
+}
+
+// GetSubscriber returns the subscriber with the given ID or ErrSubscriberNotFound
+func (db *DBConnector) GetSubscriber(ctx context.Context, id uuid.UUID) (types.Subscriber, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetSubscriber", "SELECT", "subscribers")
+	defer span.End()
+
+	const query = `SELECT ` + subscriberColumns + ` FROM subscribers WHERE id = $1`
+	var sub types.Subscriber
+	err := db.DB.QueryRowContext(ctx, query, id).Scan(subscriberFields(&sub)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Subscriber{}, classify(err, ErrSubscriberNotFound.WithMessage("Subscriber %s not found", id))
+		}
+		recordError(span, err)
+		return types.Subscriber{}, fmt.Errorf("error getting subscriber: %w", err)
+	}
+	return sub, nil
+}
+
+// AcknowledgeSubscriberJobs advances the high-water mark of a subscriber to seenUntil unless it is already past it
+func (db *DBConnector) AcknowledgeSubscriberJobs(ctx context.Context, id uuid.UUID, seenUntil time.Time) (time.Time, error) {
+	ctx, span := startSpan(ctx, "DBConnector.AcknowledgeSubscriberJobs", "UPDATE", "subscribers")
+	defer span.End()
+
+	// GREATEST ignores the NULL mark of a subscriber who never acknowledged any job
+	const query = `UPDATE subscribers SET last_seen_at = GREATEST(last_seen_at, $2) WHERE id = $1 RETURNING last_seen_at`
+	var mark time.Time
+	if err := db.DB.QueryRowContext(ctx, query, id, seenUntil.UTC()).Scan(&mark); err != nil {
+		recordError(span, err)
+		return time.Time{}, fmt.Errorf("error acknowledging subscriber jobs: %w",
+			classify(err, ErrSubscriberNotFound.WithMessage("Subscriber %s not found", id)))
+	}
+	return mark, nil
+}
+
+// subscriberFields lists the scan destinations of subscriberColumns
+func subscriberFields(sub *types.Subscriber) []interface{} {
+	return []interface{}{
+		&sub.ID, &sub.Name, &sub.Email, pq.Array(&sub.JobTitles), pq.Array(&sub.Countries), &sub.SalaryMin,
+		&sub.SalaryCurrency, &sub.SalaryPeriod, pq.Array(&sub.FollowedCompanies), pq.Array(&sub.BlockedCompanies), jsonColumn{&sub.Locations},
+		&sub.LastSeenAt, &sub.CreatedAt, &sub.UpdatedAt,
+	}
+}
+
+// uuidArray passes ids as a Postgres array, nil as an empty one for the NOT NULL columns
+func uuidArray(ids []uuid.UUID) interface{} {
+	if ids == nil {
+		ids = []uuid.UUID{}
+	}
+	return pq.Array(ids)
+}
+
+// locationsJSON passes location preferences as a JSONB array, nil as an empty one
+func locationsJSON(locations []types.LocationPreference) (string, error) {
+	if locations == nil {
+		locations = []types.LocationPreference{}
+	}
+	b, err := json.Marshal(locations)
+	return string(b), err
+}
+
+// jsonColumn scans a JSON column into the value it points to
+type jsonColumn struct {
+	dest interface{}
+}
+
+func (c jsonColumn) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, c.dest)
+	case string:
+		return json.Unmarshal([]byte(v), c.dest)
+	case nil:
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T into a JSON column", src)
+}
+
+// headlineOptions configure the snippets of jobs matching a text search
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2`
+
+// GetInternalJobs returns the jobs matching the query, jobs of followed companies first, then by relevance
+// to the text search when there is one and newest first.
+// Salaries are converted with the query SalaryFactors: the top of a job salary range must reach SalaryMin and
+// its bottom must not exceed SalaryMax. Jobs must match one of the query Locations, if any, and mention one of
+// its Skills in their title or description, if any.
+// ALL in JobTitles or Countries matches every job, and jobs tagged ALL match every title or country.
+// The query filters are used as is, the subscriber preferences are resolved by the caller.
+// Only open jobs are returned unless the query lists statuses, jobs of blocked companies never are.
+// Since the last seen job, only the jobs first seen after the query LastSeenAt are returned.
+func (db *DBConnector) GetInternalJobs(ctx context.Context, query types.JobQuery) ([]types.Job, error) {
+	ctx, span := tracer.Start(ctx, "DBConnector.GetInternalJobs")
+	defer span.End()
+
+	batchSize := 20
+	db.log(ctx).Debugf("query %+v", query)
+	jobs, err := getInternalJobs(ctx, db.DB, query, batchSize)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error getting internal jobs: %w", err)
+	}
+	span.SetAttributes(attribute.Int("jobs.count", len(jobs)))
+	db.log(ctx).Infof("Retrieved %v internal jobs", len(jobs))
+	return jobs, nil
+}
+
+func getInternalJobs(ctx context.Context, db *sqlx.DB, input types.JobQuery, batchSize int) ([]types.Job, error) {
+	var allJobs []types.Job
 	const query = `
-        SELECT
-            id
+        WITH q AS (SELECT websearch_to_tsquery('english', $10) AS query)
+        SELECT ` + jobColumns + `,
+            CASE WHEN $10 = '' THEN '' ELSE ts_headline('english', COALESCE(j.description, ''), q.query, '` + headlineOptions + `') END AS snippet
         FROM
-            jobs
+            q, jobs j` + jobCompany + `
         WHERE
-            salary_min >= $1
-            AND title = ANY($3)
-			AND country = ANY($4)
-		ORDER BY posted_date >= $2
+            ($1 = 0 OR COALESCE(j.salary_max, j.salary_min, 0) * ($12::float8[])[array_position($11::text[], j.salary_currency || '/' || j.salary_period)] >= $1)
+            AND ($13 = 0 OR COALESCE(j.salary_min, 0) * ($12::float8[])[array_position($11::text[], j.salary_currency || '/' || j.salary_period)] <= $13)
+            AND j.posted_date >= $2
+            AND ('ALL' = ANY($3) OR j.title = ANY($3) OR j.title = 'ALL')
+			AND ('ALL' = ANY($4) OR j.country = ANY($4) OR j.country = 'ALL')
+			AND j.status = ANY($7::job_status[])
+			AND (j.company_id IS NULL OR j.company_id <> ALL($8::uuid[]))
+			AND ($10 = '' OR j.search @@ q.query)
+			AND ($14::jsonb = '[]' OR EXISTS (
+				SELECT 1 FROM jsonb_to_recordset($14::jsonb) AS l(work_mode text, country text, region text, city text)
+				WHERE (l.work_mode IS NULL OR l.work_mode = j.work_mode::text)
+					AND (l.country IS NULL OR l.country = j.country::text OR j.country = 'ALL')
+					AND (l.region IS NULL OR lower(l.region) = lower(j.region))
+					AND (l.city IS NULL OR lower(l.city) = lower(j.city))))
+			AND (cardinality($15::text[]) = 0 OR EXISTS (
+				SELECT 1 FROM unnest($15::text[]) AS s(skill) WHERE j.search @@ plainto_tsquery('english', s.skill)))
+			AND ($16::timestamp IS NULL OR j.first_seen_at > $16)
+		ORDER BY COALESCE(j.company_id = ANY($9::uuid[]), false) DESC,
+			CASE WHEN $10 = '' THEN 0 ELSE ts_rank(j.search, q.query) END DESC,
+			j.posted_date DESC, j.id
 		LIMIT $5 
 		OFFSET $6
     `
+	statuses := input.Statuses
+	if len(statuses) == 0 {
+		statuses = []string{types.JobOpen}
+	}
+	locations, err := locationsJSON(input.Locations)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding locations: %w", err)
+	}
+	units := make([]string, 0, len(input.SalaryFactors))
+	factors := make([]float64, 0, len(input.SalaryFactors))
+	for unit, factor := range input.SalaryFactors {
+		units = append(units, unit)
+		factors = append(factors, factor)
+	}
 
-	for {
-		rows, err := db.QueryxContext(ctx, query, input.SalaryMin, input.PostedDate, pq.Array(input.JobTitles), pq.Array(input.PreferredCountries), batchSize, offset)
+	args := []interface{}{input.SalaryMin, input.PostedAfter, pq.Array(input.JobTitles), pq.Array(input.Countries), batchSize, 0,
+		pq.Array(statuses), uuidArray(input.BlockedCompanies), uuidArray(input.FollowedCompanies), input.Text, pq.Array(units),
+		pq.Array(factors), input.SalaryMax, locations, stringArray(input.Skills), lastSeen(input)}
+	for offset := 0; ; offset += batchSize {
+		// $6 is the offset of the batch
+		args[5] = offset
+		batch, err := getInternalJobsBatch(ctx, db, query, offset, args)
 		if err != nil {
-			return nil, fmt.Errorf("error executing query: %w", err)
+			return nil, err
 		}
-
-		var batch []uuid.UUID
-		for rows.Next() {
-			var jobID uuid.UUID
-			if err := rows.Scan(&jobID); err != nil {
-				return nil, fmt.Errorf("error scanning row: %w", err)
-			}
-			batch = append(batch, jobID)
+		if len(batch) == 0 {
+			return allJobs, nil
 		}
+		allJobs = append(allJobs, batch...)
+	}
+}
 
-		if err := rows.Err(); err != nil {
-			return nil, fmt.Errorf("error iterating over rows: %w", err)
-		}
+// getInternalJobsBatch runs one batch of the internal jobs query
+func getInternalJobsBatch(ctx context.Context, db *sqlx.DB, query string, offset int, args []interface{}) ([]types.Job, error) {
+	ctx, span := startSpan(ctx, "getInternalJobs", "SELECT", "jobs")
+	defer span.End()
+	span.SetAttributes(attribute.Int("db.query.offset", offset))
 
-		if len(batch) == 0 {
-			break
+	rows, err := db.QueryxContext(ctx, query, args...)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error executing query: %w", classify(err, nil))
+	}
+	defer rows.Close()
+
+	var batch []types.Job
+	for rows.Next() {
+		var job types.Job
+		if err := rows.StructScan(&job); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning row: %w", err)
 		}
+		// Internal jobs do not record skills
+		job.Skills = []string{}
+		batch = append(batch, job)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over rows: %w", err)
+	}
+	return batch, nil
+}
 
-		allJobIDs = append(allJobIDs, batch...)
-		offset += batchSize
+// lastSeen returns the high-water mark of a query listing the jobs since the last seen one, nil otherwise
+func lastSeen(query types.JobQuery) *time.Time {
+	if !query.SinceLastSeen {
+		return nil
 	}
+	if query.LastSeenAt == nil {
+		return &time.Time{}
+	}
+	return utc(query.LastSeenAt)
+}
 
-	return allJobIDs, nil
+// log returns the request-scoped logger, falling back to the connector logger
+func (db *DBConnector) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, db.Logger).Sugar()
 }
 
 func (db *DBConnector) Close() error {
 	return db.DB.Close()
 }
+
+// startSpan starts a client span describing a single query
+func startSpan(ctx context.Context, name, operation, table string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation.name", operation),
+			attribute.String("db.collection.name", table),
+		),
+	)
+}
+
+// recordError marks the span as failed
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"math/rand/v2"
+	"os"
+	"strings"
+	"testing"
+
+	"jobs/types"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+// testDB connects to the database of TEST_DATABASE_URL, created from db_creation, and skips the test without one
+func testDB(t *testing.T) *DBConnector {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := sqlx.Open("postgres", url)
+	if err != nil {
+		t.Fatalf("could not open test database: %v", err)
+	}
+	t.Cleanup(func() { _ = db.Close() })
+	if err := db.Ping(); err != nil {
+		t.Fatalf("could not connect to test database: %v", err)
+	}
+	return &DBConnector{DB: db, Logger: zap.NewNop()}
+}
+
+func TestGetInternalJobsSearch(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	// A made-up word in every description keeps the jobs of other tests and of the sample data out of the results
+	var word strings.Builder
+	word.WriteString("zq")
+	for range 10 {
+		word.WriteByte(byte('a' + rand.IntN(26)))
+	}
+	tag := word.String()
+
+	var ids []string
+	create := func(title, description string) string {
+		job, err := db.CreateJob(ctx, types.JobInput{Title: title, Description: description + " " + tag, Country: "UK",
+			SalaryCurrency: "USD", SalaryPeriod: types.PayYear, Status: types.JobOpen})
+		require.NoError(t, err)
+		ids = append(ids, job.ID.String())
+		return job.Title + ": " + description
+	}
+	payments := create("Backend Developer", "Build payment services in Go")
+	java := create("Sr Java Developer", "Maintain our APIs")
+	// Newer than the Java developer job, listed first without a search
+	pipelines := create("Full Stack Developer", "Pipelines in Python, some Java")
+	t.Cleanup(func() { _, _ = db.DB.Exec(`DELETE FROM jobs WHERE id = ANY($1::uuid[])`, pq.Array(ids)) })
+
+	tests := []struct {
+		name            string
+		text            string
+		expectedJobs    []string
+		expectedSnippet string
+		// unordered leaves the order of jobs ranked alike to Postgres
+		unordered bool
+	}{
+		{name: "Titles outrank descriptions", text: "java", expectedJobs: []string{java, pipelines}},
+		{name: "Plurals match", text: "payments", expectedJobs: []string{payments}, expectedSnippet: "<mark>payment</mark>"},
+		{name: "Every word must match", text: "java python", expectedJobs: []string{pipelines}},
+		{name: "Excluded words", text: "developer -payment", expectedJobs: []string{java, pipelines}, unordered: true},
+		{name: "Phrases", text: `"payment services"`, expectedJobs: []string{payments}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			found, err := db.GetInternalJobs(ctx, types.JobQuery{
+				Text: tag + " " + tt.text, JobTitles: []string{types.Wildcard}, Countries: []string{types.Wildcard},
+			})
+			require.NoError(t, err)
+
+			var jobs []string
+			for _, job := range found {
+				jobs = append(jobs, job.Title+": "+strings.TrimSuffix(job.Description, " "+tag))
+				if tt.expectedSnippet != "" {
+					assert.Contains(t, job.Snippet, tt.expectedSnippet)
+				}
+			}
+			if tt.unordered {
+				assert.ElementsMatch(t, tt.expectedJobs, jobs)
+			} else {
+				assert.Equal(t, tt.expectedJobs, jobs)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/lib/pq"
+)
+
+// Postgres error codes that are caused by client input
+const (
+	pqInvalidTextRepresentation = "22P02"
+	pqUniqueViolation           = "23505"
+	pqForeignKeyViolation       = "23503"
+	pqCheckViolation            = "23514"
+)
+
+var (
+	// ErrInvalidValue is returned when Postgres rejects a value, e.g. a job title outside the job_title enum
+	ErrInvalidValue = apperr.Validation("invalid_value", "A value is not supported")
+	// ErrConflict is returned when a row violates a unique constraint
+	ErrConflict = apperr.Conflict("conflict", "The resource already exists")
+	// ErrUnknownReference is returned when a row references a missing one, e.g. a job of an unknown company
+	ErrUnknownReference = apperr.Validation("unknown_reference", "A referenced resource does not exist")
+)
+
+// enumFields maps Postgres enum types to the API field that carries them
+var enumFields = map[string]string{
+	"job_title":              "job_titles",
+	"country":                "country",
+	"job_status":             "status",
+	"pay_period":             "salary_period",
+	"work_mode":              "work_mode",
+	"notification_channel":   "channel",
+	"notification_frequency": "frequency",
+	"application_status":     "status",
+}
+
+// referenceFields maps foreign key constraints to the API field that carries the reference
+var referenceFields = map[string]string{
+	"jobs_company_id_fkey":              "company_id",
+	"saved_searches_subscriber_id_fkey": "subscriber_id",
+	"applications_subscriber_id_fkey":   "subscriber_id",
+	"job_bookmarks_subscriber_id_fkey":  "subscriber_id",
+	"job_dismissals_subscriber_id_fkey": "subscriber_id",
+}
+
+// checkFields describes the check constraints by the API field they restrict
+var checkFields = map[string]types.FieldError{
+	"jobs_salary_range_check": {Field: "salary_max", Code: "gtefield", Message: "must be greater than or equal to salary_min"},
+}
+
+// classify turns driver errors caused by client input into domain errors.
+// sql.ErrNoRows becomes notFound when given; any other error is returned unchanged.
+func classify(err error, notFound *apperr.Error) error {
+	if notFound != nil && errors.Is(err, sql.ErrNoRows) {
+		return notFound.Wrap(err)
+	}
+
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) {
+		return err
+	}
+	switch pqErr.Code {
+	case pqInvalidTextRepresentation:
+		return invalidValue(pqErr).Wrap(err)
+	case pqUniqueViolation:
+		return ErrConflict.Wrap(err)
+	case pqForeignKeyViolation:
+		return unknownReference(pqErr).Wrap(err)
+	case pqCheckViolation:
+		e := ErrInvalidValue
+		if fe, ok := checkFields[pqErr.Constraint]; ok {
+			e = ErrInvalidValue.WithMessage("Invalid %s", fe.Field)
+			e.Fields = []types.FieldError{fe}
+		}
+		return e.Wrap(err)
+	}
+	return err
+}
+
+// invalidValue describes an invalid enum value, e.g. `invalid input value for enum job_title: "Chef"`
+func invalidValue(pqErr *pq.Error) *apperr.Error {
+	rest, ok := strings.CutPrefix(pqErr.Message, "invalid input value for enum ")
+	if !ok {
+		return ErrInvalidValue
+	}
+	enum, value, _ := strings.Cut(rest, ": ")
+	field, ok := enumFields[enum]
+	if !ok {
+		field = enum
+	}
+	e := ErrInvalidValue.WithMessage("Unsupported %s value %s", field, value)
+	e.Fields = []types.FieldError{{
+		Field:   field,
+		Code:    "enum",
+		Message: fmt.Sprintf("%s is not a supported %s", value, strings.ReplaceAll(enum, "_", " ")),
+	}}
+	return e
+}
+
+// unknownReference names the field referencing a missing row, when the constraint is known
+func unknownReference(pqErr *pq.Error) *apperr.Error {
+	field, ok := referenceFields[pqErr.Constraint]
+	if !ok {
+		return ErrUnknownReference
+	}
+	e := ErrUnknownReference.WithMessage("Unknown %s", field)
+	e.Fields = []types.FieldError{{Field: field, Code: "exists", Message: "does not reference an existing resource"}}
+	return e
+}
@@ -0,0 +1,110 @@
+package db
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name           string
+		err            error
+		notFound       *apperr.Error
+		expectedKind   apperr.Kind
+		expectedCode   string
+		expectedDetail string
+		expectedFields []types.FieldError
+	}{
+		{
+			name:           "No rows with a not found error",
+			err:            sql.ErrNoRows,
+			notFound:       ErrSubscriberNotFound,
+			expectedKind:   apperr.KindNotFound,
+			expectedCode:   "subscriber_not_found",
+			expectedDetail: "Subscriber not found",
+		},
+		{
+			name:           "Invalid enum value",
+			err:            &pq.Error{Code: pqInvalidTextRepresentation, Message: `invalid input value for enum job_title: "Chef"`},
+			expectedKind:   apperr.KindValidation,
+			expectedCode:   "invalid_value",
+			expectedDetail: `Unsupported job_titles value "Chef"`,
+			expectedFields: []types.FieldError{{Field: "job_titles", Code: "enum", Message: `"Chef" is not a supported job title`}},
+		},
+		{
+			name:           "Invalid work mode",
+			err:            &pq.Error{Code: pqInvalidTextRepresentation, Message: `invalid input value for enum work_mode: "office"`},
+			expectedKind:   apperr.KindValidation,
+			expectedCode:   "invalid_value",
+			expectedDetail: `Unsupported work_mode value "office"`,
+			expectedFields: []types.FieldError{{Field: "work_mode", Code: "enum", Message: `"office" is not a supported work mode`}},
+		},
+		{
+			name:           "Invalid text representation",
+			err:            &pq.Error{Code: pqInvalidTextRepresentation, Message: `invalid input syntax for type uuid: "x"`},
+			expectedKind:   apperr.KindValidation,
+			expectedCode:   "invalid_value",
+			expectedDetail: "A value is not supported",
+		},
+		{
+			name:           "Unique violation",
+			err:            &pq.Error{Code: pqUniqueViolation, Message: `duplicate key value violates unique constraint "subscribers_email_key"`},
+			expectedKind:   apperr.KindConflict,
+			expectedCode:   "conflict",
+			expectedDetail: "The resource already exists",
+		},
+		{
+			name:           "Foreign key violation",
+			err:            &pq.Error{Code: pqForeignKeyViolation, Constraint: "jobs_company_id_fkey"},
+			expectedKind:   apperr.KindValidation,
+			expectedCode:   "unknown_reference",
+			expectedDetail: "Unknown company_id",
+			expectedFields: []types.FieldError{{Field: "company_id", Code: "exists", Message: "does not reference an existing resource"}},
+		},
+		{
+			name:           "Check violation",
+			err:            &pq.Error{Code: pqCheckViolation, Constraint: "jobs_salary_range_check"},
+			expectedKind:   apperr.KindValidation,
+			expectedCode:   "invalid_value",
+			expectedDetail: "Invalid salary_max",
+			expectedFields: []types.FieldError{{Field: "salary_max", Code: "gtefield", Message: "must be greater than or equal to salary_min"}},
+		},
+		{
+			name:           "Foreign key violation of an unknown constraint",
+			err:            &pq.Error{Code: pqForeignKeyViolation, Constraint: "other_fkey"},
+			expectedKind:   apperr.KindValidation,
+			expectedCode:   "unknown_reference",
+			expectedDetail: "A referenced resource does not exist",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classify(tt.err, tt.notFound)
+
+			e, ok := apperr.From(err)
+			assert.True(t, ok)
+			assert.Equal(t, tt.expectedKind, e.Kind)
+			assert.Equal(t, tt.expectedCode, e.Code)
+			assert.Equal(t, tt.expectedDetail, e.Message)
+			assert.Equal(t, tt.expectedFields, e.Fields)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestClassifyKeepsOtherErrors(t *testing.T) {
+	other := &pq.Error{Code: "08006", Message: "connection failure"}
+	assert.Same(t, other, classify(other, ErrSubscriberNotFound))
+
+	// Without a not found error, no rows is left to the caller
+	assert.Equal(t, sql.ErrNoRows, classify(sql.ErrNoRows, nil))
+	assert.False(t, errors.Is(classify(sql.ErrNoRows, nil), ErrSubscriberNotFound))
+}
@@ -0,0 +1,253 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const externalJobColumns = `
+	id,
+	title,
+	country,
+	COALESCE(region, '') AS region,
+	COALESCE(city, '') AS city,
+	COALESCE(work_mode::text, '') AS work_mode,
+	COALESCE(company, '') AS company,
+	salary_min,
+	salary_currency,
+	salary_period,
+	skills,
+	first_seen_at`
+
+// ListExternalSearches returns the titles and countries of the subscriber preferences and saved searches, once each.
+// The empty filters of a saved search fall back to the subscriber preferences.
+func (db *DBConnector) ListExternalSearches(ctx context.Context) ([]types.ExternalSearch, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ListExternalSearches", "SELECT", "subscribers")
+	defer span.End()
+
+	const query = `
+		WITH filters AS (
+			SELECT job_titles, preferred_countries AS countries FROM subscribers
+			UNION ALL
+			SELECT
+				CASE WHEN cardinality(ss.job_titles) = 0 THEN s.job_titles ELSE ss.job_titles END,
+				CASE WHEN cardinality(ss.countries) = 0 THEN s.preferred_countries ELSE ss.countries END
+			FROM saved_searches ss JOIN subscribers s ON s.id = ss.subscriber_id
+		)
+		SELECT DISTINCT t::text, COALESCE(c::text, '')
+		FROM filters f
+		CROSS JOIN LATERAL unnest(f.job_titles) AS t
+		LEFT JOIN LATERAL unnest(f.countries) AS c ON true
+		ORDER BY 1, 2`
+	rows, err := db.DB.QueryContext(ctx, query)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing external searches: %w", err)
+	}
+	defer rows.Close()
+
+	var searches []types.ExternalSearch
+	for rows.Next() {
+		var search types.ExternalSearch
+		if err := rows.Scan(&search.Title, &search.Country); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning external search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over external searches: %w", err)
+	}
+	return searches, nil
+}
+
+// UpsertExternalJobs stores the jobs a source served in one transaction. Jobs already stored keep their ID and
+// first-seen time, their other fields, location and salary included, are refreshed. The seen times are taken when
+// each row is written, like the jobs trigger does, so that a subscriber acknowledging the jobs while the source was
+// fetched still gets the new ones. It returns the number of stored jobs.
+func (db *DBConnector) UpsertExternalJobs(ctx context.Context, source string, jobs []types.Job) (int64, error) {
+	ctx, span := startSpan(ctx, "DBConnector.UpsertExternalJobs", "INSERT", "external_jobs")
+	defer span.End()
+	span.SetAttributes(attribute.String("jobs.source", source), attribute.Int("db.batch.size", len(jobs)))
+
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("error starting transaction: %w", err)
+	}
+	// Rolling back after a commit is a no-op
+	defer func() { _ = tx.Rollback() }()
+
+	const query = `
+		INSERT INTO external_jobs (id, source, external_key, title, country, region, city, work_mode, company,
+			salary_min, salary_currency, salary_period, skills, first_seen_at, last_seen_at)
+		VALUES ($13, $1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), NULLIF($7, '')::work_mode, NULLIF($8, ''),
+			$9, $10, $11, $12, clock_timestamp() AT TIME ZONE 'UTC', clock_timestamp() AT TIME ZONE 'UTC')
+		ON CONFLICT (source, external_key)
+		DO UPDATE SET
+			title = EXCLUDED.title,
+			country = EXCLUDED.country,
+			region = EXCLUDED.region,
+			city = EXCLUDED.city,
+			work_mode = EXCLUDED.work_mode,
+			company = EXCLUDED.company,
+			salary_min = EXCLUDED.salary_min,
+			salary_currency = EXCLUDED.salary_currency,
+			salary_period = EXCLUDED.salary_period,
+			skills = EXCLUDED.skills,
+			last_seen_at = GREATEST(external_jobs.last_seen_at, EXCLUDED.last_seen_at)`
+	stmt, err := tx.PrepareContext(ctx, query)
+	if err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("error preparing external jobs upsert: %w", err)
+	}
+	defer stmt.Close()
+
+	for _, job := range jobs {
+		skills := job.Skills
+		if skills == nil {
+			skills = []string{}
+		}
+		key := externalKey(job)
+		_, err := stmt.ExecContext(ctx, source, key, job.Title, job.Country, job.Region, job.City, job.WorkMode, job.Company,
+			job.SalaryMin, job.SalaryCurrency, job.SalaryPeriod, pq.Array(skills), externalJobID(source, key))
+		if err != nil {
+			recordError(span, err)
+			return 0, fmt.Errorf("error upserting external job: %w", classify(err, nil))
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("error committing external jobs: %w", err)
+	}
+	return int64(len(jobs)), nil
+}
+
+// externalKey identifies an external job within its source. The providers do not identify their jobs and seldom
+// send the company, so the key covers the title, country and company along with the skills, which tell apart the
+// jobs of a search, but not the salary or location: a change of those refreshes the stored job rather than storing
+// another one. Skills are compared as a set, ignoring case. db_creation/17-external-keys.sql computes the same key.
+func externalKey(job types.Job) string {
+	fields := []string{job.Title, job.Country, job.Company}
+	for i, field := range fields {
+		fields[i] = normalizeKeyField(field)
+	}
+	skills := make([]string, 0, len(job.Skills))
+	for _, skill := range job.Skills {
+		if skill = normalizeKeyField(skill); skill != "" {
+			skills = append(skills, skill)
+		}
+	}
+	slices.Sort(skills)
+	fields = append(fields, strings.Join(slices.Compact(skills), "\x1e"))
+	sum := sha256.Sum256([]byte(strings.Join(fields, "\x1f")))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeKeyField(field string) string {
+	return strings.ToLower(strings.TrimSpace(field))
+}
+
+// externalJobNamespace is the namespace of the external job IDs, the external_job_id SQL function uses it too
+var externalJobNamespace = uuid.MustParse("a05d4073-fa34-436e-aca1-55357b26e3f0")
+
+// ExternalJobID is the ID of an external job served by source. Jobs fetched from the provider on every request are
+// converted with the external source, so their IDs only match the ingested ones for the source named external.
+func ExternalJobID(source string, job types.Job) uuid.UUID {
+	return externalJobID(source, externalKey(job))
+}
+
+func externalJobID(source, key string) uuid.UUID {
+	return uuid.NewSHA1(externalJobNamespace, []byte(source+":"+key))
+}
+
+// GetExternalJob returns the ingested external job with the given ID or ErrJobNotFound
+func (db *DBConnector) GetExternalJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetExternalJob", "SELECT", "external_jobs")
+	defer span.End()
+
+	var (
+		job       = types.Job{Source: types.SourceExternal}
+		firstSeen time.Time
+	)
+	err := db.DB.QueryRowContext(ctx, `SELECT `+externalJobColumns+` FROM external_jobs WHERE id = $1`, id).Scan(externalJobFields(&job, &firstSeen)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Job{}, classify(err, ErrJobNotFound.WithMessage("Job %s not found", id))
+		}
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("error getting external job: %w", err)
+	}
+	job.PostedAt = &firstSeen
+	job.FirstSeenAt = &firstSeen
+	return job, nil
+}
+
+// GetExternalJobs returns the ingested external jobs matching the titles and countries of the query, newest first.
+// ALL in JobTitles or Countries matches every job. Jobs first seen before the query PostedAfter or last seen
+// before seenAfter are left out, as are the jobs first seen before the query LastSeenAt when listing the jobs since
+// the last seen one. The other filters are applied by the caller.
+func (db *DBConnector) GetExternalJobs(ctx context.Context, query types.JobQuery, seenAfter time.Time) ([]types.Job, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetExternalJobs", "SELECT", "external_jobs")
+	defer span.End()
+
+	const q = `
+		SELECT ` + externalJobColumns + `
+		FROM external_jobs
+		WHERE ('ALL' = ANY($1) OR title = ANY($1))
+			AND ('ALL' = ANY($2) OR country = ANY($2))
+			AND first_seen_at >= $3
+			AND last_seen_at >= $4
+			AND ($5::timestamp IS NULL OR first_seen_at > $5)
+		ORDER BY first_seen_at DESC, id`
+	rows, err := db.DB.QueryContext(ctx, q, pq.Array(query.JobTitles), pq.Array(query.Countries), query.PostedAfter.UTC(), seenAfter.UTC(),
+		lastSeen(query))
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error getting external jobs: %w", classify(err, nil))
+	}
+	defer rows.Close()
+
+	var jobs []types.Job
+	for rows.Next() {
+		var (
+			job       = types.Job{Source: types.SourceExternal}
+			firstSeen time.Time
+		)
+		if err := rows.Scan(externalJobFields(&job, &firstSeen)...); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning external job: %w", err)
+		}
+		job.PostedAt = &firstSeen
+		job.FirstSeenAt = &firstSeen
+		jobs = append(jobs, job)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over external jobs: %w", err)
+	}
+	span.SetAttributes(attribute.Int("jobs.count", len(jobs)))
+	return jobs, nil
+}
+
+// externalJobFields lists the scan destinations of externalJobColumns
+func externalJobFields(job *types.Job, firstSeen *time.Time) []interface{} {
+	return []interface{}{
+		&job.ID, &job.Title, &job.Country, &job.Region, &job.City, &job.WorkMode, &job.Company,
+		&job.SalaryMin, &job.SalaryCurrency, &job.SalaryPeriod, pq.Array(&job.Skills), firstSeen,
+	}
+}
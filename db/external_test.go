@@ -0,0 +1,58 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExternalJobID(t *testing.T) {
+	job := types.Job{Title: "Backend Developer", Country: "UK", Company: "Acme", SalaryMin: 60000, Skills: []string{"Go", "SQL"}}
+	same := types.Job{Title: " backend developer", Country: "uk", Company: "ACME ", SalaryMin: 60000, Skills: []string{"sql", "Go", "go "}}
+	// A job keeps its ID when its salary or location changes
+	moved := types.Job{Title: "Backend Developer", Country: "UK", Company: "Acme", SalaryMin: 70000, City: "London", WorkMode: "remote",
+		Skills: []string{"Go", "SQL"}}
+
+	// The external_job_id SQL function computes the same UUID version 5
+	assert.Equal(t, uuid.MustParse("b8fdd8b2-8cad-5bf1-a3df-e282e565a45f"), externalJobID("external", "abc"))
+	assert.Equal(t, ExternalJobID(types.SourceExternal, job), ExternalJobID(types.SourceExternal, same))
+	assert.Equal(t, ExternalJobID(types.SourceExternal, job), ExternalJobID(types.SourceExternal, moved))
+	assert.NotEqual(t, ExternalJobID(types.SourceExternal, job), ExternalJobID("other", job))
+	assert.NotEqual(t, ExternalJobID(types.SourceExternal, job), ExternalJobID(types.SourceExternal, types.Job{Title: "Backend Developer", Country: "UK"}))
+	// The jobs of one search seldom have a company, their skills tell them apart
+	assert.NotEqual(t,
+		ExternalJobID(types.SourceExternal, types.Job{Title: "Backend Developer", Country: "UK", Skills: []string{"Go"}}),
+		ExternalJobID(types.SourceExternal, types.Job{Title: "Backend Developer", Country: "UK", Skills: []string{"Java"}}))
+	assert.Equal(t, uuid.Version(5), ExternalJobID(types.SourceExternal, job).Version())
+}
+
+func TestUpsertExternalJobsStampsWrite(t *testing.T) {
+	db := testDB(t)
+	ctx := context.Background()
+	source := "test-" + uuid.NewString()
+	t.Cleanup(func() { _, _ = db.DB.Exec(`DELETE FROM external_jobs WHERE source = $1`, source) })
+	job := types.Job{Title: "Backend Developer", Country: "UK", SalaryMin: 60000, Skills: []string{"Go"}}
+
+	// The source was fetched, then the subscriber acknowledged every job before the fetched ones were stored
+	acknowledged := time.Now()
+	n, err := db.UpsertExternalJobs(ctx, source, []types.Job{job})
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	stored, err := db.GetExternalJob(ctx, ExternalJobID(source, job))
+	require.NoError(t, err)
+	assert.True(t, stored.FirstSeenAt.After(acknowledged), "first seen at %v, acknowledged at %v", stored.FirstSeenAt, acknowledged)
+
+	// Storing the job again keeps its first-seen time
+	_, err = db.UpsertExternalJobs(ctx, source, []types.Job{job})
+	require.NoError(t, err)
+	again, err := db.GetExternalJob(ctx, ExternalJobID(source, job))
+	require.NoError(t, err)
+	assert.Equal(t, stored.FirstSeenAt, again.FirstSeenAt)
+}
@@ -0,0 +1,130 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+)
+
+// ErrJobActionNotFound is returned when a subscriber did not bookmark or dismiss a job
+var ErrJobActionNotFound = apperr.NotFound("job_action_not_found", "The job is not bookmarked or dismissed")
+
+// jobActionTables stores every job action in its own table, the other table holds the opposite action
+var jobActionTables = map[string]struct{ table, opposite string }{
+	types.ActionBookmark: {table: "job_bookmarks", opposite: "job_dismissals"},
+	types.ActionDismiss:  {table: "job_dismissals", opposite: "job_bookmarks"},
+}
+
+// SaveJobAction bookmarks or dismisses a job for a subscriber, undoing the opposite action. Saving an action again
+// keeps the time it was first saved.
+func (db *DBConnector) SaveJobAction(ctx context.Context, action string, a types.JobAction) (types.JobAction, error) {
+	tables, ok := jobActionTables[action]
+	if !ok {
+		return types.JobAction{}, fmt.Errorf("unknown job action %q", action)
+	}
+	ctx, span := startSpan(ctx, "DBConnector.SaveJobAction", "INSERT", tables.table)
+	defer span.End()
+
+	tx, err := db.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return types.JobAction{}, fmt.Errorf("error starting transaction: %w", err)
+	}
+	// Rolling back after a commit is a no-op
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM `+tables.opposite+` WHERE subscriber_id = $1 AND job_id = $2`, a.SubscriberID, a.JobID); err != nil {
+		recordError(span, err)
+		return types.JobAction{}, fmt.Errorf("error undoing job action: %w", err)
+	}
+	query := `
+		INSERT INTO ` + tables.table + ` (subscriber_id, job_id, source)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (subscriber_id, job_id) DO UPDATE SET source = EXCLUDED.source
+		RETURNING subscriber_id, job_id, source, created_at`
+	var saved types.JobAction
+	err = tx.QueryRowContext(ctx, query, a.SubscriberID, a.JobID, a.Source).Scan(jobActionFields(&saved)...)
+	if err != nil {
+		recordError(span, err)
+		return types.JobAction{}, fmt.Errorf("error saving job action: %w", classify(err, nil))
+	}
+	if err := tx.Commit(); err != nil {
+		recordError(span, err)
+		return types.JobAction{}, fmt.Errorf("error committing job action: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteJobAction removes a bookmark or dismissal of a job, ErrJobActionNotFound when there is none
+func (db *DBConnector) DeleteJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) error {
+	tables, ok := jobActionTables[action]
+	if !ok {
+		return fmt.Errorf("unknown job action %q", action)
+	}
+	ctx, span := startSpan(ctx, "DBConnector.DeleteJobAction", "DELETE", tables.table)
+	defer span.End()
+
+	res, err := db.DB.ExecContext(ctx, `DELETE FROM `+tables.table+` WHERE subscriber_id = $1 AND job_id = $2`, subscriberID, jobID)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error deleting job action: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrJobActionNotFound.WithMessage("Job %s is not %s", jobID, actionParticiple(action))
+	}
+	return nil
+}
+
+// ListJobActions returns the bookmarks or dismissals of a subscriber, newest first
+func (db *DBConnector) ListJobActions(ctx context.Context, action string, subscriberID uuid.UUID) ([]types.JobAction, error) {
+	tables, ok := jobActionTables[action]
+	if !ok {
+		return nil, fmt.Errorf("unknown job action %q", action)
+	}
+	ctx, span := startSpan(ctx, "DBConnector.ListJobActions", "SELECT", tables.table)
+	defer span.End()
+
+	query := `
+		SELECT subscriber_id, job_id, source, created_at
+		FROM ` + tables.table + `
+		WHERE subscriber_id = $1
+		ORDER BY created_at DESC, job_id`
+	rows, err := db.DB.QueryContext(ctx, query, subscriberID)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing job actions: %w", err)
+	}
+	defer rows.Close()
+
+	actions := []types.JobAction{}
+	for rows.Next() {
+		var a types.JobAction
+		if err := rows.Scan(jobActionFields(&a)...); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning job action: %w", err)
+		}
+		actions = append(actions, a)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over job actions: %w", err)
+	}
+	return actions, nil
+}
+
+// actionParticiple names the state an action leaves a job in, in messages
+func actionParticiple(action string) string {
+	if action == types.ActionDismiss {
+		return "dismissed"
+	}
+	return "bookmarked"
+}
+
+// jobActionFields lists the scan destinations of a job action
+func jobActionFields(a *types.JobAction) []interface{} {
+	return []interface{}{&a.SubscriberID, &a.JobID, &a.Source, &a.CreatedAt}
+}
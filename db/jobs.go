@@ -0,0 +1,226 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrJobNotFound is returned when no internal job has the given ID
+	ErrJobNotFound = apperr.NotFound("job_not_found", "Job not found")
+	// ErrJobModified is returned when a job no longer has the version the write was conditioned on
+	ErrJobModified = apperr.PreconditionFailed("job_modified", "The job was modified since it was read")
+)
+
+// jobColumns selects a job aliased j joined with jobCompany
+const jobColumns = `
+	j.id,
+	'internal' AS source,
+	j.title,
+	COALESCE(j.description, '') AS description,
+	COALESCE(j.location, '') AS location,
+	j.country,
+	COALESCE(j.region, '') AS region,
+	COALESCE(j.city, '') AS city,
+	j.work_mode,
+	COALESCE(j.salary_min, 0) AS salary_min,
+	j.salary_max,
+	j.salary_currency,
+	j.salary_period,
+	j.posted_date,
+	j.status,
+	j.expires_at,
+	j.updated_at,
+	j.first_seen_at,
+	j.company_id,
+	COALESCE(c.name, '') AS company`
+
+const jobCompany = ` LEFT JOIN companies c ON c.id = j.company_id`
+
+// returningJob wraps a write returning the job row so that the company name is joined in
+func returningJob(statement string) string {
+	return `WITH j AS (` + statement + ` RETURNING *) SELECT ` + jobColumns + ` FROM j` + jobCompany
+}
+
+// CreateJob publishes an internal job
+func (db *DBConnector) CreateJob(ctx context.Context, input types.JobInput) (types.Job, error) {
+	ctx, span := startSpan(ctx, "DBConnector.CreateJob", "INSERT", "jobs")
+	defer span.End()
+
+	query := returningJob(`
+		INSERT INTO jobs (title, description, location, country, salary_min, status, expires_at, posted_date, updated_at, company_id,
+			salary_max, salary_currency, salary_period, region, city, work_mode)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $8, $9, $10, $11, $12, NULLIF($13, ''), NULLIF($14, ''), $15)`)
+	var job types.Job
+	err := db.DB.QueryRowxContext(ctx, query, input.Title, input.Description, input.Location, input.Country, input.SalaryMin,
+		input.Status, utc(input.ExpiresAt), time.Now().UTC(), input.CompanyID,
+		input.SalaryMax, input.SalaryCurrency, input.SalaryPeriod, input.Region, input.City, input.WorkMode).StructScan(&job)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("error creating job: %w", classify(err, nil))
+	}
+	job.Skills = []string{}
+	return job, nil
+}
+
+// GetJob returns the internal job with the given ID or ErrJobNotFound
+func (db *DBConnector) GetJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetJob", "SELECT", "jobs")
+	defer span.End()
+
+	var job types.Job
+	err := db.DB.QueryRowxContext(ctx, `SELECT `+jobColumns+` FROM jobs j`+jobCompany+` WHERE j.id = $1`, id).StructScan(&job)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.Job{}, classify(err, ErrJobNotFound.WithMessage("Job %s not found", id))
+		}
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("error getting job: %w", err)
+	}
+	job.Skills = []string{}
+	return job, nil
+}
+
+// ReplaceJob overwrites every field of a job. When version is set, the job must still have been
+// updated at that time, otherwise ErrJobModified is returned.
+func (db *DBConnector) ReplaceJob(ctx context.Context, id uuid.UUID, input types.JobInput, version *time.Time) (types.Job, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ReplaceJob", "UPDATE", "jobs")
+	defer span.End()
+
+	query := returningJob(`
+		UPDATE jobs
+		SET title = $2, description = $3, location = $4, country = $5, salary_min = $6, status = $7, expires_at = $8, company_id = $10,
+			salary_max = $11, salary_currency = $12, salary_period = $13, region = NULLIF($14, ''), city = NULLIF($15, ''), work_mode = $16
+		WHERE id = $1 AND ($9::timestamp IS NULL OR updated_at = $9)`)
+	job, err := db.updateJob(ctx, id, query, id, input.Title, input.Description, input.Location, input.Country, input.SalaryMin,
+		input.Status, utc(input.ExpiresAt), version, input.CompanyID, input.SalaryMax, input.SalaryCurrency, input.SalaryPeriod,
+		input.Region, input.City, input.WorkMode)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("error replacing job: %w", err)
+	}
+	return job, nil
+}
+
+// PatchJob changes the fields set in patch, version is checked as in ReplaceJob
+func (db *DBConnector) PatchJob(ctx context.Context, id uuid.UUID, patch types.JobPatch, version *time.Time) (types.Job, error) {
+	ctx, span := startSpan(ctx, "DBConnector.PatchJob", "UPDATE", "jobs")
+	defer span.End()
+
+	query := returningJob(`
+		UPDATE jobs
+		SET title = COALESCE($2::job_title, title),
+			description = COALESCE($3, description),
+			location = COALESCE($4, location),
+			country = COALESCE($5::country, country),
+			salary_min = COALESCE($6, salary_min),
+			status = COALESCE($7::job_status, status),
+			expires_at = COALESCE($8, expires_at),
+			company_id = CASE WHEN $17 THEN NULL ELSE COALESCE($10, company_id) END,
+			salary_max = CASE WHEN $18 THEN NULL ELSE COALESCE($11, salary_max) END,
+			salary_currency = COALESCE($12, salary_currency),
+			salary_period = COALESCE($13::pay_period, salary_period),
+			region = CASE WHEN $14::text IS NULL THEN region ELSE NULLIF($14, '') END,
+			city = CASE WHEN $15::text IS NULL THEN city ELSE NULLIF($15, '') END,
+			work_mode = COALESCE($16::work_mode, work_mode)
+		WHERE id = $1 AND ($9::timestamp IS NULL OR updated_at = $9)`)
+	job, err := db.updateJob(ctx, id, query, id, patch.Title, patch.Description, patch.Location, patch.Country, patch.SalaryMin,
+		patch.Status, utc(patch.ExpiresAt), version, patch.CompanyID, patch.SalaryMax, patch.SalaryCurrency, patch.SalaryPeriod,
+		patch.Region, patch.City, patch.WorkMode, patch.ClearCompanyID, patch.ClearSalaryMax)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("error patching job: %w", err)
+	}
+	return job, nil
+}
+
+// DeleteJob removes a job, version is checked as in ReplaceJob
+func (db *DBConnector) DeleteJob(ctx context.Context, id uuid.UUID, version *time.Time) error {
+	ctx, span := startSpan(ctx, "DBConnector.DeleteJob", "DELETE", "jobs")
+	defer span.End()
+
+	const query = `DELETE FROM jobs WHERE id = $1 AND ($2::timestamp IS NULL OR updated_at = $2)`
+	res, err := db.DB.ExecContext(ctx, query, id, version)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error deleting job: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return db.missingJob(ctx, id)
+	}
+	return nil
+}
+
+// ExpireJobs expires the open jobs whose expires_at has passed. With maxAge set, open jobs without
+// expires_at also expire once they were posted maxAge ago. It returns the number of expired jobs.
+func (db *DBConnector) ExpireJobs(ctx context.Context, now time.Time, maxAge time.Duration) (int64, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ExpireJobs", "UPDATE", "jobs")
+	defer span.End()
+
+	var postedBefore *time.Time
+	if maxAge > 0 {
+		t := now.Add(-maxAge).UTC()
+		postedBefore = &t
+	}
+	const query = `
+		UPDATE jobs
+		SET status = 'expired'
+		WHERE status = 'open'
+			AND (expires_at <= $1 OR (expires_at IS NULL AND posted_date <= $2::timestamp))`
+	res, err := db.DB.ExecContext(ctx, query, now.UTC(), postedBefore)
+	if err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("error expiring jobs: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		recordError(span, err)
+		return 0, fmt.Errorf("error counting expired jobs: %w", err)
+	}
+	span.SetAttributes(attribute.Int64("jobs.expired", n))
+	return n, nil
+}
+
+// updateJob runs a conditional update returning the job, a missing row is explained by missingJob
+func (db *DBConnector) updateJob(ctx context.Context, id uuid.UUID, query string, args ...interface{}) (types.Job, error) {
+	var job types.Job
+	err := db.DB.QueryRowxContext(ctx, query, args...).StructScan(&job)
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Job{}, db.missingJob(ctx, id)
+	}
+	if err != nil {
+		return types.Job{}, classify(err, nil)
+	}
+	job.Skills = []string{}
+	return job, nil
+}
+
+// missingJob tells apart a conditional write that found no job from one that found a newer version
+func (db *DBConnector) missingJob(ctx context.Context, id uuid.UUID) error {
+	var exists bool
+	if err := db.DB.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM jobs WHERE id = $1)`, id).Scan(&exists); err != nil {
+		return fmt.Errorf("error checking job: %w", err)
+	}
+	if exists {
+		return ErrJobModified
+	}
+	return ErrJobNotFound.WithMessage("Job %s not found", id)
+}
+
+// utc converts t for the timestamp columns, which store UTC without a time zone
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
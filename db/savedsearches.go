@@ -0,0 +1,173 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrSavedSearchNotFound is returned when no saved search has the given ID
+var ErrSavedSearchNotFound = apperr.NotFound("saved_search_not_found", "Saved search not found")
+
+const savedSearchColumns = `
+	id,
+	subscriber_id,
+	name,
+	job_titles,
+	countries,
+	salary_min,
+	skills,
+	keywords,
+	channel,
+	COALESCE(webhook_url, ''),
+	frequency,
+	last_seen_at,
+	created_at,
+	updated_at`
+
+// CreateSavedSearch records a saved search of a subscriber, a search with the same name is a conflict
+func (db *DBConnector) CreateSavedSearch(ctx context.Context, subscriberID uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	ctx, span := startSpan(ctx, "DBConnector.CreateSavedSearch", "INSERT", "saved_searches")
+	defer span.End()
+
+	const query = `
+		INSERT INTO saved_searches (subscriber_id, name, job_titles, countries, salary_min, skills, keywords, channel, webhook_url, frequency)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, ''), $10)
+		RETURNING ` + savedSearchColumns
+	var search types.SavedSearch
+	err := db.DB.QueryRowContext(ctx, query, append([]interface{}{subscriberID}, savedSearchArgs(input)...)...).Scan(savedSearchFields(&search)...)
+	if err != nil {
+		recordError(span, err)
+		return types.SavedSearch{}, fmt.Errorf("error creating saved search: %w", classify(err, nil))
+	}
+	return search, nil
+}
+
+// GetSavedSearch returns the saved search with the given ID or ErrSavedSearchNotFound
+func (db *DBConnector) GetSavedSearch(ctx context.Context, id uuid.UUID) (types.SavedSearch, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetSavedSearch", "SELECT", "saved_searches")
+	defer span.End()
+
+	var search types.SavedSearch
+	err := db.DB.QueryRowContext(ctx, `SELECT `+savedSearchColumns+` FROM saved_searches WHERE id = $1`, id).Scan(savedSearchFields(&search)...)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.SavedSearch{}, classify(err, ErrSavedSearchNotFound.WithMessage("Saved search %s not found", id))
+		}
+		recordError(span, err)
+		return types.SavedSearch{}, fmt.Errorf("error getting saved search: %w", err)
+	}
+	return search, nil
+}
+
+// ListSavedSearches returns the saved searches of a subscriber by name
+func (db *DBConnector) ListSavedSearches(ctx context.Context, subscriberID uuid.UUID) ([]types.SavedSearch, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ListSavedSearches", "SELECT", "saved_searches")
+	defer span.End()
+
+	rows, err := db.DB.QueryContext(ctx, `SELECT `+savedSearchColumns+` FROM saved_searches WHERE subscriber_id = $1 ORDER BY name`, subscriberID)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error listing saved searches: %w", err)
+	}
+	defer rows.Close()
+
+	searches := []types.SavedSearch{}
+	for rows.Next() {
+		var search types.SavedSearch
+		if err := rows.Scan(savedSearchFields(&search)...); err != nil {
+			recordError(span, err)
+			return nil, fmt.Errorf("error scanning saved search: %w", err)
+		}
+		searches = append(searches, search)
+	}
+	if err := rows.Err(); err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("error iterating over saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// ReplaceSavedSearch overwrites every field of a saved search but its subscriber
+func (db *DBConnector) ReplaceSavedSearch(ctx context.Context, id uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ReplaceSavedSearch", "UPDATE", "saved_searches")
+	defer span.End()
+
+	const query = `
+		UPDATE saved_searches
+		SET name = $2, job_titles = $3, countries = $4, salary_min = $5, skills = $6, keywords = $7, channel = $8,
+			webhook_url = NULLIF($9, ''), frequency = $10
+		WHERE id = $1
+		RETURNING ` + savedSearchColumns
+	var search types.SavedSearch
+	err := db.DB.QueryRowContext(ctx, query, append([]interface{}{id}, savedSearchArgs(input)...)...).Scan(savedSearchFields(&search)...)
+	if err != nil {
+		recordError(span, err)
+		return types.SavedSearch{}, fmt.Errorf("error replacing saved search: %w",
+			classify(err, ErrSavedSearchNotFound.WithMessage("Saved search %s not found", id)))
+	}
+	return search, nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (db *DBConnector) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	ctx, span := startSpan(ctx, "DBConnector.DeleteSavedSearch", "DELETE", "saved_searches")
+	defer span.End()
+
+	res, err := db.DB.ExecContext(ctx, `DELETE FROM saved_searches WHERE id = $1`, id)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error deleting saved search: %w", err)
+	}
+	if n, err := res.RowsAffected(); err == nil && n == 0 {
+		return ErrSavedSearchNotFound.WithMessage("Saved search %s not found", id)
+	}
+	return nil
+}
+
+// AcknowledgeSavedSearchJobs advances the high-water mark of a saved search to seenUntil unless it is already past it
+func (db *DBConnector) AcknowledgeSavedSearchJobs(ctx context.Context, id uuid.UUID, seenUntil time.Time) (time.Time, error) {
+	ctx, span := startSpan(ctx, "DBConnector.AcknowledgeSavedSearchJobs", "UPDATE", "saved_searches")
+	defer span.End()
+
+	const query = `UPDATE saved_searches SET last_seen_at = GREATEST(last_seen_at, $2) WHERE id = $1 RETURNING last_seen_at`
+	var mark time.Time
+	if err := db.DB.QueryRowContext(ctx, query, id, seenUntil.UTC()).Scan(&mark); err != nil {
+		recordError(span, err)
+		return time.Time{}, fmt.Errorf("error acknowledging saved search jobs: %w",
+			classify(err, ErrSavedSearchNotFound.WithMessage("Saved search %s not found", id)))
+	}
+	return mark, nil
+}
+
+// savedSearchArgs lists the query parameters of the input fields, from name to frequency
+func savedSearchArgs(input types.SavedSearchInput) []interface{} {
+	return []interface{}{
+		input.Name, stringArray(input.JobTitles), stringArray(input.Countries), input.SalaryMin, stringArray(input.Skills), input.Keywords,
+		input.Channel, input.WebhookURL, input.Frequency,
+	}
+}
+
+// savedSearchFields lists the scan destinations of savedSearchColumns
+func savedSearchFields(search *types.SavedSearch) []interface{} {
+	return []interface{}{
+		&search.ID, &search.SubscriberID, &search.Name, pq.Array(&search.JobTitles), pq.Array(&search.Countries), &search.SalaryMin,
+		pq.Array(&search.Skills), &search.Keywords, &search.Channel, &search.WebhookURL, &search.Frequency, &search.LastSeenAt, &search.CreatedAt, &search.UpdatedAt,
+	}
+}
+
+// stringArray passes values as a Postgres array, nil as an empty one for the NOT NULL columns
+func stringArray(values []string) interface{} {
+	if values == nil {
+		values = []string{}
+	}
+	return pq.Array(values)
+}
@@ -0,0 +1,98 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrSubscriberNotFound is returned when no subscriber matches the lookup
+	ErrSubscriberNotFound = apperr.NotFound("subscriber_not_found", "Subscriber not found")
+	// ErrMagicLinkNotFound is returned for unknown, expired or already used login tokens
+	ErrMagicLinkNotFound = apperr.NotFound("magic_link_not_found", "Magic link not found")
+)
+
+// SessionStore defines the persistence operations for passwordless logins
+type SessionStore interface {
+	GetSubscriberIDByEmail(ctx context.Context, email string) (uuid.UUID, error)
+	CreateMagicLink(ctx context.Context, subscriberID uuid.UUID, hash string, expiresAt time.Time) error
+	// ConsumeMagicLink marks a valid token as used and returns its subscriber
+	ConsumeMagicLink(ctx context.Context, hash string) (uuid.UUID, error)
+	RecordImpersonation(ctx context.Context, audit types.ImpersonationAudit) error
+}
+
+// GetSubscriberIDByEmail returns the ID of the subscriber registered with email
+func (db *DBConnector) GetSubscriberIDByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "DBConnector.GetSubscriberIDByEmail", "SELECT", "subscribers")
+	defer span.End()
+
+	var id uuid.UUID
+	err := db.DB.QueryRowxContext(ctx, `SELECT id FROM subscribers WHERE email = $1`, email).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrSubscriberNotFound
+		}
+		recordError(span, err)
+		return uuid.Nil, fmt.Errorf("error getting subscriber by email: %w", err)
+	}
+	return id, nil
+}
+
+// CreateMagicLink stores the hash of a single use login token
+func (db *DBConnector) CreateMagicLink(ctx context.Context, subscriberID uuid.UUID, hash string, expiresAt time.Time) error {
+	ctx, span := startSpan(ctx, "DBConnector.CreateMagicLink", "INSERT", "magic_links")
+	defer span.End()
+
+	const query = `INSERT INTO magic_links (token_hash, subscriber_id, expires_at) VALUES ($1, $2, $3)`
+	if _, err := db.DB.ExecContext(ctx, query, hash, subscriberID, expiresAt); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error inserting magic link: %w", err)
+	}
+	return nil
+}
+
+// ConsumeMagicLink marks the token as used, only if it is unused and not expired
+func (db *DBConnector) ConsumeMagicLink(ctx context.Context, hash string) (uuid.UUID, error) {
+	ctx, span := startSpan(ctx, "DBConnector.ConsumeMagicLink", "UPDATE", "magic_links")
+	defer span.End()
+
+	const query = `
+		UPDATE magic_links
+		SET used_at = $2
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > $2
+		RETURNING subscriber_id`
+	var id uuid.UUID
+	err := db.DB.QueryRowxContext(ctx, query, hash, time.Now().UTC()).Scan(&id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return uuid.Nil, ErrMagicLinkNotFound
+		}
+		recordError(span, err)
+		return uuid.Nil, fmt.Errorf("error consuming magic link: %w", err)
+	}
+	return id, nil
+}
+
+// RecordImpersonation stores an audit entry for an admin reading a subscriber's data
+func (db *DBConnector) RecordImpersonation(ctx context.Context, audit types.ImpersonationAudit) error {
+	ctx, span := startSpan(ctx, "DBConnector.RecordImpersonation", "INSERT", "impersonation_audit")
+	defer span.End()
+
+	const query = `
+		INSERT INTO impersonation_audit (api_key_id, subscriber_id, method, path, request_id)
+		VALUES ($1, $2, $3, $4, $5)`
+	_, err := db.DB.ExecContext(ctx, query, audit.APIKeyID, audit.SubscriberID, audit.Method, audit.Path, audit.RequestID)
+	if err != nil {
+		recordError(span, err)
+		return fmt.Errorf("error recording impersonation: %w", err)
+	}
+	return nil
+}
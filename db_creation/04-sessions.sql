@@ -0,0 +1,21 @@
+-- Create magic_links table if it does not already exist.
+-- Only the SHA-256 hash of each login token is stored; tokens are single use.
+CREATE TABLE IF NOT EXISTS magic_links (
+    token_hash CHAR(64) PRIMARY KEY,
+    subscriber_id UUID NOT NULL REFERENCES subscribers(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+-- Create impersonation_audit table if it does not already exist.
+-- One row per request where an admin key read a subscriber's data.
+CREATE TABLE IF NOT EXISTS impersonation_audit (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    api_key_id UUID NOT NULL REFERENCES api_keys(id),
+    subscriber_id UUID NOT NULL,
+    method VARCHAR(16) NOT NULL,
+    path TEXT NOT NULL,
+    request_id VARCHAR(128),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
@@ -0,0 +1,8 @@
+-- Create rate_limit_buckets table if it does not already exist.
+-- Used by the shared (postgres) rate limit store, one token bucket per client and route.
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    key TEXT PRIMARY KEY,
+    tokens DOUBLE PRECISION NOT NULL,
+    allowed BOOLEAN NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
@@ -0,0 +1,13 @@
+-- Keep jobs.updated_at current on every update, it is the version checked by If-Match.
+-- clock_timestamp() rather than now() so that two updates in one transaction get different versions.
+CREATE OR REPLACE FUNCTION set_updated_at() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.updated_at = clock_timestamp() AT TIME ZONE 'UTC';
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_set_updated_at ON jobs;
+CREATE TRIGGER jobs_set_updated_at
+    BEFORE UPDATE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,20 @@
+-- Create job_status type if it does not already exist
+DO $$
+BEGIN
+   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'job_status') THEN
+      CREATE TYPE job_status AS ENUM (
+         'draft',
+         'open',
+         'closed',
+         'expired' -- set by the sweeper once expires_at has passed
+      );
+   END IF;
+END
+$$;
+
+-- Jobs are listed while open, until expires_at when set
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS status job_status NOT NULL DEFAULT 'open';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS expires_at TIMESTAMP;
+
+-- The sweeper looks up open jobs by expiration
+CREATE INDEX IF NOT EXISTS jobs_open_expires_at_idx ON jobs (expires_at) WHERE status = 'open';
@@ -0,0 +1,22 @@
+-- Create companies table if it does not already exist
+CREATE TABLE IF NOT EXISTS companies (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    website VARCHAR(255),
+    description TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+-- Jobs are published by a company, deleting the company keeps its jobs
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS company_id UUID REFERENCES companies(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS jobs_company_id_idx ON jobs (company_id);
+
+-- Subscribers see jobs of followed companies first and never see jobs of blocked ones
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS followed_companies UUID[] NOT NULL DEFAULT '{}';
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS blocked_companies UUID[] NOT NULL DEFAULT '{}';
+
+DROP TRIGGER IF EXISTS companies_set_updated_at ON companies;
+CREATE TRIGGER companies_set_updated_at
+    BEFORE UPDATE ON companies
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,28 @@
+-- Full-text search over the job title and description, kept current by a trigger since the
+-- job_title enum cannot be cast to text in a generated column
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS search tsvector;
+
+CREATE OR REPLACE FUNCTION set_jobs_search() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.search =
+        setweight(to_tsvector('english', NEW.title::text), 'A') ||
+        setweight(to_tsvector('english', COALESCE(NEW.description, '')), 'B');
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_set_search ON jobs;
+CREATE TRIGGER jobs_set_search
+    BEFORE INSERT OR UPDATE OF title, description ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_jobs_search();
+
+-- Index the existing jobs. The search column is set directly rather than through the trigger, and without bumping
+-- updated_at, which is the version clients send in If-Match.
+ALTER TABLE jobs DISABLE TRIGGER jobs_set_updated_at;
+UPDATE jobs SET search =
+    setweight(to_tsvector('english', title::text), 'A') ||
+    setweight(to_tsvector('english', COALESCE(description, '')), 'B')
+WHERE search IS NULL;
+ALTER TABLE jobs ENABLE TRIGGER jobs_set_updated_at;
+
+CREATE INDEX IF NOT EXISTS jobs_search_idx ON jobs USING GIN (search);
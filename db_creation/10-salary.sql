@@ -0,0 +1,18 @@
+-- Salaries are ranges in an ISO 4217 currency paid per period. Existing salaries are yearly USD amounts.
+DO $$
+BEGIN
+   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'pay_period') THEN
+      CREATE TYPE pay_period AS ENUM ('hour', 'day', 'week', 'month', 'year');
+   END IF;
+END
+$$;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_max INTEGER;
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS salary_period pay_period NOT NULL DEFAULT 'year';
+ALTER TABLE jobs DROP CONSTRAINT IF EXISTS jobs_salary_range_check;
+ALTER TABLE jobs ADD CONSTRAINT jobs_salary_range_check CHECK (salary_max IS NULL OR salary_max >= COALESCE(salary_min, 0));
+
+-- The minimum salary of a subscriber is expressed in their preferred currency and period
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS salary_currency CHAR(3) NOT NULL DEFAULT 'USD';
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS salary_period pay_period NOT NULL DEFAULT 'year';
@@ -0,0 +1,24 @@
+-- Jobs have a structured location and a work mode, the free-text location is kept as a display label
+DO $$
+BEGIN
+   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'work_mode') THEN
+      CREATE TYPE work_mode AS ENUM ('onsite', 'hybrid', 'remote');
+   END IF;
+END
+$$;
+
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS region VARCHAR(255);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS city VARCHAR(255);
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS work_mode work_mode;
+
+-- Existing jobs are onsite unless their location says otherwise
+UPDATE jobs SET work_mode = CASE WHEN location ILIKE '%remote%' THEN 'remote'::work_mode ELSE 'onsite'::work_mode END
+WHERE work_mode IS NULL;
+ALTER TABLE jobs ALTER COLUMN work_mode SET DEFAULT 'onsite';
+ALTER TABLE jobs ALTER COLUMN work_mode SET NOT NULL;
+
+CREATE INDEX IF NOT EXISTS jobs_city_idx ON jobs (lower(city));
+
+-- Subscribers list where and how they want to work, e.g. [{"work_mode": "remote"}, {"work_mode": "hybrid", "city": "Buenos Aires"}].
+-- An empty list matches every job.
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS locations JSONB NOT NULL DEFAULT '[]';
@@ -0,0 +1,23 @@
+-- External jobs ingested from the providers. The providers do not identify their jobs, a job is identified by
+-- its source and a key derived from its content, so that it keeps its ID while the providers keep serving it.
+CREATE TABLE IF NOT EXISTS external_jobs (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    source VARCHAR(255) NOT NULL,
+    external_key CHAR(64) NOT NULL,
+    title VARCHAR(255) NOT NULL,
+    country VARCHAR(255) NOT NULL,
+    region VARCHAR(255),
+    city VARCHAR(255),
+    work_mode work_mode,
+    company VARCHAR(255),
+    salary_min INTEGER NOT NULL DEFAULT 0,
+    salary_currency CHAR(3) NOT NULL DEFAULT 'USD',
+    salary_period pay_period NOT NULL DEFAULT 'year',
+    skills TEXT[] NOT NULL DEFAULT '{}',
+    first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (source, external_key)
+);
+
+CREATE INDEX IF NOT EXISTS external_jobs_title_country_idx ON external_jobs (title, country);
+CREATE INDEX IF NOT EXISTS external_jobs_last_seen_at_idx ON external_jobs (last_seen_at);
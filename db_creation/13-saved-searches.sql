@@ -0,0 +1,33 @@
+-- Saved searches are named sets of job filters of a subscriber, notified through a channel at a frequency
+DO $$
+BEGIN
+   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'notification_channel') THEN
+      CREATE TYPE notification_channel AS ENUM ('email', 'webhook');
+   END IF;
+   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'notification_frequency') THEN
+      CREATE TYPE notification_frequency AS ENUM ('instant', 'daily', 'weekly');
+   END IF;
+END
+$$;
+
+CREATE TABLE IF NOT EXISTS saved_searches (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    subscriber_id UUID NOT NULL REFERENCES subscribers(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    job_titles job_title[] NOT NULL DEFAULT '{}',
+    countries country[] NOT NULL DEFAULT '{}',
+    salary_min INTEGER NOT NULL DEFAULT 0,
+    skills TEXT[] NOT NULL DEFAULT '{}',
+    keywords TEXT NOT NULL DEFAULT '',
+    channel notification_channel NOT NULL DEFAULT 'email',
+    webhook_url TEXT,
+    frequency notification_frequency NOT NULL DEFAULT 'daily',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (subscriber_id, name)
+);
+
+DROP TRIGGER IF EXISTS saved_searches_set_updated_at ON saved_searches;
+CREATE TRIGGER saved_searches_set_updated_at
+    BEFORE UPDATE ON saved_searches
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
@@ -0,0 +1,33 @@
+-- Jobs record when subscribers could first see them, the time they were first open.
+-- External jobs already record it in external_jobs.first_seen_at.
+ALTER TABLE jobs ADD COLUMN IF NOT EXISTS first_seen_at TIMESTAMP;
+
+-- Jobs listed before the column existed were seen when posted
+UPDATE jobs SET first_seen_at = posted_date WHERE first_seen_at IS NULL AND status <> 'draft';
+
+CREATE OR REPLACE FUNCTION set_first_seen_at() RETURNS TRIGGER AS $$
+BEGIN
+    IF NEW.status = 'open' AND NEW.first_seen_at IS NULL THEN
+        NEW.first_seen_at = clock_timestamp() AT TIME ZONE 'UTC';
+    END IF;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_set_first_seen_at ON jobs;
+CREATE TRIGGER jobs_set_first_seen_at
+    BEFORE INSERT OR UPDATE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION set_first_seen_at();
+
+CREATE INDEX IF NOT EXISTS jobs_first_seen_at_idx ON jobs (first_seen_at);
+CREATE INDEX IF NOT EXISTS external_jobs_first_seen_at_idx ON external_jobs (first_seen_at);
+
+-- High-water marks: since=last_seen lists the jobs first seen after them, acknowledging a list advances them
+ALTER TABLE subscribers ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP;
+ALTER TABLE saved_searches ADD COLUMN IF NOT EXISTS last_seen_at TIMESTAMP;
+
+-- Acknowledging the jobs of a saved search does not change the search, only its filters version it
+DROP TRIGGER IF EXISTS saved_searches_set_updated_at ON saved_searches;
+CREATE TRIGGER saved_searches_set_updated_at
+    BEFORE UPDATE OF name, job_titles, countries, salary_min, skills, keywords, channel, webhook_url, frequency ON saved_searches
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
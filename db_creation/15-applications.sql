@@ -0,0 +1,49 @@
+-- Applications track subscribers applying to internal or external jobs through a status pipeline
+DO $$
+BEGIN
+   IF NOT EXISTS (SELECT 1 FROM pg_type WHERE typname = 'application_status') THEN
+      CREATE TYPE application_status AS ENUM ('saved', 'applied', 'interviewing', 'offer', 'rejected');
+   END IF;
+END
+$$;
+
+-- An application references the job of its source until the job is deleted, title, company and country describe
+-- the job as it was when the application was created
+CREATE TABLE IF NOT EXISTS applications (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    subscriber_id UUID NOT NULL REFERENCES subscribers(id) ON DELETE CASCADE,
+    source VARCHAR(16) NOT NULL,
+    job_id UUID REFERENCES jobs(id) ON DELETE SET NULL,
+    external_job_id UUID REFERENCES external_jobs(id) ON DELETE SET NULL,
+    title VARCHAR(255) NOT NULL,
+    company VARCHAR(255),
+    country VARCHAR(255) NOT NULL,
+    status application_status NOT NULL DEFAULT 'saved',
+    notes TEXT NOT NULL DEFAULT '',
+    applied_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    CONSTRAINT applications_source_check CHECK (source IN ('internal', 'external')),
+    CONSTRAINT applications_job_check CHECK (job_id IS NULL OR external_job_id IS NULL),
+    UNIQUE (subscriber_id, job_id),
+    UNIQUE (subscriber_id, external_job_id)
+);
+
+CREATE INDEX IF NOT EXISTS applications_subscriber_status_idx ON applications (subscriber_id, status);
+
+DROP TRIGGER IF EXISTS applications_set_updated_at ON applications;
+CREATE TRIGGER applications_set_updated_at
+    BEFORE UPDATE ON applications
+    FOR EACH ROW EXECUTE FUNCTION set_updated_at();
+
+-- The status history of applications, from_status is NULL for their creation
+CREATE TABLE IF NOT EXISTS application_events (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    application_id UUID NOT NULL REFERENCES applications(id) ON DELETE CASCADE,
+    from_status application_status,
+    to_status application_status NOT NULL,
+    note TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS application_events_application_id_idx ON application_events (application_id, created_at);
@@ -0,0 +1,54 @@
+-- External jobs get IDs derived from their source and key, so that the jobs fetched from the providers on every
+-- request have the ID they get once ingested. external_job_id computes the UUID version 5 of source:external_key
+-- in the namespace db.ExternalJobID uses.
+CREATE OR REPLACE FUNCTION external_job_id(source TEXT, external_key TEXT) RETURNS UUID AS $$
+DECLARE
+    hash BYTEA := substring(digest(decode('a05d4073fa34436eaca155357b26e3f0', 'hex') ||
+        convert_to(source || ':' || external_key, 'UTF8'), 'sha1') FROM 1 FOR 16);
+BEGIN
+    hash := set_byte(hash, 6, (get_byte(hash, 6) & 15) | 80);
+    hash := set_byte(hash, 8, (get_byte(hash, 8) & 63) | 128);
+    RETURN encode(hash, 'hex')::uuid;
+END
+$$ LANGUAGE plpgsql IMMUTABLE;
+
+-- Applications follow the external jobs to their new IDs
+ALTER TABLE applications DROP CONSTRAINT IF EXISTS applications_external_job_id_fkey;
+ALTER TABLE applications ADD CONSTRAINT applications_external_job_id_fkey
+    FOREIGN KEY (external_job_id) REFERENCES external_jobs(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+UPDATE external_jobs SET id = external_job_id(source, external_key) WHERE id <> external_job_id(source, external_key);
+
+-- Bookmarks and dismissals of jobs by subscribers. job_id is an internal job or an external one, which is not
+-- necessarily ingested, so it does not reference either table. A job is bookmarked or dismissed, never both.
+CREATE TABLE IF NOT EXISTS job_bookmarks (
+    subscriber_id UUID NOT NULL REFERENCES subscribers(id) ON DELETE CASCADE,
+    job_id UUID NOT NULL,
+    source VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (subscriber_id, job_id),
+    CONSTRAINT job_bookmarks_source_check CHECK (source IN ('internal', 'external'))
+);
+
+CREATE TABLE IF NOT EXISTS job_dismissals (
+    subscriber_id UUID NOT NULL REFERENCES subscribers(id) ON DELETE CASCADE,
+    job_id UUID NOT NULL,
+    source VARCHAR(16) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    PRIMARY KEY (subscriber_id, job_id),
+    CONSTRAINT job_dismissals_source_check CHECK (source IN ('internal', 'external'))
+);
+
+-- Deleting an internal job deletes its bookmarks and dismissals
+CREATE OR REPLACE FUNCTION delete_job_actions() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM job_bookmarks WHERE job_id = OLD.id;
+    DELETE FROM job_dismissals WHERE job_id = OLD.id;
+    RETURN OLD;
+END
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS jobs_delete_job_actions ON jobs;
+CREATE TRIGGER jobs_delete_job_actions
+    AFTER DELETE ON jobs
+    FOR EACH ROW EXECUTE FUNCTION delete_job_actions();
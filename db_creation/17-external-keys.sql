@@ -0,0 +1,44 @@
+-- External jobs are keyed by their title, country, company and skills, so that a change of salary or location
+-- refreshes a stored job rather than storing another one. external_job_key computes the key db.externalKey does.
+CREATE OR REPLACE FUNCTION external_job_key(title TEXT, country TEXT, company TEXT, skills TEXT[]) RETURNS TEXT AS $$
+    SELECT encode(digest(convert_to(lower(btrim(title)) || chr(31) || lower(btrim(country)) || chr(31) ||
+        lower(btrim(COALESCE(company, ''))) || chr(31) ||
+        array_to_string(ARRAY(
+            SELECT DISTINCT lower(btrim(s)) COLLATE "C" FROM unnest(skills) AS s WHERE btrim(s) <> '' ORDER BY 1
+        ), chr(30)), 'UTF8'), 'sha256'), 'hex')
+$$ LANGUAGE sql IMMUTABLE;
+
+-- The jobs stored under the former keys, which also covered the location and salary, are rekeyed. Jobs are never
+-- merged: when several now share a key, the first seen one takes it and the others keep their former key and ID
+-- until they age out, the provider serving them under the new key from now on.
+CREATE TEMPORARY TABLE external_job_rekey AS
+SELECT old_id, key, external_job_id(source, key) AS new_id
+FROM (
+    SELECT id AS old_id, source, key, row_number() OVER (PARTITION BY source, key ORDER BY first_seen_at, id) AS n
+    FROM (SELECT id, source, first_seen_at, external_job_key(title, country, company, skills) AS key FROM external_jobs) k
+) r
+WHERE n = 1;
+
+-- Applications follow through the ON UPDATE CASCADE of their foreign key
+UPDATE external_jobs e SET external_key = r.key, id = r.new_id
+FROM external_job_rekey r
+WHERE e.id = r.old_id AND (e.external_key <> r.key OR e.id <> r.new_id);
+
+-- Bookmarks and dismissals do not reference the jobs, they move to the new IDs. A job bookmarked under one ID and
+-- dismissed under the other stays bookmarked.
+INSERT INTO job_bookmarks (subscriber_id, job_id, source, created_at)
+SELECT b.subscriber_id, r.new_id, b.source, b.created_at
+FROM job_bookmarks b JOIN external_job_rekey r ON r.old_id = b.job_id
+WHERE r.old_id <> r.new_id
+ON CONFLICT DO NOTHING;
+DELETE FROM job_bookmarks b USING external_job_rekey r WHERE b.job_id = r.old_id AND r.old_id <> r.new_id;
+
+INSERT INTO job_dismissals (subscriber_id, job_id, source, created_at)
+SELECT d.subscriber_id, r.new_id, d.source, d.created_at
+FROM job_dismissals d JOIN external_job_rekey r ON r.old_id = d.job_id
+WHERE r.old_id <> r.new_id
+ON CONFLICT DO NOTHING;
+DELETE FROM job_dismissals d USING external_job_rekey r WHERE d.job_id = r.old_id AND r.old_id <> r.new_id;
+DELETE FROM job_dismissals d USING job_bookmarks b WHERE d.subscriber_id = b.subscriber_id AND d.job_id = b.job_id;
+
+DROP TABLE external_job_rekey;
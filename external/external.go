@@ -1,39 +1,74 @@
 package external
 
 import (
+	"context"
 	"encoding/json"
 	"encoding/xml"
 	"fmt"
+	"jobs/logging"
 	"jobs/types"
 	"net/http"
 	"net/url"
+	"strings"
 
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
 	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer("jobs/external")
+
 type ExternalJobsFetcher interface {
-	FetchExternalJobs(name string, minSalary, maxSalary int64, country string) ([]types.Job, error)
+	FetchExternalJobs(ctx context.Context, name string, minSalary, maxSalary int64, country string) ([]types.ExternalJob, error)
 }
 
+// DefaultBaseURL is used when ExternalJobs.BaseURL is empty
+const DefaultBaseURL = "http://localhost:8081"
+
 type ExternalJobs struct {
-	Client *http.Client
-	Log    *zap.Logger
+	Client  *http.Client
+	Log     *zap.Logger
+	BaseURL string
 }
 
 func NewExternalJobs(client *http.Client, log *zap.Logger) *ExternalJobs {
-	return &ExternalJobs{Client: client, Log: log}
+	return &ExternalJobs{Client: client, Log: log, BaseURL: DefaultBaseURL}
 }
 
-func (e *ExternalJobs) FetchExternalJobs(name string, minSalary, maxSalary int64, country string) ([]types.Job, error) {
-	apiURL := buildAPIURL(name, minSalary, maxSalary, country)
-	e.Log.Sugar().Infof("Fetching jobs from API: %s", apiURL)
+func (e *ExternalJobs) FetchExternalJobs(ctx context.Context, name string, minSalary, maxSalary int64, country string) (jobs []types.ExternalJob, err error) {
+	ctx, span := tracer.Start(ctx, "ExternalJobs.FetchExternalJobs")
+	span.SetAttributes(
+		attribute.String("job.title", name),
+		attribute.String("job.country", country),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attribute.Int("jobs.count", len(jobs)))
+		span.End()
+	}()
+
+	apiURL := buildAPIURL(e.BaseURL, name, minSalary, maxSalary, country)
+	logging.FromContext(ctx, e.Log).Sugar().Infof("Fetching jobs from API: %s", apiURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not build request (%s): %w", apiURL, err)
+	}
+	// Propagate the trace context to the upstream API
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 
-	resp, err := e.Client.Get(apiURL)
+	resp, err := e.Client.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("error fetching jobs from API (%s): %w", apiURL, err)
 	}
 	defer resp.Body.Close()
 
+	span.SetAttributes(attribute.Int("http.response.status_code", resp.StatusCode))
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
@@ -43,7 +78,6 @@ func (e *ExternalJobs) FetchExternalJobs(name string, minSalary, maxSalary int64
 		return nil, fmt.Errorf("could not decode response: %w", err)
 	}
 
-	var jobs []types.Job
 	jobList, ok := jobsResponse[country]
 	if !ok {
 		return nil, fmt.Errorf("no jobs found for country: %s", country)
@@ -69,18 +103,30 @@ func (e *ExternalJobs) FetchExternalJobs(name string, minSalary, maxSalary int64
 			return nil, fmt.Errorf("could not unmarshal skills XML: %w", err)
 		}
 
+		// The company name, work mode, region and city are optional trailing elements
+		optional := make([]string, 4)
+		for i := range optional {
+			if len(jobData) > 3+i {
+				optional[i], _ = jobData[3+i].(string)
+			}
+		}
+
 		// Append the job to the jobs slice
-		jobs = append(jobs, types.Job{
-			Title:  title,
-			Salary: int(salary),
-			Skills: skills,
+		jobs = append(jobs, types.ExternalJob{
+			Title:    title,
+			Salary:   int(salary),
+			Skills:   skills,
+			Company:  optional[0],
+			WorkMode: optional[1],
+			Region:   optional[2],
+			City:     optional[3],
 		})
 	}
 
 	return jobs, nil
 }
 
-func buildAPIURL(name string, minSalary, maxSalary int64, country string) string {
+func buildAPIURL(baseURL, name string, minSalary, maxSalary int64, country string) string {
 	params := url.Values{}
 
 	params.Add("name", name)
@@ -94,6 +140,9 @@ func buildAPIURL(name string, minSalary, maxSalary int64, country string) string
 		params.Add("country", country)
 	}
 
-	apiURL := fmt.Sprintf("http://localhost:8081/jobs?%s", params.Encode())
+	if baseURL == "" {
+		baseURL = DefaultBaseURL
+	}
+	apiURL := fmt.Sprintf("%s/jobs?%s", strings.TrimSuffix(baseURL, "/"), params.Encode())
 	return apiURL
 }
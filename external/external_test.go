@@ -1,6 +1,7 @@
 package external
 
 import (
+	"context"
 	"io"
 
 	"net/http"
@@ -8,15 +9,20 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
 type mockTransport struct {
 	Response   string
 	StatusCode int
+	Request    *http.Request
 }
 
 func (m *mockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	m.Request = req
 	return &http.Response{
 		StatusCode: m.StatusCode,
 		Body:       io.NopCloser(strings.NewReader(m.Response)),
@@ -53,13 +59,51 @@ func TestFetchExternalJobs(t *testing.T) {
 		logger, _ := zap.NewProduction()
 		externalJobs := NewExternalJobs(mockClient, logger)
 
-		jobs, err := externalJobs.FetchExternalJobs("Cloud Engineer", 50000, 70000, "USA")
+		jobs, err := externalJobs.FetchExternalJobs(context.Background(), "Cloud Engineer", 50000, 70000, "USA")
 		assert.NoError(t, err)
 		assert.Len(t, jobs, 1)
 		assert.Equal(t, "Cloud Engineer", jobs[0].Title)
 		assert.Equal(t, 65000, jobs[0].Salary)
 		assert.Len(t, jobs[0].Skills.Skills, 3)
 		assert.Contains(t, []string{"AWS", "Azure", "Docker"}, jobs[0].Skills.Skills[0].Name)
+		assert.Empty(t, jobs[0].Company)
+	})
+
+	t.Run("Company", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockTransport{
+				Response:   `{"USA": [["Cloud Engineer", 65000, "<skills><skill>AWS</skill></skills>", "Acme"]]}`,
+				StatusCode: http.StatusOK,
+			},
+		}
+
+		logger, _ := zap.NewProduction()
+		externalJobs := NewExternalJobs(mockClient, logger)
+
+		jobs, err := externalJobs.FetchExternalJobs(context.Background(), "Cloud Engineer", 0, 0, "USA")
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 1)
+		assert.Equal(t, "Acme", jobs[0].Company)
+		assert.Empty(t, jobs[0].WorkMode)
+	})
+
+	t.Run("Location", func(t *testing.T) {
+		mockClient := &http.Client{
+			Transport: &mockTransport{
+				Response:   `{"USA": [["Cloud Engineer", 65000, "<skills><skill>AWS</skill></skills>", "Acme", "hybrid", "California", "San Francisco"]]}`,
+				StatusCode: http.StatusOK,
+			},
+		}
+
+		logger, _ := zap.NewProduction()
+		externalJobs := NewExternalJobs(mockClient, logger)
+
+		jobs, err := externalJobs.FetchExternalJobs(context.Background(), "Cloud Engineer", 0, 0, "USA")
+		assert.NoError(t, err)
+		assert.Len(t, jobs, 1)
+		assert.Equal(t, "hybrid", jobs[0].WorkMode)
+		assert.Equal(t, "California", jobs[0].Region)
+		assert.Equal(t, "San Francisco", jobs[0].City)
 	})
 
 	t.Run("Failure", func(t *testing.T) {
@@ -73,9 +117,33 @@ func TestFetchExternalJobs(t *testing.T) {
 		logger, _ := zap.NewProduction()
 		externalJobs := NewExternalJobs(mockClient, logger)
 
-		jobs, err := externalJobs.FetchExternalJobs("Cloud Engineer", 50000, 70000, "USA")
+		jobs, err := externalJobs.FetchExternalJobs(context.Background(), "Cloud Engineer", 50000, 70000, "USA")
 		assert.Error(t, err)
 		assert.Nil(t, jobs)
 		assert.Equal(t, "unexpected status code: 500", err.Error())
 	})
 }
+
+func TestFetchExternalJobsPropagatesTraceContext(t *testing.T) {
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	tp := sdktrace.NewTracerProvider()
+	otel.SetTracerProvider(tp)
+	defer func() { _ = tp.Shutdown(context.Background()) }()
+
+	transport := &mockTransport{
+		Response:   `{"USA": []}`,
+		StatusCode: http.StatusOK,
+	}
+	logger, _ := zap.NewProduction()
+	externalJobs := NewExternalJobs(&http.Client{Transport: transport}, logger)
+
+	ctx, span := tp.Tracer("test").Start(context.Background(), "parent")
+	defer span.End()
+
+	_, err := externalJobs.FetchExternalJobs(ctx, "Cloud Engineer", 0, 0, "USA")
+	assert.NoError(t, err)
+	if assert.NotNil(t, transport.Request) {
+		traceparent := transport.Request.Header.Get("traceparent")
+		assert.Contains(t, traceparent, span.SpanContext().TraceID().String())
+	}
+}
@@ -2,29 +2,58 @@ package main
 
 import (
 	"context"
+	"fmt"
+	"jobs/config"
+	"jobs/currency"
+	d "jobs/db"
 	"jobs/external"
+	"jobs/mailer"
+	"jobs/openapi"
+	"jobs/ratelimit"
 	"jobs/server"
 	"jobs/service"
 	s "jobs/setup"
 	"net/http"
 	_ "net/http/pprof"
-	"time"
+	"os"
 
 	"github.com/grafana/pyroscope-go"
 	_ "github.com/lib/pq"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 )
 
 // main is the entry point of the application.
 //
-// It sets up the database connection, creates a new notification service, and starts the server.
+// It loads the configuration, sets up the database connection, creates a new notification service, and starts the server.
+// `jobs config print [flags]` prints the effective configuration with secrets redacted instead.
 // No parameters.
 // No return values.
 func main() {
+	args := os.Args[1:]
+	if len(args) >= 2 && args[0] == "config" && args[1] == "print" {
+		if err := printConfig(args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	cfg, err := config.Load(args)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not load config: %v\n", err)
+		os.Exit(2)
+	}
+
 	ctx := context.Background()
 
+	logger, logLevel, err := s.NewLogger(cfg.Log)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "could not configure logger: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Setup the database connection
-	db, err := s.Setup(ctx)
-	logger := db.Logger
+	db, err := s.Setup(ctx, cfg.Database, logger)
 	if err != nil {
 		logger.Sugar().Fatalf("could not configure db: %v", err)
 	}
@@ -34,23 +63,119 @@ func main() {
 		}
 	}()
 
-	_, err = pyroscope.Start(pyroscope.Config{
-		ApplicationName: "your.app.name",
-		ServerAddress:   "http://pyroscope:4040", // URL del servidor Pyroscope
-	})
-
+	shutdownTracing, err := s.SetupTracing(ctx, cfg.Tracing)
 	if err != nil {
-		logger.Sugar().Warnf("could not start Pyroscope: %v", err)
+		logger.Sugar().Fatalf("could not configure tracing: %v", err)
 	}
-	// Run pprof in a separate goroutine
-	go func() {
-		if err := http.ListenAndServe(":6060", nil); err != nil {
-			logger.Sugar().Warnf("pprof server error: %v", err)
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			logger.Sugar().Warnf("error shutting down tracing: %v", err)
 		}
 	}()
-	client := &http.Client{Timeout: 10 * time.Second}
+
+	if cfg.Pyroscope.Enabled {
+		_, err = pyroscope.Start(pyroscope.Config{
+			ApplicationName: cfg.Pyroscope.ApplicationName,
+			ServerAddress:   cfg.Pyroscope.ServerAddress,
+		})
+		if err != nil {
+			logger.Sugar().Warnf("could not start Pyroscope: %v", err)
+		}
+	}
+	// Run pprof in a separate goroutine
+	if cfg.Server.PprofPort != "" {
+		go func() {
+			if err := http.ListenAndServe(cfg.Server.PprofPort, nil); err != nil {
+				logger.Sugar().Warnf("pprof server error: %v", err)
+			}
+		}()
+	}
+	client := &http.Client{
+		Timeout:   cfg.External.Timeout,
+		Transport: otelhttp.NewTransport(http.DefaultTransport),
+	}
 	jobsFetcher := external.NewExternalJobs(client, logger)
+	jobsFetcher.BaseURL = cfg.External.BaseURL
 	jobsService := service.NewJobsService(logger, db, jobsFetcher)
-	port := ":8080"
-	server.ServerSetup(jobsService, port, logger)
+	if jobsService.Rates, err = currency.Load(cfg.Jobs.ExchangeRatesFile); err != nil {
+		logger.Sugar().Fatalf("could not load exchange rates: %v", err)
+	}
+	if cfg.External.IngestInterval > 0 {
+		jobsService.StoredExternalJobs = true
+		jobsService.ExternalJobsMaxAge = cfg.External.MaxAge
+		go jobsService.RunExternalIngestion(ctx, cfg.External.IngestInterval)
+	}
+	if cfg.Jobs.SweepInterval > 0 {
+		go jobsService.SweepExpiredJobs(ctx, cfg.Jobs.SweepInterval, cfg.Jobs.MaxAge)
+	}
+	keysService := service.NewKeysService(logger, db)
+	if cfg.Auth.BootstrapAdminKey != "" {
+		if err := keysService.BootstrapAdminKey(ctx, cfg.Auth.BootstrapAdminKey); err != nil {
+			logger.Sugar().Fatalf("could not bootstrap admin API key: %v", err)
+		}
+	}
+
+	srv := server.NewServer(ctx, jobsService, logger)
+	srv.Keys = keysService
+	if srv.TrustedProxies, err = server.ParseTrustedProxies(cfg.Server.TrustedProxies); err != nil {
+		logger.Sugar().Fatalf("could not parse trusted proxies: %v", err)
+	}
+	if cfg.Auth.SessionSecret != "" {
+		var m mailer.Mailer = mailer.NewLogMailer(logger)
+		if cfg.Mail.SMTPAddr != "" {
+			m = mailer.NewSMTPMailer(cfg.Mail.SMTPAddr, cfg.Mail.From, cfg.Mail.SMTPUser, cfg.Mail.SMTPPassword)
+		}
+		srv.Sessions = service.NewSessionsService(logger, db, m, service.SessionConfig{
+			Secret:       []byte(cfg.Auth.SessionSecret),
+			TokenTTL:     cfg.Auth.SessionTTL,
+			MagicLinkTTL: cfg.Auth.MagicLinkTTL,
+			MagicLinkURL: cfg.Auth.MagicLinkURL,
+		})
+	}
+	if cfg.RateLimit.Enabled {
+		srv.Limiter = newRateLimiter(cfg.RateLimit, db)
+	}
+	if cfg.Server.ValidateRequests {
+		router, err := openapi.NewRouter()
+		if err != nil {
+			logger.Sugar().Fatalf("could not load OpenAPI spec: %v", err)
+		}
+		srv.OpenAPI = router
+		if cfg.Server.ValidateResponses {
+			srv.OnResponseMismatch = func(r *http.Request, err error) {
+				logger.Sugar().Errorf("Response to %s %s does not match the OpenAPI spec: %v", r.Method, r.URL.Path, err)
+			}
+		}
+	}
+	srv.LogLevel = logLevel
+	server.ServerSetup(srv, cfg.Server.Port)
+}
+
+// printConfig loads the configuration like the server would and prints it with secrets redacted
+func printConfig(args []string) error {
+	cfg, err := config.Load(args)
+	if err != nil {
+		return fmt.Errorf("could not load config: %w", err)
+	}
+	return cfg.Print(os.Stdout)
+}
+
+// newRateLimiter builds the rate limiter from the configured default and per-route limits
+func newRateLimiter(cfg config.RateLimitConfig, db *d.DBConnector) *ratelimit.Limiter {
+	var store ratelimit.Store = ratelimit.NewMemoryStore()
+	if cfg.Store == "postgres" {
+		store = ratelimit.NewPostgresStore(db.DB)
+	}
+	def := ratelimit.Policy{
+		PerKey: ratelimit.Limit{Rate: cfg.PerKeyRate, Burst: cfg.PerKeyBurst},
+		PerIP:  ratelimit.Limit{Rate: cfg.PerIPRate, Burst: cfg.PerIPBurst},
+	}
+	routes := make(map[string]ratelimit.Policy, len(cfg.Routes))
+	for route, p := range cfg.Routes {
+		routes[route] = ratelimit.Policy{
+			PerKey: ratelimit.Limit{Rate: p.PerKey.Rate, Burst: p.PerKey.Burst},
+			PerIP:  ratelimit.Limit{Rate: p.PerIP.Rate, Burst: p.PerIP.Burst},
+		}
+	}
+	return ratelimit.NewLimiter(store, def, routes)
 }
@@ -0,0 +1,19 @@
+package openapi
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSpecIsValid(t *testing.T) {
+	router, err := NewRouter()
+	require.NoError(t, err)
+
+	for _, path := range []string{"/V1/jobs", "/V2/subscribers/me/jobs"} {
+		_, _, err := router.FindRoute(httptest.NewRequest("GET", path, nil))
+		assert.NoError(t, err, path)
+	}
+}
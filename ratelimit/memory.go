@@ -0,0 +1,58 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type bucket struct {
+	tokens  float64
+	updated time.Time
+}
+
+// MemoryStore keeps buckets in process memory. It is the default store; use a shared
+// store such as PostgresStore when running several replicas.
+type MemoryStore struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+	now     func() time.Time
+	lastGC  time.Time
+	// IdleTTL is how long an untouched bucket is kept before it is dropped
+	IdleTTL time.Duration
+}
+
+// NewMemoryStore creates a new instance of MemoryStore
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now, IdleTTL: 10 * time.Minute}
+}
+
+func (m *MemoryStore) Take(_ context.Context, key string, limit Limit) (Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.gc(now)
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+	tokens, res := refill(b.tokens, now.Sub(b.updated), limit)
+	b.tokens, b.updated = tokens, now
+	return res, nil
+}
+
+// gc drops idle buckets, at most once per IdleTTL
+func (m *MemoryStore) gc(now time.Time) {
+	if now.Sub(m.lastGC) < m.IdleTTL {
+		return
+	}
+	m.lastGC = now
+	for key, b := range m.buckets {
+		if now.Sub(b.updated) >= m.IdleTTL {
+			delete(m.buckets, key)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryStoreTake(t *testing.T) {
+	now := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+	limit := Limit{Rate: 1, Burst: 2}
+	ctx := context.Background()
+
+	res, err := store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 1, Reset: time.Second}, res)
+
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: true, Limit: 2, Remaining: 0, Reset: 2 * time.Second}, res)
+
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.Equal(t, Result{Allowed: false, Limit: 2, Remaining: 0, RetryAfter: time.Second, Reset: 2 * time.Second}, res)
+
+	// Other keys have their own bucket
+	res, err = store.Take(ctx, "other", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// Half a token is not enough, a full one is
+	now = now.Add(500 * time.Millisecond)
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	assert.Equal(t, 500*time.Millisecond, res.RetryAfter)
+
+	now = now.Add(500 * time.Millisecond)
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+
+	// The bucket never holds more than the burst
+	now = now.Add(time.Hour)
+	res, err = store.Take(ctx, "client", limit)
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Remaining)
+}
+
+func TestMemoryStoreDropsIdleBuckets(t *testing.T) {
+	now := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	store := NewMemoryStore()
+	store.now = func() time.Time { return now }
+
+	_, err := store.Take(context.Background(), "client", Limit{Rate: 1, Burst: 1})
+	require.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+
+	now = now.Add(store.IdleTTL)
+	_, err = store.Take(context.Background(), "other", Limit{Rate: 1, Burst: 1})
+	require.NoError(t, err)
+	assert.Len(t, store.buckets, 1)
+	assert.Contains(t, store.buckets, "other")
+}
+
+func TestLimiterPolicies(t *testing.T) {
+	l := NewLimiter(NewMemoryStore(), Policy{
+		PerKey: Limit{Rate: 1, Burst: 10},
+		PerIP:  Limit{Rate: 1, Burst: 10},
+	}, map[string]Policy{
+		"/V1/subscribe":       {PerKey: Limit{Rate: 1, Burst: 1}},
+		"/V1/auth/magic-link": {PerIP: Limit{Rate: 1, Burst: 1}},
+	})
+	ctx := context.Background()
+
+	res, limited, err := l.AllowKey(ctx, "/V1/subscribe", "key")
+	require.NoError(t, err)
+	assert.True(t, limited)
+	assert.Equal(t, 1, res.Limit)
+
+	// The route has no per IP limit
+	res, limited, err = l.AllowIP(ctx, "/V1/subscribe", "10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, limited)
+	assert.True(t, res.Allowed)
+
+	// Unknown routes use the default policy
+	res, limited, err = l.AllowKey(ctx, "/V1/jobs", "key")
+	require.NoError(t, err)
+	assert.True(t, limited)
+	assert.Equal(t, 10, res.Limit)
+
+	// Buckets are per route
+	res, _, err = l.AllowKey(ctx, "/V1/subscribe", "key")
+	require.NoError(t, err)
+	assert.False(t, res.Allowed)
+	res, _, err = l.AllowIP(ctx, "/V1/auth/magic-link", "10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, res.Allowed)
+}
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// PostgresStore shares buckets between replicas through the rate_limit_buckets table.
+// Each Take is a single atomic upsert.
+type PostgresStore struct {
+	DB  *sqlx.DB
+	now func() time.Time
+}
+
+// NewPostgresStore creates a new instance of PostgresStore
+func NewPostgresStore(db *sqlx.DB) *PostgresStore {
+	return &PostgresStore{DB: db, now: time.Now}
+}
+
+func (p *PostgresStore) Take(ctx context.Context, key string, limit Limit) (Result, error) {
+	// refilled is LEAST(burst, tokens + elapsed * rate), a token is only taken when refilled >= 1
+	const query = `
+		INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+		VALUES ($1, $3 - 1, TRUE, $4)
+		ON CONFLICT (key) DO UPDATE SET
+			allowed = LEAST($3, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - b.updated_at)), 0) * $2) >= 1,
+			tokens = LEAST($3, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - b.updated_at)), 0) * $2)
+				- CASE WHEN LEAST($3, b.tokens + GREATEST(EXTRACT(EPOCH FROM ($4 - b.updated_at)), 0) * $2) >= 1 THEN 1 ELSE 0 END,
+			updated_at = $4
+		RETURNING tokens, allowed`
+
+	var tokens float64
+	var allowed bool
+	err := p.DB.QueryRowxContext(ctx, query, key, limit.Rate, float64(limit.Burst), p.now().UTC()).Scan(&tokens, &allowed)
+	if err != nil {
+		return Result{}, fmt.Errorf("error updating rate limit bucket: %w", err)
+	}
+
+	burst := float64(limit.Burst)
+	res := Result{
+		Allowed:   allowed,
+		Limit:     limit.Burst,
+		Remaining: int(math.Floor(tokens)),
+		Reset:     secondsToDuration((burst - tokens) / limit.Rate),
+	}
+	if !allowed {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	return res, nil
+}
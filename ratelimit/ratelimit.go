@@ -0,0 +1,106 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Limit is a token bucket refilled at Rate tokens per second, holding at most Burst tokens.
+// A zero Rate disables the limit.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited reports whether the limit is disabled
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0 || l.Burst <= 0
+}
+
+// Result is the outcome of taking one token from a bucket
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// RetryAfter is how long to wait before a token is available, zero when allowed
+	RetryAfter time.Duration
+	// Reset is how long until the bucket is full again
+	Reset time.Duration
+}
+
+// Store keeps token buckets. Implementations must be safe for concurrent use.
+type Store interface {
+	Take(ctx context.Context, key string, limit Limit) (Result, error)
+}
+
+// Policy holds the limits applied to one route
+type Policy struct {
+	PerKey Limit
+	PerIP  Limit
+}
+
+// Limiter applies per-route policies on top of a Store
+type Limiter struct {
+	Store   Store
+	Default Policy
+	// Routes maps a route template, e.g. "/V1/subscribe", to its policy
+	Routes map[string]Policy
+}
+
+// NewLimiter creates a new instance of Limiter
+func NewLimiter(store Store, def Policy, routes map[string]Policy) *Limiter {
+	return &Limiter{Store: store, Default: def, Routes: routes}
+}
+
+// AllowIP takes a token from the client IP bucket of the route.
+// The second return value is false when the route has no per IP limit.
+func (l *Limiter) AllowIP(ctx context.Context, route, ip string) (Result, bool, error) {
+	return l.take(ctx, "ip", route, ip, l.policy(route).PerIP)
+}
+
+// AllowKey takes a token from the client (API key or subscriber) bucket of the route.
+// The second return value is false when the route has no per key limit.
+func (l *Limiter) AllowKey(ctx context.Context, route, key string) (Result, bool, error) {
+	return l.take(ctx, "key", route, key, l.policy(route).PerKey)
+}
+
+func (l *Limiter) policy(route string) Policy {
+	if p, ok := l.Routes[route]; ok {
+		return p
+	}
+	return l.Default
+}
+
+func (l *Limiter) take(ctx context.Context, kind, route, id string, limit Limit) (Result, bool, error) {
+	if limit.Unlimited() {
+		return Result{Allowed: true}, false, nil
+	}
+	res, err := l.Store.Take(ctx, fmt.Sprintf("%s:%s:%s", kind, route, id), limit)
+	if err != nil {
+		return Result{}, true, fmt.Errorf("could not take rate limit token: %w", err)
+	}
+	return res, true, nil
+}
+
+// refill returns the bucket content after elapsed time and the result of taking one token from it
+func refill(tokens float64, elapsed time.Duration, limit Limit) (float64, Result) {
+	burst := float64(limit.Burst)
+	tokens = math.Min(burst, tokens+elapsed.Seconds()*limit.Rate)
+
+	res := Result{Limit: limit.Burst}
+	if tokens >= 1 {
+		tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = secondsToDuration((1 - tokens) / limit.Rate)
+	}
+	res.Remaining = int(math.Floor(tokens))
+	res.Reset = secondsToDuration((burst - tokens) / limit.Rate)
+	return tokens, res
+}
+
+func secondsToDuration(s float64) time.Duration {
+	return time.Duration(math.Ceil(s * float64(time.Second)))
+}
@@ -0,0 +1,169 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+
+	"jobs/apperr"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateApplicationHandler records an application of the subscriber of the session, or of subscriber_id for API keys
+func (s *Server) CreateApplicationHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.ApplicationInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	id, err := s.resolveSubscriber(r, reqBody.SubscriberID)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if id == uuid.Nil {
+		s.sendError(w, r, apperr.Validation("validation_failed", "The request body failed validation", t.FieldError{
+			Field:   "subscriber_id",
+			Code:    "required",
+			Message: "is required with an API key",
+		}))
+		return
+	}
+
+	app, err := s.Svc.CreateApplication(r.Context(), id, reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/V1/applications/"+app.ID.String())
+	s.sendJSONResponse(w, r, http.StatusCreated, app)
+}
+
+// ListApplicationsHandler lists the applications of the subscriber of the session, or of subscriber_id
+func (s *Server) ListApplicationsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.subscriberFromQuery(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !slices.Contains(t.ApplicationStatuses, status) {
+		s.sendError(w, r, apperr.InvalidParameter("status", "must be one of "+strings.Join(t.ApplicationStatuses, ", ")))
+		return
+	}
+
+	apps, err := s.Svc.ListApplications(r.Context(), id, status)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, apps)
+}
+
+// ApplicationSummaryHandler counts the applications of the subscriber of the session, or of subscriber_id, by status
+func (s *Server) ApplicationSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.subscriberFromQuery(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	summary, err := s.Svc.SummarizeApplications(r.Context(), id)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, summary)
+}
+
+// GetApplicationHandler returns an application
+func (s *Server) GetApplicationHandler(w http.ResponseWriter, r *http.Request) {
+	app, err := s.applicationFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, app)
+}
+
+// PatchApplicationHandler moves an application along the pipeline and changes its notes or application date
+func (s *Server) PatchApplicationHandler(w http.ResponseWriter, r *http.Request) {
+	app, err := s.applicationFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	var reqBody t.ApplicationPatch
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	app, err = s.Svc.PatchApplication(r.Context(), app, reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, app)
+}
+
+// DeleteApplicationHandler removes an application and its history
+func (s *Server) DeleteApplicationHandler(w http.ResponseWriter, r *http.Request) {
+	app, err := s.applicationFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	if err := s.Svc.DeleteApplication(r.Context(), app.ID); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ApplicationEventsHandler lists the status history of an application
+func (s *Server) ApplicationEventsHandler(w http.ResponseWriter, r *http.Request) {
+	app, err := s.applicationFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	events, err := s.Svc.ListApplicationEvents(r.Context(), app.ID)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, events)
+}
+
+// applicationFromPath loads the application of the {id} path variable. Applications are the subscriber's own data,
+// sessions only reach theirs and API keys need the impersonation scope.
+func (s *Server) applicationFromPath(r *http.Request) (t.Application, error) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return t.Application{}, apperr.InvalidParameter("id", "must be a UUID")
+	}
+	app, err := s.Svc.GetApplication(r.Context(), id)
+	if err != nil {
+		return t.Application{}, err
+	}
+	if _, err := s.resolveSubscriber(r, app.SubscriberID); err != nil {
+		return t.Application{}, err
+	}
+	return app, nil
+}
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jobs/service"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestApplicationHandlers(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	jobID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	own := uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+	other := uuid.MustParse("00000000-0000-0000-0000-0000000000bb")
+	created := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	app := types.Application{ID: id, SubscriberID: own, JobID: &jobID, Source: types.SourceInternal, Title: "Backend Developer",
+		Country: "UK", Status: types.ApplicationSaved, CreatedAt: created, UpdatedAt: created}
+	appJSON := `{"id":"00000000-0000-0000-0000-000000000001","subscriber_id":"00000000-0000-0000-0000-0000000000aa",` +
+		`"job_id":"00000000-0000-0000-0000-000000000002","source":"internal","title":"Backend Developer","country":"UK",` +
+		`"status":"saved","created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}`
+	session := map[string]string{"Authorization": "Bearer valid-token"}
+	interviewing := types.ApplicationInterviewing
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		headers          map[string]string
+		body             string
+		setupMock        func(svc *MockJobsService)
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{
+			name:    "Sessions save a job",
+			method:  http.MethodPost,
+			path:    "/V1/applications",
+			headers: session,
+			body:    `{"job_id":"00000000-0000-0000-0000-000000000002"}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("CreateApplication", mock.Anything, own, types.ApplicationInput{JobID: jobID}).Return(app, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     appJSON,
+			expectedLocation: "/V1/applications/00000000-0000-0000-0000-000000000001",
+		},
+		{
+			name:           "Tracking applications requires jobs:track",
+			method:         http.MethodPost,
+			path:           "/V1/applications?subscriber_id=00000000-0000-0000-0000-0000000000aa",
+			headers:        map[string]string{"X-API-Key": "jsk_reader"},
+			body:           `{"job_id":"00000000-0000-0000-0000-000000000002"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the jobs:track scope","instance":"/V1/applications","code":"insufficient_scope"}`,
+		},
+		{
+			name:           "Applications name a job",
+			method:         http.MethodPost,
+			path:           "/V1/applications",
+			headers:        session,
+			body:           `{"status":"applied"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Unknown statuses are rejected",
+			method:         http.MethodGet,
+			path:           "/V1/applications?status=hired",
+			headers:        session,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "List the applications in a status",
+			method:  http.MethodGet,
+			path:    "/V1/applications?status=saved",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ListApplications", mock.Anything, own, types.ApplicationSaved).Return([]types.Application{app}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + appJSON + "]",
+		},
+		{
+			name:    "Count the applications by status",
+			method:  http.MethodGet,
+			path:    "/V1/applications:summary",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("SummarizeApplications", mock.Anything, own).Return(types.ApplicationSummary{
+					Counts: map[string]int{"saved": 1, "applied": 0, "interviewing": 0, "offer": 0, "rejected": 0}, Total: 1}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"counts":{"saved":1,"applied":0,"interviewing":0,"offer":0,"rejected":0},"total":1}`,
+		},
+		{
+			name:    "Sessions cannot read the applications of others",
+			method:  http.MethodGet,
+			path:    "/V1/applications/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				foreign := app
+				foreign.SubscriberID = other
+				svc.On("GetApplication", mock.Anything, id).Return(foreign, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "Move an application forward",
+			method:  http.MethodPatch,
+			path:    "/V1/applications/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			body:    `{"status":"interviewing","note":"Call on Monday"}`,
+			setupMock: func(svc *MockJobsService) {
+				moved := app
+				moved.Status = types.ApplicationInterviewing
+				svc.On("GetApplication", mock.Anything, id).Return(app, nil)
+				svc.On("PatchApplication", mock.Anything, app, types.ApplicationPatch{Status: &interviewing, Note: "Call on Monday"}).Return(moved, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   strings.Replace(appJSON, `"saved"`, `"interviewing"`, 1),
+		},
+		{
+			name:    "Applications never move back",
+			method:  http.MethodPatch,
+			path:    "/V1/applications/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			body:    `{"status":"interviewing"}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetApplication", mock.Anything, id).Return(app, nil)
+				svc.On("PatchApplication", mock.Anything, app, types.ApplicationPatch{Status: &interviewing}).Return(types.Application{}, service.ErrInvalidTransition)
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:    "Delete an unknown application",
+			method:  http.MethodDelete,
+			path:    "/V1/applications/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetApplication", mock.Anything, id).Return(types.Application{}, service.ErrApplicationNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			sm := new(MockSessionManager)
+			sm.On("ValidateSessionToken", mock.Anything, "valid-token").Return(types.SessionClaims{SubscriberID: own, Scopes: []string{types.ScopeJobsRead, types.ScopeTrack}}, nil).Maybe()
+			tt.setupMock(svc)
+
+			s, _ := newTestRouterServer(t, svc, map[string][]string{"jsk_reader": {types.ScopeJobsRead}})
+			s.Sessions = sm
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
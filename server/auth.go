@@ -0,0 +1,283 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"jobs/apperr"
+	"jobs/logging"
+	"jobs/service"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"go.uber.org/zap"
+)
+
+// APIKeyHeader carries the client API key
+const APIKeyHeader = "X-API-Key"
+
+type (
+	apiKeyCtxKey  struct{}
+	sessionCtxKey struct{}
+)
+
+// RequireScope authenticates the request and checks it grants scope.
+// Subscribers authenticate with a session token (Authorization: Bearer), clients with an API key (X-API-Key).
+// Missing or invalid credentials get a 401, credentials without the scope get a 403. The request is only validated
+// against the spec once authorized, so that anonymous callers never learn the schema.
+func (s *Server) RequireScope(scope string, next http.HandlerFunc) http.HandlerFunc {
+	next = s.OpenAPIValidationMiddleware(next).ServeHTTP
+	return func(w http.ResponseWriter, r *http.Request) {
+		if token, ok := bearerToken(r); ok {
+			s.requireSession(scope, token, next, w, r)
+			return
+		}
+
+		plain := r.Header.Get(APIKeyHeader)
+		if plain == "" {
+			w.Header().Set("WWW-Authenticate", `ApiKey header="`+APIKeyHeader+`"`)
+			sendErrorResponse(w, r, http.StatusUnauthorized, "missing_credentials", "Missing API key or session token")
+			return
+		}
+
+		key, err := s.Keys.AuthenticateAPIKey(r.Context(), plain)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIKey) {
+				w.Header().Set("WWW-Authenticate", `ApiKey header="`+APIKeyHeader+`"`)
+			}
+			s.sendError(w, r, err)
+			return
+		}
+
+		if !service.HasScope(key, scope) {
+			sendErrorResponse(w, r, http.StatusForbidden, "insufficient_scope", "API key is missing the "+scope+" scope")
+			return
+		}
+
+		if !s.allowClient(w, r, "key:"+key.ID.String()) {
+			return
+		}
+
+		logger := logging.FromContext(r.Context(), s.Logger).With(zap.Stringer("api_key_id", key.ID))
+		ctx := context.WithValue(r.Context(), apiKeyCtxKey{}, key)
+		ctx = logging.WithLogger(ctx, logger)
+		next(w, r.WithContext(ctx))
+	}
+}
+
+// requireSession validates a subscriber session token and checks it grants scope
+func (s *Server) requireSession(scope, token string, next http.HandlerFunc, w http.ResponseWriter, r *http.Request) {
+	if s.Sessions == nil {
+		sendErrorResponse(w, r, http.StatusUnauthorized, "sessions_disabled", "Session tokens are not enabled")
+		return
+	}
+
+	claims, err := s.Sessions.ValidateSessionToken(r.Context(), token)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		sendErrorResponse(w, r, http.StatusUnauthorized, "invalid_token", "Invalid or expired session token")
+		return
+	}
+	if !slices.Contains(claims.Scopes, scope) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="insufficient_scope", scope="`+scope+`"`)
+		sendErrorResponse(w, r, http.StatusForbidden, "insufficient_scope", "Session token is missing the "+scope+" scope")
+		return
+	}
+
+	if !s.allowClient(w, r, "subscriber:"+claims.SubscriberID.String()) {
+		return
+	}
+
+	logger := logging.FromContext(r.Context(), s.Logger).With(zap.Stringer("subscriber_id", claims.SubscriberID))
+	ctx := context.WithValue(r.Context(), sessionCtxKey{}, claims)
+	ctx = logging.WithLogger(ctx, logger)
+	next(w, r.WithContext(ctx))
+}
+
+// bearerToken returns the token of an "Authorization: Bearer" header
+func bearerToken(r *http.Request) (string, bool) {
+	scheme, token, ok := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", false
+	}
+	return strings.TrimSpace(token), true
+}
+
+// SessionFromContext returns the subscriber session authenticated by RequireScope, if any
+func SessionFromContext(ctx context.Context) (t.SessionClaims, bool) {
+	claims, ok := ctx.Value(sessionCtxKey{}).(t.SessionClaims)
+	return claims, ok
+}
+
+// resolveSubscriber returns the subscriber whose data the request may read.
+// Sessions always read their own subscriber; API keys need the impersonation scope to pick one
+// through the id query parameter, and every such read is audited.
+func (s *Server) resolveSubscriber(r *http.Request, requested uuid.UUID) (uuid.UUID, error) {
+	if claims, ok := SessionFromContext(r.Context()); ok {
+		if requested != uuid.Nil && requested != claims.SubscriberID {
+			return uuid.Nil, apperr.Forbidden("subscriber_mismatch", "Cannot read another subscriber's data")
+		}
+		return claims.SubscriberID, nil
+	}
+
+	if requested == uuid.Nil {
+		return uuid.Nil, nil
+	}
+	key, ok := APIKeyFromContext(r.Context())
+	if !ok || !service.HasScope(key, t.ScopeImpersonate) {
+		return uuid.Nil, apperr.Forbidden("insufficient_scope", "API key is missing the "+t.ScopeImpersonate+" scope")
+	}
+	if s.Sessions == nil {
+		return uuid.Nil, apperr.Forbidden("impersonation_disabled", "Impersonation is not enabled")
+	}
+	audit := t.ImpersonationAudit{
+		APIKeyID:     key.ID,
+		SubscriberID: requested,
+		Method:       r.Method,
+		Path:         r.URL.RequestURI(),
+		RequestID:    logging.RequestID(r.Context()),
+	}
+	if err := s.Sessions.RecordImpersonation(r.Context(), audit); err != nil {
+		return uuid.Nil, err
+	}
+	return requested, nil
+}
+
+// APIKeyFromContext returns the API key authenticated by RequireScope, if any
+func APIKeyFromContext(ctx context.Context) (t.APIKey, bool) {
+	key, ok := ctx.Value(apiKeyCtxKey{}).(t.APIKey)
+	return key, ok
+}
+
+// IssueAPIKeyHandler issues a new API key
+func (s *Server) IssueAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.IssueAPIKeyInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	resp, err := s.Keys.IssueAPIKey(r.Context(), reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusCreated, resp)
+}
+
+// ListAPIKeysHandler lists every API key without secrets
+func (s *Server) ListAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.Keys.ListAPIKeys(r.Context())
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, keys)
+}
+
+// RevokeAPIKeyHandler revokes an API key immediately
+func (s *Server) RevokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, r, apperr.InvalidParameter("id", "must be a UUID"))
+		return
+	}
+
+	if err := s.Keys.RevokeAPIKey(r.Context(), id); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RotateAPIKeyHandler replaces an API key, keeping the old one valid for an optional grace period
+func (s *Server) RotateAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		s.sendError(w, r, apperr.InvalidParameter("id", "must be a UUID"))
+		return
+	}
+
+	var reqBody t.RotateAPIKeyInput
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+			return
+		}
+	}
+	var grace time.Duration
+	if reqBody.GracePeriod != "" {
+		grace, err = time.ParseDuration(reqBody.GracePeriod)
+		if err != nil || grace < 0 {
+			s.sendError(w, r, apperr.InvalidParameter("grace_period", "must be a non-negative duration, e.g. 24h"))
+			return
+		}
+	}
+
+	resp, err := s.Keys.RotateAPIKey(r.Context(), id, grace)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusCreated, resp)
+}
+
+// MagicLinkHandler emails a sign-in link to a subscriber.
+// It always answers 202 so that callers cannot probe which emails are subscribed.
+func (s *Server) MagicLinkHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.MagicLinkInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	// The per key limit of the route applies per email, so that spreading the requests over many IPs does not
+	// flood one inbox
+	if !s.allowClient(w, r, "email:"+strings.ToLower(reqBody.Email)) {
+		return
+	}
+
+	if err := s.Sessions.RequestMagicLink(r.Context(), reqBody.Email); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// SessionTokenHandler exchanges a magic link token for a session token
+func (s *Server) SessionTokenHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.SessionTokenInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	resp, err := s.Sessions.ExchangeMagicLink(r.Context(), reqBody.Token)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSession) {
+			sendErrorResponse(w, r, http.StatusUnauthorized, "invalid_magic_link", "Invalid or expired sign-in link")
+			return
+		}
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	s.sendJSONResponse(w, r, http.StatusOK, resp)
+}
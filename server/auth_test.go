@@ -0,0 +1,451 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"jobs/service"
+	"jobs/setup"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"go.uber.org/zap"
+)
+
+type MockKeyManager struct {
+	mock.Mock
+}
+
+func (m *MockKeyManager) IssueAPIKey(ctx context.Context, input types.IssueAPIKeyInput) (types.IssueAPIKeyOutput, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(types.IssueAPIKeyOutput), args.Error(1)
+}
+
+func (m *MockKeyManager) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]types.APIKey), args.Error(1)
+}
+
+func (m *MockKeyManager) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockKeyManager) RotateAPIKey(ctx context.Context, id uuid.UUID, gracePeriod time.Duration) (types.IssueAPIKeyOutput, error) {
+	args := m.Called(ctx, id, gracePeriod)
+	return args.Get(0).(types.IssueAPIKeyOutput), args.Error(1)
+}
+
+func (m *MockKeyManager) AuthenticateAPIKey(ctx context.Context, key string) (types.APIKey, error) {
+	args := m.Called(ctx, key)
+	return args.Get(0).(types.APIKey), args.Error(1)
+}
+
+// newTestRouterServer returns a server with every route registered and the given keys accepted.
+// Requests and responses are validated against the OpenAPI spec.
+func newTestRouterServer(t *testing.T, svc service.Service, keys map[string][]string) (*Server, *MockKeyManager) {
+	logger, _ := setup.SetupLogger()
+	km := new(MockKeyManager)
+	for plain, scopes := range keys {
+		km.On("AuthenticateAPIKey", mock.Anything, plain).Return(types.APIKey{ID: uuid.New(), Scopes: scopes}, nil)
+	}
+	km.On("AuthenticateAPIKey", mock.Anything, mock.Anything).Return(types.APIKey{}, service.ErrInvalidAPIKey)
+
+	s := NewServer(context.Background(), svc, logger)
+	s.Keys = km
+	s.LogLevel = zap.NewAtomicLevel()
+	withOpenAPIValidation(t, s)
+	s.SetupRouter()
+	return s, km
+}
+
+func TestRequireScope(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		apiKey         string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Missing API key",
+			method:         http.MethodGet,
+			path:           "/V1/admin/keys",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"type":"urn:jobs:problem:missing_credentials","title":"Unauthorized","status":401,"detail":"Missing API key or session token","instance":"/V1/admin/keys","code":"missing_credentials"}`,
+		},
+		{
+			name:           "Invalid API key",
+			method:         http.MethodGet,
+			path:           "/V1/admin/keys",
+			apiKey:         "jsk_unknown",
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_api_key","title":"Unauthorized","status":401,"detail":"Invalid API key","instance":"/V1/admin/keys","code":"invalid_api_key"}`,
+		},
+		{
+			name:           "Missing scope",
+			method:         http.MethodGet,
+			path:           "/V1/admin/keys",
+			apiKey:         "jsk_reader",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the admin scope","instance":"/V1/admin/keys","code":"insufficient_scope"}`,
+		},
+		{
+			name:           "Admin scope grants access",
+			method:         http.MethodGet,
+			path:           "/V1/admin/keys",
+			apiKey:         "jsk_admin",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `[]`,
+		},
+		{
+			name:           "Subscribe scope does not grant jobs:read",
+			method:         http.MethodGet,
+			path:           "/V1/jobs",
+			apiKey:         "jsk_subscriber",
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the jobs:read scope","instance":"/V1/jobs","code":"insufficient_scope"}`,
+		},
+		{
+			name:           "Anonymous invalid bodies are not validated",
+			method:         http.MethodPost,
+			path:           "/V1/admin/keys",
+			body:           `{"scopes":["root"]}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"type":"urn:jobs:problem:missing_credentials","title":"Unauthorized","status":401,"detail":"Missing API key or session token","instance":"/V1/admin/keys","code":"missing_credentials"}`,
+		},
+	}
+
+	s, km := newTestRouterServer(t, new(MockJobsService), map[string][]string{
+		"jsk_admin":      {types.ScopeAdmin},
+		"jsk_reader":     {types.ScopeJobsRead},
+		"jsk_subscriber": {types.ScopeSubscribe},
+	})
+	km.On("ListAPIKeys", mock.Anything).Return([]types.APIKey{}, nil)
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			if tt.apiKey != "" {
+				req.Header.Set(APIKeyHeader, tt.apiKey)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			if tt.expectedStatus == http.StatusUnauthorized {
+				assert.NotEmpty(t, w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestAPIKeyAdminHandlers(t *testing.T) {
+	keyID := uuid.MustParse("7f1f8c9e-8a51-4d0b-9b7c-0e6c2c1d2a10")
+	newKeyID := uuid.MustParse("0b4c3c1e-2f0b-4c7e-9a3e-5d8f1a2b3c4d")
+	created := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		setupMock      func(km *MockKeyManager)
+		rejectedBySpec bool
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:   "Issue key",
+			method: http.MethodPost,
+			path:   "/V1/admin/keys",
+			body:   `{"name":"recruiting","scopes":["subscribe","jobs:read"]}`,
+			setupMock: func(km *MockKeyManager) {
+				km.On("IssueAPIKey", mock.Anything, types.IssueAPIKeyInput{Name: "recruiting", Scopes: []string{"subscribe", "jobs:read"}}).
+					Return(types.IssueAPIKeyOutput{
+						APIKey: types.APIKey{ID: keyID, Name: "recruiting", Prefix: "jsk_abcdefgh", Scopes: []string{"subscribe", "jobs:read"}, CreatedAt: created},
+						Key:    "jsk_abcdefgh-secret",
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":"7f1f8c9e-8a51-4d0b-9b7c-0e6c2c1d2a10","name":"recruiting","prefix":"jsk_abcdefgh","scopes":["subscribe","jobs:read"],"created_at":"2024-11-01T10:00:00Z","key":"jsk_abcdefgh-secret"}`,
+		},
+		{
+			name:           "Issue key with unknown scope",
+			method:         http.MethodPost,
+			path:           "/V1/admin/keys",
+			body:           `{"name":"recruiting","scopes":["root"]}`,
+			setupMock:      func(km *MockKeyManager) {},
+			rejectedBySpec: true,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/V1/admin/keys","code":"validation_failed","errors":[{"field":"scopes[0]","code":"enum","message":"value is not one of the allowed values [\"subscribe\",\"jobs:read\",\"jobs:write\",\"jobs:track\",\"admin\",\"admin:impersonate\"]"}]}`,
+		},
+		{
+			name:   "Revoke key",
+			method: http.MethodDelete,
+			path:   "/V1/admin/keys/" + keyID.String(),
+			setupMock: func(km *MockKeyManager) {
+				km.On("RevokeAPIKey", mock.Anything, keyID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:   "Revoke unknown key",
+			method: http.MethodDelete,
+			path:   "/V1/admin/keys/" + newKeyID.String(),
+			setupMock: func(km *MockKeyManager) {
+				km.On("RevokeAPIKey", mock.Anything, newKeyID).Return(service.ErrAPIKeyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:jobs:problem:api_key_not_found","title":"Not Found","status":404,"detail":"API key not found","instance":"/V1/admin/keys/0b4c3c1e-2f0b-4c7e-9a3e-5d8f1a2b3c4d","code":"api_key_not_found"}`,
+		},
+		{
+			name:   "Rotate key with grace period",
+			method: http.MethodPost,
+			path:   "/V1/admin/keys/" + keyID.String() + "/rotate",
+			body:   `{"grace_period":"24h"}`,
+			setupMock: func(km *MockKeyManager) {
+				km.On("RotateAPIKey", mock.Anything, keyID, 24*time.Hour).
+					Return(types.IssueAPIKeyOutput{
+						APIKey: types.APIKey{ID: newKeyID, Name: "recruiting", Prefix: "jsk_zyxwvuts", Scopes: []string{"subscribe"}, RotatedFrom: &keyID, CreatedAt: created},
+						Key:    "jsk_zyxwvuts-secret",
+					}, nil)
+			},
+			expectedStatus: http.StatusCreated,
+			expectedBody:   `{"id":"0b4c3c1e-2f0b-4c7e-9a3e-5d8f1a2b3c4d","name":"recruiting","prefix":"jsk_zyxwvuts","scopes":["subscribe"],"rotated_from":"7f1f8c9e-8a51-4d0b-9b7c-0e6c2c1d2a10","created_at":"2024-11-01T10:00:00Z","key":"jsk_zyxwvuts-secret"}`,
+		},
+		{
+			name:           "Rotate key with invalid grace period",
+			method:         http.MethodPost,
+			path:           "/V1/admin/keys/" + keyID.String() + "/rotate",
+			body:           `{"grace_period":"tomorrow"}`,
+			setupMock:      func(km *MockKeyManager) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_parameter","title":"Unprocessable Entity","status":422,"detail":"Invalid grace_period parameter","instance":"/V1/admin/keys/7f1f8c9e-8a51-4d0b-9b7c-0e6c2c1d2a10/rotate","code":"invalid_parameter","errors":[{"field":"grace_period","code":"format","message":"must be a non-negative duration, e.g. 24h"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, km := newTestRouterServer(t, new(MockJobsService), map[string][]string{"jsk_admin": {types.ScopeAdmin}})
+			tt.setupMock(km)
+
+			req := httptest.NewRequest(tt.method, tt.path, bytes.NewBufferString(tt.body))
+			req.Header.Set(APIKeyHeader, "jsk_admin")
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			if tt.rejectedBySpec {
+				// The spec is enforced once the key is authenticated, before the handler
+				km.AssertNotCalled(t, "IssueAPIKey", mock.Anything, mock.Anything)
+				return
+			}
+			km.AssertExpectations(t)
+		})
+	}
+}
+
+func TestRequireScopeInternalError(t *testing.T) {
+	logger, _ := setup.SetupLogger()
+	km := new(MockKeyManager)
+	km.On("AuthenticateAPIKey", mock.Anything, "jsk_any").Return(types.APIKey{}, errors.New("db down"))
+
+	s := NewServer(context.Background(), new(MockJobsService), logger)
+	s.Keys = km
+	handler := s.RequireScope(types.ScopeJobsRead, func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("handler should not be called")
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/V1/jobs", nil)
+	req.Header.Set(APIKeyHeader, "jsk_any")
+	w := httptest.NewRecorder()
+	handler(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"type":"urn:jobs:problem:internal_error","title":"Internal Server Error","status":500,"detail":"An internal error occurred","instance":"/V1/jobs","code":"internal_error"}`, withoutRequestID(w.Body.String()))
+}
+
+type MockSessionManager struct {
+	mock.Mock
+}
+
+func (m *MockSessionManager) RequestMagicLink(ctx context.Context, email string) error {
+	args := m.Called(ctx, email)
+	return args.Error(0)
+}
+
+func (m *MockSessionManager) ExchangeMagicLink(ctx context.Context, token string) (types.SessionOutput, error) {
+	args := m.Called(ctx, token)
+	return args.Get(0).(types.SessionOutput), args.Error(1)
+}
+
+func (m *MockSessionManager) ValidateSessionToken(ctx context.Context, token string) (types.SessionClaims, error) {
+	args := m.Called(ctx, token)
+	return args.Get(0).(types.SessionClaims), args.Error(1)
+}
+
+func (m *MockSessionManager) RecordImpersonation(ctx context.Context, audit types.ImpersonationAudit) error {
+	args := m.Called(ctx, audit)
+	return args.Error(0)
+}
+
+func TestJobsHandlerSubscriberResolution(t *testing.T) {
+	own := uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6")
+	other := uuid.MustParse("3f0c2a5e-1b7d-4c9a-8e6f-2d1b0a9c8e7f")
+
+	tests := []struct {
+		name           string
+		headers        map[string]string
+		query          string
+		setupMock      func(svc *MockJobsService, sm *MockSessionManager)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "Session reads its own jobs without id",
+			headers: map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock: func(svc *MockJobsService, sm *MockSessionManager) {
+				svc.On("ListJobs", mock.Anything, types.JobQuery{SubscriberID: own}).Return(types.JobList{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Session cannot read another subscriber",
+			headers:        map[string]string{"Authorization": "Bearer valid-token"},
+			query:          "?id=" + other.String(),
+			setupMock:      func(svc *MockJobsService, sm *MockSessionManager) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:subscriber_mismatch","title":"Forbidden","status":403,"detail":"Cannot read another subscriber's data","instance":"/V1/jobs","code":"subscriber_mismatch"}`,
+		},
+		{
+			name:           "Invalid session token",
+			headers:        map[string]string{"Authorization": "Bearer forged"},
+			setupMock:      func(svc *MockJobsService, sm *MockSessionManager) {},
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_token","title":"Unauthorized","status":401,"detail":"Invalid or expired session token","instance":"/V1/jobs","code":"invalid_token"}`,
+		},
+		{
+			name:           "API key without impersonation scope cannot pick a subscriber",
+			headers:        map[string]string{APIKeyHeader: "jsk_reader"},
+			query:          "?id=" + other.String(),
+			setupMock:      func(svc *MockJobsService, sm *MockSessionManager) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the admin:impersonate scope","instance":"/V1/jobs","code":"insufficient_scope"}`,
+		},
+		{
+			name:    "Admin impersonation is audited",
+			headers: map[string]string{APIKeyHeader: "jsk_admin", RequestIDHeader: "req-1"},
+			query:   "?id=" + other.String(),
+			setupMock: func(svc *MockJobsService, sm *MockSessionManager) {
+				sm.On("RecordImpersonation", mock.Anything, mock.MatchedBy(func(a types.ImpersonationAudit) bool {
+					return a.SubscriberID == other && a.Method == http.MethodGet && a.Path == "/V1/jobs?id="+other.String() && a.RequestID == "req-1"
+				})).Return(nil)
+				svc.On("ListJobs", mock.Anything, types.JobQuery{SubscriberID: other}).Return(types.JobList{}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			sm := new(MockSessionManager)
+			sm.On("ValidateSessionToken", mock.Anything, "valid-token").Return(types.SessionClaims{SubscriberID: own, Scopes: []string{types.ScopeJobsRead}}, nil).Maybe()
+			sm.On("ValidateSessionToken", mock.Anything, mock.Anything).Return(types.SessionClaims{}, service.ErrInvalidSession).Maybe()
+			tt.setupMock(svc, sm)
+
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_admin":  {types.ScopeAdmin},
+				"jsk_reader": {types.ScopeJobsRead},
+			})
+			s.Sessions = sm
+
+			req := httptest.NewRequest(http.MethodGet, "/V1/jobs"+tt.query, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			svc.AssertExpectations(t)
+			sm.AssertExpectations(t)
+		})
+	}
+}
+
+func TestSessionHandlers(t *testing.T) {
+	expires := time.Date(2024, time.November, 1, 11, 0, 0, 0, time.UTC)
+	sm := new(MockSessionManager)
+	sm.On("RequestMagicLink", mock.Anything, "jane@example.com").Return(nil)
+	sm.On("ExchangeMagicLink", mock.Anything, "good").Return(types.SessionOutput{AccessToken: "jwt", TokenType: "Bearer", ExpiresIn: 3600, ExpiresAt: expires}, nil)
+	sm.On("ExchangeMagicLink", mock.Anything, "used").Return(types.SessionOutput{}, service.ErrInvalidSession)
+
+	logger, _ := setup.SetupLogger()
+	s := NewServer(context.Background(), new(MockJobsService), logger)
+	s.Keys = new(MockKeyManager)
+	s.Sessions = sm
+	withOpenAPIValidation(t, s)
+	s.SetupRouter()
+
+	tests := []struct {
+		name           string
+		path           string
+		body           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{name: "Request magic link", path: "/V1/auth/magic-link", body: `{"email":"jane@example.com"}`, expectedStatus: http.StatusAccepted},
+		{
+			name:           "Request magic link with invalid email",
+			path:           "/V1/auth/magic-link",
+			body:           `{"email":"jane"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/V1/auth/magic-link","code":"validation_failed","errors":[{"field":"email","code":"email","message":"must be a valid email address"}]}`,
+		},
+		{
+			name:           "Exchange token",
+			path:           "/V1/auth/token",
+			body:           `{"token":"good"}`,
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"access_token":"jwt","token_type":"Bearer","expires_in":3600,"expires_at":"2024-11-01T11:00:00Z"}`,
+		},
+		{
+			name:           "Exchange used token",
+			path:           "/V1/auth/token",
+			body:           `{"token":"used"}`,
+			expectedStatus: http.StatusUnauthorized,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_magic_link","title":"Unauthorized","status":401,"detail":"Invalid or expired sign-in link","instance":"/V1/auth/token","code":"invalid_magic_link"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, tt.path, bytes.NewBufferString(tt.body))
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+		})
+	}
+}
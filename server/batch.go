@@ -0,0 +1,232 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"jobs/apperr"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+)
+
+// Limits of a batch import
+const (
+	maxBatchRows  = 1000
+	maxBatchBytes = 5 << 20
+)
+
+// csvListSeparator separates the values of list columns in CSV uploads, e.g. "USA;UK"
+const csvListSeparator = ";"
+
+// csvColumns are the accepted CSV columns, named like the SubscribeInput JSON fields
+var csvColumns = []string{
+	"name", "email", "job_titles", "country", "salary_min", "salary_currency", "salary_period",
+	"followed_companies", "blocked_companies",
+}
+
+// batchRow is a parsed row of an upload, err is set when the row could not be decoded
+type batchRow struct {
+	input t.SubscribeInput
+	err   error
+}
+
+// BatchSubscribeHandler imports subscribers from a JSON array or a CSV upload and reports the outcome of every row.
+// With atomic=true nothing is saved unless every row is valid.
+func (s *Server) BatchSubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	atomic := false
+	if v := r.URL.Query().Get("atomic"); v != "" {
+		var err error
+		if atomic, err = strconv.ParseBool(v); err != nil {
+			s.sendError(w, r, apperr.InvalidParameter("atomic", "must be true or false"))
+			return
+		}
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchBytes)
+	parsed, err := parseBatch(r)
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			sendErrorResponse(w, r, http.StatusRequestEntityTooLarge, "payload_too_large", fmt.Sprintf("The request body exceeds %d bytes", maxBatchBytes))
+			return
+		}
+		s.sendError(w, r, err)
+		return
+	}
+	if len(parsed) > maxBatchRows {
+		s.sendError(w, r, apperr.Validation("batch_too_large", fmt.Sprintf("A batch holds at most %d rows", maxBatchRows)))
+		return
+	}
+
+	rows := make([]t.SubscriberImportRow, len(parsed))
+	for i, p := range parsed {
+		rows[i] = t.SubscriberImportRow{Row: i + 1, Input: subscriberFromV1(p.input), Err: p.err}
+		if rows[i].Err == nil {
+			rows[i].Err = s.validateRequestBody(p.input)
+		}
+		if rows[i].Err != nil {
+			rows[i].Err = apperr.FromValidation(rows[i].Err)
+		}
+	}
+
+	output, err := s.Svc.ImportSubscribers(r.Context(), rows, atomic)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, output)
+}
+
+// parseBatch decodes the upload according to its Content-Type, JSON being the default
+func parseBatch(r *http.Request) ([]batchRow, error) {
+	mediaType := "application/json"
+	if ct := r.Header.Get("Content-Type"); ct != "" {
+		var err error
+		if mediaType, _, err = mime.ParseMediaType(ct); err != nil {
+			return nil, apperr.Validation("invalid_content_type", "The Content-Type header is malformed")
+		}
+	}
+	if mediaType == "text/csv" {
+		return parseCSVBatch(r.Body)
+	}
+	return parseJSONBatch(r.Body)
+}
+
+// parseJSONBatch decodes an array of SubscribeInput, a row that does not decode only fails that row
+func parseJSONBatch(body io.Reader) ([]batchRow, error) {
+	var raw []json.RawMessage
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return nil, err
+		}
+		return nil, apperr.Validation("invalid_json", "The request body is not a JSON array")
+	}
+
+	rows := make([]batchRow, len(raw))
+	for i, item := range raw {
+		if err := json.Unmarshal(item, &rows[i].input); err != nil {
+			rows[i].err = apperr.Validation("invalid_json", "The row is not a valid subscription")
+		}
+	}
+	return rows, nil
+}
+
+// parseCSVBatch decodes a CSV upload whose header names the columns, list columns are separated by csvListSeparator
+func parseCSVBatch(body io.Reader) ([]batchRow, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, csvError(err)
+	}
+	for i, column := range header {
+		header[i] = strings.ToLower(strings.TrimSpace(column))
+		if !slices.Contains(csvColumns, header[i]) {
+			last := len(csvColumns) - 1
+			return nil, apperr.Validation("invalid_csv", fmt.Sprintf("Unknown column %q, expected %s and %s", column,
+				strings.Join(csvColumns[:last], ", "), csvColumns[last]))
+		}
+	}
+
+	var rows []batchRow
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return rows, nil
+		}
+		if err != nil {
+			return nil, csvError(err)
+		}
+
+		var row batchRow
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			switch header[i] {
+			case "name":
+				row.input.Name = value
+			case "email":
+				row.input.Email = value
+			case "job_titles":
+				row.input.JobTitles = splitCSVList(value)
+			case "country":
+				row.input.PreferredCountries = splitCSVList(value)
+			case "salary_min":
+				if value == "" {
+					continue
+				}
+				salary, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					row.err = apperr.Validation("validation_failed", "The request body failed validation",
+						t.FieldError{Field: "salary_min", Code: "integer", Message: "must be an integer"})
+					continue
+				}
+				row.input.SalaryMin = salary
+			case "salary_currency":
+				row.input.SalaryCurrency = value
+			case "salary_period":
+				row.input.SalaryPeriod = value
+			case "followed_companies", "blocked_companies":
+				ids, err := splitCSVIDs(value)
+				if err != nil {
+					row.err = apperr.Validation("validation_failed", "The request body failed validation",
+						t.FieldError{Field: header[i], Code: "uuid", Message: "must be UUIDs"})
+					continue
+				}
+				if header[i] == "followed_companies" {
+					row.input.FollowedCompanies = ids
+				} else {
+					row.input.BlockedCompanies = ids
+				}
+			}
+		}
+		rows = append(rows, row)
+	}
+}
+
+func csvError(err error) error {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		return err
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return apperr.Validation("invalid_csv", fmt.Sprintf("Line %d: %v", parseErr.Line, parseErr.Err))
+	}
+	return apperr.Validation("invalid_csv", "The request body is not valid CSV")
+}
+
+func splitCSVList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, csvListSeparator) {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func splitCSVIDs(value string) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	for _, item := range splitCSVList(value) {
+		id, err := uuid.Parse(item)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
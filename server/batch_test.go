@@ -0,0 +1,136 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jobs/apperr"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestBatchSubscribeHandler(t *testing.T) {
+	id := uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6")
+	jane := types.SubscriberInput{
+		Name:      "Jane",
+		Email:     "jane@example.com",
+		JobTitles: []string{"Backend Developer", "Go Developer"},
+		Countries: []string{"USA", "UK"},
+		SalaryMin: 1000,
+	}
+	report := types.SubscriberImportOutput{
+		Created: 1,
+		Failed:  1,
+		Results: []types.SubscriberImportResult{
+			{Row: 1, Email: "jane@example.com", Status: types.ImportCreated, ID: &id},
+			{Row: 2, Email: "john", Status: types.ImportFailed, Code: "validation_failed", Reason: "The request body failed validation",
+				Errors: []types.FieldError{{Field: "email", Code: "email", Message: "must be a valid email address"}}},
+		},
+	}
+	reportJSON := `{"created":1,"updated":0,"failed":1,"results":[{"row":1,"email":"jane@example.com","status":"created","id":"b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"},{"row":2,"email":"john","status":"failed","code":"validation_failed","reason":"The request body failed validation","errors":[{"field":"email","code":"email","message":"must be a valid email address"}]}]}`
+
+	// janeThenInvalidEmail matches a valid first row followed by a second row failing on its email
+	janeThenInvalidEmail := mock.MatchedBy(func(rows []types.SubscriberImportRow) bool {
+		if len(rows) != 2 || rows[0].Row != 1 || rows[1].Row != 2 {
+			return false
+		}
+		e, ok := apperr.From(rows[1].Err)
+		return rows[0].Err == nil && assert.ObjectsAreEqual(jane, rows[0].Input) &&
+			ok && len(e.Fields) == 1 && e.Fields[0].Field == "email"
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		contentType    string
+		body           string
+		setupMock      func(svc *MockJobsService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:        "JSON rows are validated one by one",
+			path:        "/V1/subscribers:batch",
+			contentType: "application/json",
+			body:        `[{"name":"Jane","email":"jane@example.com","job_titles":["Backend Developer","Go Developer"],"country":["USA","UK"],"salary_min":1000},{"name":"John","email":"john","job_titles":["Designer"],"country":["USA"],"salary_min":1000}]`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ImportSubscribers", mock.Anything, janeThenInvalidEmail, false).Return(report, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   reportJSON,
+		},
+		{
+			name:        "CSV upload with list columns",
+			path:        "/V1/subscribers:batch?atomic=false",
+			contentType: "text/csv; charset=utf-8",
+			body:        "name,email,job_titles,country,salary_min\nJane,jane@example.com,Backend Developer; Go Developer,USA;UK,1000\nJohn,john,Designer,USA,1000\n",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ImportSubscribers", mock.Anything, janeThenInvalidEmail, false).Return(report, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   reportJSON,
+		},
+		{
+			name:        "Atomic import is rejected as a whole",
+			path:        "/V1/subscribers:batch?atomic=true",
+			contentType: "application/json",
+			body:        `[{"name":"Jane","email":"jane@example.com","job_titles":["Backend Developer","Go Developer"],"country":["USA","UK"],"salary_min":1000},{"name":"John","email":"john","job_titles":["Designer"],"country":["USA"],"salary_min":1000}]`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ImportSubscribers", mock.Anything, janeThenInvalidEmail, true).Return(types.SubscriberImportOutput{},
+					apperr.Validation("batch_rejected", "1 of 2 rows failed, nothing was saved",
+						types.FieldError{Field: "rows[2].email", Code: "email", Message: "must be a valid email address"}))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:batch_rejected","title":"Unprocessable Entity","status":422,"detail":"1 of 2 rows failed, nothing was saved","instance":"/V1/subscribers:batch","code":"batch_rejected","errors":[{"field":"rows[2].email","code":"email","message":"must be a valid email address"}]}`,
+		},
+		{
+			name:           "Unknown CSV column",
+			path:           "/V1/subscribers:batch",
+			contentType:    "text/csv",
+			body:           "name,email,phone\nJane,jane@example.com,555\n",
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_csv","title":"Unprocessable Entity","status":422,"detail":"Unknown column \"phone\", expected name, email, job_titles, country, salary_min, salary_currency, salary_period, followed_companies and blocked_companies","instance":"/V1/subscribers:batch","code":"invalid_csv"}`,
+		},
+		{
+			name:        "Malformed CSV salary fails the row",
+			path:        "/V1/subscribers:batch",
+			contentType: "text/csv",
+			body:        "name,email,job_titles,country,salary_min\nJane,jane@example.com,Backend Developer,USA,lots\n",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ImportSubscribers", mock.Anything, mock.MatchedBy(func(rows []types.SubscriberImportRow) bool {
+					e, ok := apperr.From(rows[0].Err)
+					return len(rows) == 1 && ok && e.Fields[0].Field == "salary_min"
+				}), false).Return(types.SubscriberImportOutput{Failed: 1, Results: []types.SubscriberImportResult{
+					{Row: 1, Email: "jane@example.com", Status: types.ImportFailed, Code: "validation_failed", Reason: "The request body failed validation"},
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			tt.setupMock(svc)
+			s, _ := newTestRouterServer(t, svc, map[string][]string{"jsk_subscribe": {types.ScopeSubscribe}})
+
+			req := httptest.NewRequest(http.MethodPost, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(APIKeyHeader, "jsk_subscribe")
+			req.Header.Set("Content-Type", tt.contentType)
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"jobs/apperr"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateCompanyHandler records a company
+func (s *Server) CreateCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.CompanyInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	company, err := s.Svc.CreateCompany(r.Context(), reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/V1/companies/"+company.ID.String())
+	s.sendJSONResponse(w, r, http.StatusCreated, company)
+}
+
+// ListCompaniesHandler lists every company by name
+func (s *Server) ListCompaniesHandler(w http.ResponseWriter, r *http.Request) {
+	companies, err := s.Svc.ListCompanies(r.Context())
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, companies)
+}
+
+// GetCompanyHandler returns a company
+func (s *Server) GetCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := companyFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	company, err := s.Svc.GetCompany(r.Context(), id)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, company)
+}
+
+// ReplaceCompanyHandler overwrites every field of a company
+func (s *Server) ReplaceCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := companyFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	var reqBody t.CompanyInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	company, err := s.Svc.ReplaceCompany(r.Context(), id, reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, company)
+}
+
+// DeleteCompanyHandler removes a company, its jobs are kept without a company
+func (s *Server) DeleteCompanyHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := companyFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	if err := s.Svc.DeleteCompany(r.Context(), id); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func companyFromPath(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return uuid.Nil, apperr.InvalidParameter("id", "must be a UUID")
+	}
+	return id, nil
+}
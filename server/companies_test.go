@@ -0,0 +1,148 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jobs/apperr"
+	"jobs/service"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCompanyHandlers(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	created := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	input := types.CompanyInput{Name: "Acme", Website: "https://acme.example"}
+	company := types.Company{ID: id, Name: "Acme", Website: "https://acme.example", CreatedAt: created, UpdatedAt: created}
+	companyJSON := `{"id":"00000000-0000-0000-0000-000000000001","name":"Acme","website":"https://acme.example","created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}`
+	inputJSON := `{"name":"Acme","website":"https://acme.example"}`
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		apiKey           string
+		body             string
+		setupMock        func(svc *MockJobsService)
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{
+			name:   "Create a company",
+			method: http.MethodPost,
+			path:   "/V1/companies",
+			apiKey: "jsk_writer",
+			body:   inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("CreateCompany", mock.Anything, input).Return(company, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     companyJSON,
+			expectedLocation: "/V1/companies/00000000-0000-0000-0000-000000000001",
+		},
+		{
+			name:   "Company names are unique",
+			method: http.MethodPost,
+			path:   "/V1/companies",
+			apiKey: "jsk_writer",
+			body:   inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("CreateCompany", mock.Anything, input).Return(types.Company{}, apperr.Conflict("conflict", "The resource already exists"))
+			},
+			expectedStatus: http.StatusConflict,
+		},
+		{
+			name:           "Website must be a URL",
+			method:         http.MethodPost,
+			path:           "/V1/companies",
+			apiKey:         "jsk_writer",
+			body:           `{"name":"Acme","website":"acme"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Reading keys cannot create",
+			method:         http.MethodPost,
+			path:           "/V1/companies",
+			apiKey:         "jsk_reader",
+			body:           inputJSON,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "List companies",
+			method: http.MethodGet,
+			path:   "/V1/companies",
+			apiKey: "jsk_reader",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ListCompanies", mock.Anything).Return([]types.Company{company}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + companyJSON + "]",
+		},
+		{
+			name:   "Get a company",
+			method: http.MethodGet,
+			path:   "/V1/companies/00000000-0000-0000-0000-000000000001",
+			apiKey: "jsk_reader",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetCompany", mock.Anything, id).Return(company, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   companyJSON,
+		},
+		{
+			name:   "Replace a company",
+			method: http.MethodPut,
+			path:   "/V1/companies/00000000-0000-0000-0000-000000000001",
+			apiKey: "jsk_writer",
+			body:   inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ReplaceCompany", mock.Anything, id, input).Return(company, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   companyJSON,
+		},
+		{
+			name:   "Delete an unknown company",
+			method: http.MethodDelete,
+			path:   "/V1/companies/00000000-0000-0000-0000-000000000001",
+			apiKey: "jsk_writer",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("DeleteCompany", mock.Anything, id).Return(service.ErrCompanyNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			tt.setupMock(svc)
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_writer": {types.ScopeJobsWrite},
+				"jsk_reader": {types.ScopeJobsRead},
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(APIKeyHeader, tt.apiKey)
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
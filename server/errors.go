@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"jobs/apperr"
+	"jobs/logging"
+	t "jobs/types"
+
+	"go.uber.org/zap"
+)
+
+// ProblemContentType is the media type of every error response (RFC 7807)
+const ProblemContentType = "application/problem+json"
+
+// problemTypePrefix turns an error code into the problem type URI
+const problemTypePrefix = "urn:jobs:problem:"
+
+var kindStatus = map[apperr.Kind]int{
+	apperr.KindNotFound:           http.StatusNotFound,
+	apperr.KindValidation:         http.StatusUnprocessableEntity,
+	apperr.KindConflict:           http.StatusConflict,
+	apperr.KindUnavailable:        http.StatusServiceUnavailable,
+	apperr.KindUnauthenticated:    http.StatusUnauthorized,
+	apperr.KindForbidden:          http.StatusForbidden,
+	apperr.KindPreconditionFailed: http.StatusPreconditionFailed,
+}
+
+// sendError maps err to a problem response. Domain errors keep their status, code and message;
+// anything else is logged and answered with an opaque 500 so that internals never leak.
+func (s *Server) sendError(w http.ResponseWriter, r *http.Request, err error) {
+	err = apperr.FromValidation(err)
+	var status int
+	e, ok := apperr.From(err)
+	if ok {
+		status, ok = kindStatus[e.Kind]
+	}
+	if !ok {
+		recordError(r, err)
+		logging.FromContext(r.Context(), s.Logger).Error("Request failed", zap.Error(err))
+		sendErrorResponse(w, r, http.StatusInternalServerError, "internal_error", "An internal error occurred")
+		return
+	}
+	if status >= http.StatusInternalServerError {
+		recordError(r, err)
+		logging.FromContext(r.Context(), s.Logger).Warn("Upstream failure", zap.Error(err))
+	}
+	writeProblem(w, r, t.Problem{Status: status, Code: e.Code, Detail: e.Message, Errors: e.Fields})
+}
+
+// sendErrorResponse sends a problem response with the given status, error code and detail
+func sendErrorResponse(w http.ResponseWriter, r *http.Request, status int, code, detail string) {
+	writeProblem(w, r, t.Problem{Status: status, Code: code, Detail: detail})
+}
+
+func writeProblem(w http.ResponseWriter, r *http.Request, p t.Problem) {
+	p.Type = problemTypePrefix + p.Code
+	p.Title = http.StatusText(p.Status)
+	p.Instance = r.URL.Path
+	p.RequestID = logging.RequestID(r.Context())
+
+	response, err := json.Marshal(p)
+	if err != nil {
+		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ProblemContentType)
+	w.WriteHeader(p.Status)
+	_, _ = w.Write(response)
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+
+	t "jobs/types"
+)
+
+// BookmarkJobHandler bookmarks a job for the subscriber of the session, or of subscriber_id
+func (s *Server) BookmarkJobHandler(w http.ResponseWriter, r *http.Request) {
+	s.saveJobAction(w, r, t.ActionBookmark)
+}
+
+// DeleteBookmarkHandler removes the bookmark of a job
+func (s *Server) DeleteBookmarkHandler(w http.ResponseWriter, r *http.Request) {
+	s.deleteJobAction(w, r, t.ActionBookmark)
+}
+
+// ListBookmarksHandler lists the bookmarks of the subscriber of the session, or of subscriber_id
+func (s *Server) ListBookmarksHandler(w http.ResponseWriter, r *http.Request) {
+	s.listJobActions(w, r, t.ActionBookmark)
+}
+
+// DismissJobHandler dismisses a job, jobs lists leave it out
+func (s *Server) DismissJobHandler(w http.ResponseWriter, r *http.Request) {
+	s.saveJobAction(w, r, t.ActionDismiss)
+}
+
+// DeleteDismissalHandler lists a dismissed job again
+func (s *Server) DeleteDismissalHandler(w http.ResponseWriter, r *http.Request) {
+	s.deleteJobAction(w, r, t.ActionDismiss)
+}
+
+// ListDismissalsHandler lists the dismissals of the subscriber of the session, or of subscriber_id
+func (s *Server) ListDismissalsHandler(w http.ResponseWriter, r *http.Request) {
+	s.listJobActions(w, r, t.ActionDismiss)
+}
+
+func (s *Server) saveJobAction(w http.ResponseWriter, r *http.Request, action string) {
+	jobID, err := jobFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	subscriberID, err := s.subscriberFromQuery(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	saved, err := s.Svc.SaveJobAction(r.Context(), action, subscriberID, jobID)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, saved)
+}
+
+func (s *Server) deleteJobAction(w http.ResponseWriter, r *http.Request, action string) {
+	jobID, err := jobFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	subscriberID, err := s.subscriberFromQuery(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	if err := s.Svc.DeleteJobAction(r.Context(), action, subscriberID, jobID); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listJobActions(w http.ResponseWriter, r *http.Request, action string) {
+	subscriberID, err := s.subscriberFromQuery(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	actions, err := s.Svc.ListJobActions(r.Context(), action, subscriberID)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, actions)
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jobs/apperr"
+	"jobs/service"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJobActionHandlers(t *testing.T) {
+	own := uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+	other := uuid.MustParse("00000000-0000-0000-0000-0000000000bb")
+	jobID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	created := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	bookmark := types.JobAction{SubscriberID: own, JobID: jobID, Source: types.SourceExternal, CreatedAt: created}
+	bookmarkJSON := `{"subscriber_id":"00000000-0000-0000-0000-0000000000aa","job_id":"00000000-0000-0000-0000-000000000002",` +
+		`"source":"external","created_at":"2024-11-01T10:00:00Z"}`
+	session := map[string]string{"Authorization": "Bearer valid-token"}
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		setupMock      func(svc *MockJobsService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "Sessions bookmark a job",
+			method:  http.MethodPut,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000002/bookmark",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("SaveJobAction", mock.Anything, types.ActionBookmark, own, jobID).Return(bookmark, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   bookmarkJSON,
+		},
+		{
+			name:    "Dismiss an unknown job",
+			method:  http.MethodPut,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000002/dismissal",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("SaveJobAction", mock.Anything, types.ActionDismiss, own, jobID).
+					Return(types.JobAction{}, apperr.Validation("unknown_reference", "A referenced resource does not exist"))
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Sessions cannot act for others",
+			method:         http.MethodPut,
+			path:           "/V1/jobs/00000000-0000-0000-0000-000000000002/dismissal?subscriber_id=00000000-0000-0000-0000-0000000000bb",
+			headers:        session,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "API keys name the subscriber",
+			method:         http.MethodPut,
+			path:           "/V1/jobs/00000000-0000-0000-0000-000000000002/bookmark",
+			headers:        map[string]string{APIKeyHeader: "jsk_admin"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Dismissing requires jobs:track",
+			method:         http.MethodPut,
+			path:           "/V1/jobs/00000000-0000-0000-0000-000000000002/dismissal?subscriber_id=00000000-0000-0000-0000-0000000000bb",
+			headers:        map[string]string{APIKeyHeader: "jsk_reader"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "API keys impersonate the subscriber",
+			method:  http.MethodDelete,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000002/dismissal?subscriber_id=00000000-0000-0000-0000-0000000000bb",
+			headers: map[string]string{APIKeyHeader: "jsk_admin"},
+			setupMock: func(svc *MockJobsService) {
+				svc.On("DeleteJobAction", mock.Anything, types.ActionDismiss, other, jobID).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:    "Remove a missing bookmark",
+			method:  http.MethodDelete,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000002/bookmark",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("DeleteJobAction", mock.Anything, types.ActionBookmark, own, jobID).Return(service.ErrJobActionNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+		{
+			name:    "List the bookmarks",
+			method:  http.MethodGet,
+			path:    "/V1/bookmarks",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ListJobActions", mock.Anything, types.ActionBookmark, own).Return([]types.JobAction{bookmark}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + bookmarkJSON + "]",
+		},
+		{
+			name:    "List the bookmarked jobs first",
+			method:  http.MethodGet,
+			path:    "/V2/subscribers/me/jobs?bookmarked_first=true",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				query := types.JobQuery{SubscriberID: own, BookmarkedFirst: true, Limit: defaultPageSize}
+				svc.On("ListJobs", mock.Anything, query).Return(types.JobList{List: types.List[types.Job]{
+					Items: []types.Job{{ID: &jobID, Source: types.SourceExternal, Title: "Backend Developer", Country: "USA", Skills: []string{}, Bookmarked: true}},
+					Total: 1,
+				}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"id":"00000000-0000-0000-0000-000000000002","source":"external","title":"Backend Developer","country":"USA","salary_min":0,"skills":[],"bookmarked":true}],"total":1}`,
+		},
+		{
+			name:           "bookmarked_first is a boolean",
+			method:         http.MethodGet,
+			path:           "/V1/jobs?bookmarked_first=first",
+			headers:        session,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			sm := new(MockSessionManager)
+			sm.On("ValidateSessionToken", mock.Anything, "valid-token").Return(types.SessionClaims{SubscriberID: own, Scopes: []string{types.ScopeJobsRead, types.ScopeTrack}}, nil).Maybe()
+			sm.On("RecordImpersonation", mock.Anything, mock.Anything).Return(nil).Maybe()
+			tt.setupMock(svc)
+
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_admin":  {types.ScopeJobsRead, types.ScopeTrack, types.ScopeImpersonate},
+				"jsk_reader": {types.ScopeJobsRead, types.ScopeImpersonate},
+			})
+			s.Sessions = sm
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(""))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
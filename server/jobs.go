@@ -0,0 +1,254 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"jobs/apperr"
+	"jobs/service"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateJobHandler publishes an internal job
+func (s *Server) CreateJobHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.JobInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	job, err := s.Svc.CreateJob(r.Context(), reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/V1/jobs/"+job.ID.String())
+	s.sendJob(w, r, http.StatusCreated, job)
+}
+
+// GetJobHandler returns an internal job with its ETag
+func (s *Server) GetJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := jobFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	job, err := s.Svc.GetJob(r.Context(), id)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJob(w, r, http.StatusOK, job)
+}
+
+// ReplaceJobHandler overwrites every field of an internal job
+func (s *Server) ReplaceJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, version, err := jobWriteTarget(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	var reqBody t.JobInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	job, err := s.Svc.ReplaceJob(r.Context(), id, reqBody, version)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJob(w, r, http.StatusOK, job)
+}
+
+// PatchJobHandler changes the fields present in the body and keeps the others
+func (s *Server) PatchJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, version, err := jobWriteTarget(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	var reqBody t.JobPatch
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	job, err := s.Svc.PatchJob(r.Context(), id, reqBody, version)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJob(w, r, http.StatusOK, job)
+}
+
+// DeleteJobHandler removes an internal job
+func (s *Server) DeleteJobHandler(w http.ResponseWriter, r *http.Request) {
+	id, version, err := jobWriteTarget(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	if err := s.Svc.DeleteJob(r.Context(), id, version); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// statusFilter parses the status query parameter. Listing jobs that are not open requires the admin scope.
+func statusFilter(r *http.Request) ([]string, error) {
+	statuses := r.URL.Query()["status"]
+	for _, status := range statuses {
+		switch status {
+		case t.JobOpen:
+		case t.JobDraft, t.JobClosed, t.JobExpired:
+			if key, ok := APIKeyFromContext(r.Context()); !ok || !service.HasScope(key, t.ScopeAdmin) {
+				return nil, apperr.Forbidden("insufficient_scope", "Listing "+status+" jobs requires the "+t.ScopeAdmin+" scope")
+			}
+		default:
+			return nil, apperr.InvalidParameter("status", "must be one of draft, open, closed and expired")
+		}
+	}
+	return statuses, nil
+}
+
+// wildcardFilter rejects a list parameter mixing ALL with specific values
+func wildcardFilter(name string, values []string) error {
+	if t.MixesWildcard(values) {
+		return apperr.InvalidParameter(name, "cannot combine "+t.Wildcard+" with other values")
+	}
+	return nil
+}
+
+// locationFilter parses the work_mode and city query parameters, which replace the subscriber location preferences.
+// Every work mode is looked for in city when both are set.
+func locationFilter(r *http.Request) ([]t.LocationPreference, error) {
+	params := r.URL.Query()
+	modes, city := params["work_mode"], strings.TrimSpace(params.Get("city"))
+	if len(city) > 255 {
+		return nil, apperr.InvalidParameter("city", "must be at most 255 characters")
+	}
+	if len(modes) == 0 {
+		if city == "" {
+			return nil, nil
+		}
+		return []t.LocationPreference{{City: city}}, nil
+	}
+	locations := make([]t.LocationPreference, 0, len(modes))
+	for _, mode := range modes {
+		if !slices.Contains(t.Vocabularies["work_mode"], mode) {
+			return nil, apperr.InvalidParameter("work_mode", "must be one of onsite, hybrid and remote")
+		}
+		locations = append(locations, t.LocationPreference{WorkMode: mode, City: city})
+	}
+	return locations, nil
+}
+
+// maxSearchLength caps the q parameter
+const maxSearchLength = 200
+
+// searchText returns the q parameter, a full-text search over the jobs
+func searchText(r *http.Request) (string, error) {
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if len(q) > maxSearchLength {
+		return "", apperr.InvalidParameter("q", "must be at most "+strconv.Itoa(maxSearchLength)+" characters")
+	}
+	return q, nil
+}
+
+// sinceLastSeenValue is the only mode of the since parameter
+const sinceLastSeenValue = "last_seen"
+
+// sinceLastSeen parses the since query parameter, last_seen lists the jobs first seen after the high-water mark
+func sinceLastSeen(r *http.Request) (bool, error) {
+	switch r.URL.Query().Get("since") {
+	case "":
+		return false, nil
+	case sinceLastSeenValue:
+		return true, nil
+	}
+	return false, apperr.InvalidParameter("since", "must be "+sinceLastSeenValue)
+}
+
+// bookmarkedFirst parses the bookmarked_first query parameter, which lists the bookmarked jobs before the others
+func bookmarkedFirst(r *http.Request) (bool, error) {
+	v := r.URL.Query().Get("bookmarked_first")
+	if v == "" {
+		return false, nil
+	}
+	first, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, apperr.InvalidParameter("bookmarked_first", "must be true or false")
+	}
+	return first, nil
+}
+
+// sendJob writes a job along with the ETag of its version
+func (s *Server) sendJob(w http.ResponseWriter, r *http.Request, code int, job t.Job) {
+	if job.UpdatedAt != nil {
+		w.Header().Set("ETag", jobETag(*job.UpdatedAt))
+	}
+	s.sendJSONResponse(w, r, code, job)
+}
+
+// jobETag is a strong ETag made of the microseconds of updated_at, the precision Postgres stores
+func jobETag(updatedAt time.Time) string {
+	return `"` + strconv.FormatInt(updatedAt.UnixMicro(), 10) + `"`
+}
+
+func jobFromPath(r *http.Request) (uuid.UUID, error) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return uuid.Nil, apperr.InvalidParameter("id", "must be a UUID")
+	}
+	return id, nil
+}
+
+// jobWriteTarget returns the job to write and the version named by If-Match.
+// The version is nil without If-Match or with "*"; an ETag this server could not have issued never matches.
+func jobWriteTarget(r *http.Request) (uuid.UUID, *time.Time, error) {
+	id, err := jobFromPath(r)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	ifMatch := strings.TrimSpace(r.Header.Get("If-Match"))
+	if ifMatch == "" || ifMatch == "*" {
+		return id, nil, nil
+	}
+	unquoted, ok := strings.CutPrefix(ifMatch, `"`)
+	if ok {
+		unquoted, ok = strings.CutSuffix(unquoted, `"`)
+	}
+	micros, err := strconv.ParseInt(unquoted, 10, 64)
+	if !ok || err != nil {
+		return uuid.Nil, nil, service.ErrJobModified
+	}
+	version := time.UnixMicro(micros).UTC()
+	return id, &version, nil
+}
@@ -0,0 +1,254 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jobs/apperr"
+	"jobs/service"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestJobHandlers(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	posted := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	updated := time.Date(2024, time.November, 2, 10, 0, 0, 123456000, time.UTC)
+	etag := `"1730541600123456"`
+	input := types.JobInput{Title: "Backend Developer", Description: "Go services", Location: "Remote", Country: "USA", SalaryMin: 3000}
+	job := types.Job{ID: &id, Source: types.SourceInternal, Title: "Backend Developer", Description: "Go services", Location: "Remote", Country: "USA", SalaryMin: 3000, Skills: []string{}, PostedAt: &posted, UpdatedAt: &updated}
+	jobJSON := `{"id":"00000000-0000-0000-0000-000000000001","source":"internal","title":"Backend Developer","description":"Go services","location":"Remote","country":"USA","salary_min":3000,"skills":[],"posted_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-02T10:00:00.123456Z"}`
+	inputJSON := `{"title":"Backend Developer","description":"Go services","location":"Remote","country":"USA","salary_min":3000}`
+	salary := int64(4000)
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		apiKey           string
+		ifMatch          string
+		body             string
+		setupMock        func(svc *MockJobsService)
+		expectedStatus   int
+		expectedBody     string
+		expectedETag     string
+		expectedLocation string
+	}{
+		{
+			name:   "Publish a job",
+			method: http.MethodPost,
+			path:   "/V1/jobs",
+			apiKey: "jsk_writer",
+			body:   inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("CreateJob", mock.Anything, input).Return(job, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     jobJSON,
+			expectedETag:     etag,
+			expectedLocation: "/V1/jobs/00000000-0000-0000-0000-000000000001",
+		},
+		{
+			name:           "Title outside the vocabulary",
+			method:         http.MethodPost,
+			path:           "/V1/jobs",
+			apiKey:         "jsk_writer",
+			body:           `{"title":"Chef","country":"USA"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Reading keys cannot publish",
+			method:         http.MethodPost,
+			path:           "/V1/jobs",
+			apiKey:         "jsk_reader",
+			body:           inputJSON,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:   "Read a job with its ETag",
+			method: http.MethodGet,
+			path:   "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey: "jsk_reader",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetJob", mock.Anything, id).Return(job, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   jobJSON,
+			expectedETag:   etag,
+		},
+		{
+			name:   "Unknown job",
+			method: http.MethodGet,
+			path:   "/V1/jobs/00000000-0000-0000-0000-000000000002",
+			apiKey: "jsk_reader",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetJob", mock.Anything, uuid.MustParse("00000000-0000-0000-0000-000000000002")).
+					Return(types.Job{}, service.ErrJobNotFound.WithMessage("Job 00000000-0000-0000-0000-000000000002 not found"))
+			},
+			expectedStatus: http.StatusNotFound,
+			expectedBody:   `{"type":"urn:jobs:problem:job_not_found","title":"Not Found","status":404,"detail":"Job 00000000-0000-0000-0000-000000000002 not found","instance":"/V1/jobs/00000000-0000-0000-0000-000000000002","code":"job_not_found"}`,
+		},
+		{
+			name:    "Replace the version named by If-Match",
+			method:  http.MethodPut,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey:  "jsk_writer",
+			ifMatch: etag,
+			body:    inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ReplaceJob", mock.Anything, id, input, &updated).Return(job, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   jobJSON,
+			expectedETag:   etag,
+		},
+		{
+			name:    "Replace a modified job",
+			method:  http.MethodPut,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey:  "jsk_writer",
+			ifMatch: etag,
+			body:    inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ReplaceJob", mock.Anything, id, input, &updated).Return(types.Job{}, service.ErrJobModified)
+			},
+			expectedStatus: http.StatusPreconditionFailed,
+			expectedBody:   `{"type":"urn:jobs:problem:job_modified","title":"Precondition Failed","status":412,"detail":"The job was modified since it was read","instance":"/V1/jobs/00000000-0000-0000-0000-000000000001","code":"job_modified"}`,
+		},
+		{
+			name:           "ETags this server did not issue never match",
+			method:         http.MethodPut,
+			path:           "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey:         "jsk_writer",
+			ifMatch:        `W/"1730541600123456"`,
+			body:           inputJSON,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "Patch only changes the given fields",
+			method: http.MethodPatch,
+			path:   "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey: "jsk_writer",
+			body:   `{"salary_min":4000}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("PatchJob", mock.Anything, id, types.JobPatch{SalaryMin: &salary}, (*time.Time)(nil)).Return(job, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   jobJSON,
+			expectedETag:   etag,
+		},
+		{
+			name:   "Patch clears the fields sent as null",
+			method: http.MethodPatch,
+			path:   "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey: "jsk_writer",
+			body:   `{"salary_min":4000,"salary_max":null,"company_id":null}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("PatchJob", mock.Anything, id, types.JobPatch{SalaryMin: &salary, ClearCompanyID: true, ClearSalaryMax: true}, (*time.Time)(nil)).
+					Return(job, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   jobJSON,
+			expectedETag:   etag,
+		},
+		{
+			name:    "Delete any version",
+			method:  http.MethodDelete,
+			path:    "/V1/jobs/00000000-0000-0000-0000-000000000001",
+			apiKey:  "jsk_writer",
+			ifMatch: "*",
+			setupMock: func(svc *MockJobsService) {
+				svc.On("DeleteJob", mock.Anything, id, (*time.Time)(nil)).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			tt.setupMock(svc)
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_writer": {types.ScopeJobsWrite},
+				"jsk_reader": {types.ScopeJobsRead},
+			})
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(APIKeyHeader, tt.apiKey)
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			assert.Equal(t, tt.expectedETag, w.Header().Get("ETag"))
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
+
+func TestJobInputVocabulary(t *testing.T) {
+	s := NewServer(context.Background(), new(MockJobsService), nil)
+	title := "Chef"
+
+	assert.NoError(t, s.validateRequestBody(types.JobInput{Title: "Backend Developer", Country: "UK"}))
+	assert.Error(t, s.validateRequestBody(types.JobInput{Title: "Chef", Country: "UK"}))
+	assert.Error(t, s.validateRequestBody(types.JobInput{Title: "Backend Developer", Country: "ALL"}))
+	assert.NoError(t, s.validateRequestBody(types.JobPatch{}))
+	assert.Error(t, s.validateRequestBody(types.JobPatch{Title: &title}))
+}
+
+func TestStatusFilter(t *testing.T) {
+	admin := types.APIKey{ID: uuid.New(), Scopes: []string{types.ScopeAdmin}}
+	reader := types.APIKey{ID: uuid.New(), Scopes: []string{types.ScopeJobsRead}}
+
+	tests := []struct {
+		name          string
+		query         string
+		key           *types.APIKey
+		expected      []string
+		expectedError string
+	}{
+		{name: "No filter", query: "", key: &reader},
+		{name: "Anyone lists open jobs", query: "status=open", key: &reader, expected: []string{types.JobOpen}},
+		{name: "Admins list closed jobs", query: "status=open&status=closed", key: &admin, expected: []string{types.JobOpen, types.JobClosed}},
+		{name: "Other keys cannot", query: "status=closed", key: &reader, expectedError: "insufficient_scope"},
+		{name: "Sessions cannot", query: "status=draft", expectedError: "insufficient_scope"},
+		{name: "Unknown status", query: "status=archived", key: &admin, expectedError: "invalid_parameter"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/V1/jobs?"+tt.query, nil)
+			if tt.key != nil {
+				req = req.WithContext(context.WithValue(req.Context(), apiKeyCtxKey{}, *tt.key))
+			}
+
+			statuses, err := statusFilter(req)
+
+			if tt.expectedError != "" {
+				e, ok := apperr.From(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expectedError, e.Code)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, statuses)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+
+	"jobs/logging"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRequestIDAndAccessLogMiddleware(t *testing.T) {
+	tests := []struct {
+		name        string
+		requestID   string
+		expectReuse bool
+	}{
+		{name: "Accepts incoming request ID", requestID: "abc-123", expectReuse: true},
+		{name: "Generates request ID when missing", requestID: "", expectReuse: false},
+		{name: "Replaces invalid request ID", requestID: "bad id\n", expectReuse: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core, logs := observer.New(zap.InfoLevel)
+			server := NewServer(context.Background(), new(MockJobsService), zap.New(core))
+			server.TrustedProxies = []netip.Prefix{netip.MustParsePrefix("192.0.2.1/32"), netip.MustParsePrefix("10.0.0.0/8")}
+
+			var ctxRequestID string
+			router := mux.NewRouter()
+			router.Use(server.RequestIDMiddleware, server.AccessLogMiddleware)
+			router.HandleFunc("/V1/jobs", func(w http.ResponseWriter, r *http.Request) {
+				ctxRequestID = logging.RequestID(r.Context())
+				logging.FromContext(r.Context(), nil).Info("handler log")
+				w.WriteHeader(http.StatusTeapot)
+				_, _ = w.Write([]byte("hello"))
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/V1/jobs?id=1", nil)
+			req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+			if tt.requestID != "" {
+				req.Header.Set(RequestIDHeader, tt.requestID)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			returned := w.Header().Get(RequestIDHeader)
+			assert.NotEmpty(t, returned)
+			assert.Equal(t, returned, ctxRequestID)
+			if tt.expectReuse {
+				assert.Equal(t, tt.requestID, returned)
+			} else {
+				assert.NotEqual(t, tt.requestID, returned)
+			}
+
+			entries := logs.All()
+			if assert.Len(t, entries, 2) {
+				assert.Equal(t, returned, entries[0].ContextMap()["request_id"])
+
+				access := entries[1].ContextMap()
+				assert.Equal(t, "request completed", entries[1].Message)
+				assert.Equal(t, returned, access["request_id"])
+				assert.Equal(t, http.MethodGet, access["method"])
+				assert.Equal(t, "/V1/jobs", access["route"])
+				assert.EqualValues(t, http.StatusTeapot, access["status"])
+				assert.EqualValues(t, 5, access["bytes"])
+				assert.Equal(t, "203.0.113.7", access["client_ip"])
+				assert.Contains(t, access, "latency")
+			}
+		})
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.0.0.0/8", "192.0.2.1"})
+	assert.NoError(t, err)
+	server := &Server{TrustedProxies: proxies}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		headers    map[string]string
+		expectedIP string
+	}{
+		{name: "Connection address without headers", remoteAddr: "198.51.100.4:41000", expectedIP: "198.51.100.4"},
+		{
+			name:       "Spoofed X-Forwarded-For from an untrusted client",
+			remoteAddr: "198.51.100.4:41000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7"},
+			expectedIP: "198.51.100.4",
+		},
+		{
+			name:       "Spoofed X-Real-IP from an untrusted client",
+			remoteAddr: "198.51.100.4:41000",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.7"},
+			expectedIP: "198.51.100.4",
+		},
+		{
+			name:       "X-Forwarded-For from a trusted proxy",
+			remoteAddr: "192.0.2.1:41000",
+			headers:    map[string]string{"X-Forwarded-For": "203.0.113.7, 10.0.0.1"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:       "Forged hops before the client are ignored",
+			remoteAddr: "10.0.0.2:41000",
+			headers:    map[string]string{"X-Forwarded-For": "1.2.3.4, 203.0.113.7"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:       "X-Real-IP from a trusted proxy",
+			remoteAddr: "10.0.0.2:41000",
+			headers:    map[string]string{"X-Real-IP": "203.0.113.7"},
+			expectedIP: "203.0.113.7",
+		},
+		{
+			name:       "Every hop trusted",
+			remoteAddr: "10.0.0.2:41000",
+			headers:    map[string]string{"X-Forwarded-For": "10.0.0.3, 10.0.0.1"},
+			expectedIP: "10.0.0.3",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/V1/jobs", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			assert.Equal(t, tt.expectedIP, server.clientIP(req))
+		})
+	}
+}
+
+func TestParseTrustedProxies(t *testing.T) {
+	proxies, err := ParseTrustedProxies([]string{"10.1.2.3/8", "::ffff:192.0.2.1", "2001:db8::/32"})
+	assert.NoError(t, err)
+	assert.Equal(t, []netip.Prefix{
+		netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("192.0.2.1/32"), netip.MustParsePrefix("2001:db8::/32"),
+	}, proxies)
+
+	_, err = ParseTrustedProxies([]string{"proxy.local"})
+	assert.ErrorContains(t, err, `invalid trusted proxy "proxy.local"`)
+}
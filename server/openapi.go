@@ -0,0 +1,218 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"jobs/apperr"
+	"jobs/openapi"
+	t "jobs/types"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	v5emb "github.com/swaggest/swgui/v5emb"
+)
+
+// validationOptions leaves authentication to RequireScope and reports every schema error at once
+var validationOptions = &openapi3filter.Options{
+	AuthenticationFunc:    openapi3filter.NoopAuthenticationFunc,
+	MultiError:            true,
+	IncludeResponseStatus: true,
+}
+
+// OpenAPISpecHandler serves the embedded OpenAPI document
+func (s *Server) OpenAPISpecHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/yaml")
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write(openapi.Spec)
+}
+
+// SwaggerUIHandler serves the bundled Swagger UI for the embedded document
+func SwaggerUIHandler() http.Handler {
+	return v5emb.New("Job Subscription API", "/V1/openapi.yml", "/V1/docs/")
+}
+
+// OpenAPIValidationMiddleware rejects requests that do not match the spec with a 422. RequireScope runs it after
+// authentication, public routes are wrapped in it directly. Routes missing from the spec are passed through. When OnResponseMismatch is set,
+// responses are validated too and mismatches are reported to it.
+func (s *Server) OpenAPIValidationMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.OpenAPI == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		route, pathParams, err := s.OpenAPI.FindRoute(r)
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Bodies have always been read as JSON, keep accepting clients that do not say so
+		if r.ContentLength != 0 && r.Header.Get("Content-Type") == "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options:    validationOptions,
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			if unsupportedMediaType(err) {
+				sendErrorResponse(w, r, http.StatusUnsupportedMediaType, "unsupported_media_type", "Content-Type "+r.Header.Get("Content-Type")+" is not supported")
+				return
+			}
+			s.sendError(w, r, requestValidationError(err))
+			return
+		}
+
+		if s.OnResponseMismatch == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &responseBuffer{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		s.validateResponse(input, rec)
+		rec.flush(w)
+	})
+}
+
+func (s *Server) validateResponse(input *openapi3filter.RequestValidationInput, rec *responseBuffer) {
+	err := openapi3filter.ValidateResponse(input.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 rec.status,
+		Header:                 rec.header,
+		Body:                   io.NopCloser(bytes.NewReader(rec.body.Bytes())),
+		Options:                validationOptions,
+	})
+	if err != nil {
+		s.OnResponseMismatch(input.Request, err)
+	}
+}
+
+// requestValidationError turns kin-openapi errors into the same problems the handlers return
+func requestValidationError(err error) error {
+	var fields []t.FieldError
+	for _, e := range unpackErrors(err) {
+		var reqErr *openapi3filter.RequestError
+		if !errors.As(e, &reqErr) {
+			continue
+		}
+		if reqErr.Parameter != nil {
+			return apperr.InvalidParameter(reqErr.Parameter.Name, parameterReason(reqErr)).Wrap(err)
+		}
+
+		schemaErrs := schemaErrors(reqErr.Err)
+		if len(schemaErrs) == 0 {
+			return apperr.Validation("invalid_json", "The request body is not valid JSON").Wrap(err)
+		}
+		for _, schemaErr := range schemaErrs {
+			fields = append(fields, t.FieldError{
+				Field:   schemaFieldPath(schemaErr.JSONPointer()),
+				Code:    schemaErr.SchemaField,
+				Message: schemaErr.Reason,
+			})
+		}
+	}
+	if len(fields) == 0 {
+		return apperr.Validation("invalid_request", "The request does not match the API specification").Wrap(err)
+	}
+	e := apperr.Validation("validation_failed", "The request body failed validation", fields...)
+	return e.Wrap(err)
+}
+
+// schemaErrors collects the schema errors behind a request body error
+func schemaErrors(err error) []*openapi3.SchemaError {
+	var errs []*openapi3.SchemaError
+	for _, e := range unpackErrors(err) {
+		var schemaErr *openapi3.SchemaError
+		if errors.As(e, &schemaErr) {
+			errs = append(errs, schemaErr)
+		}
+	}
+	return errs
+}
+
+// schemaFieldPath renders a JSON pointer the way validator field errors are named, e.g. scopes[0]
+func schemaFieldPath(pointer []string) string {
+	var b strings.Builder
+	for _, p := range pointer {
+		if _, err := strconv.Atoi(p); err == nil {
+			b.WriteString("[" + p + "]")
+			continue
+		}
+		if b.Len() > 0 {
+			b.WriteByte('.')
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// unsupportedMediaType reports whether the request body has a Content-Type the operation does not accept
+func unsupportedMediaType(err error) bool {
+	for _, e := range unpackErrors(err) {
+		var reqErr *openapi3filter.RequestError
+		if errors.As(e, &reqErr) && reqErr.RequestBody != nil && strings.HasPrefix(reqErr.Reason, "header Content-Type has unexpected value") {
+			return true
+		}
+	}
+	return false
+}
+
+// formatReasons matches the messages the handlers used before the spec was enforced
+var formatReasons = map[string]string{
+	"uuid":      "must be a UUID",
+	"date-time": "must be an RFC 3339 date-time",
+}
+
+func parameterReason(reqErr *openapi3filter.RequestError) string {
+	var schemaErr *openapi3.SchemaError
+	if errors.As(reqErr.Err, &schemaErr) {
+		if reason, ok := formatReasons[schemaErr.Schema.Format]; ok && schemaErr.SchemaField == "format" {
+			return reason
+		}
+		return schemaErr.Reason
+	}
+	if reqErr.Reason != "" {
+		return reqErr.Reason
+	}
+	return "is invalid"
+}
+
+// unpackErrors flattens the openapi3.MultiError returned with MultiError enabled
+func unpackErrors(err error) []error {
+	multi, ok := err.(openapi3.MultiError)
+	if !ok {
+		return []error{err}
+	}
+	var errs []error
+	for _, e := range multi {
+		errs = append(errs, unpackErrors(e)...)
+	}
+	return errs
+}
+
+// responseBuffer holds a response until it has been validated
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header         { return b.header }
+func (b *responseBuffer) Write(p []byte) (int, error) { return b.body.Write(p) }
+func (b *responseBuffer) WriteHeader(code int)        { b.status = code }
+
+func (b *responseBuffer) flush(w http.ResponseWriter) {
+	for k, v := range b.header {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(b.status)
+	_, _ = w.Write(b.body.Bytes())
+}
@@ -0,0 +1,131 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jobs/openapi"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// withOpenAPIValidation turns on the test mode: requests are validated and
+// any response that does not match the spec fails the test
+func withOpenAPIValidation(t *testing.T, s *Server) {
+	t.Helper()
+	router, err := openapi.NewRouter()
+	require.NoError(t, err)
+	s.OpenAPI = router
+	s.OnResponseMismatch = func(r *http.Request, err error) {
+		t.Errorf("response to %s %s does not match the OpenAPI spec: %v", r.Method, r.URL.Path, err)
+	}
+}
+
+func TestOpenAPIRequestValidation(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		body           string
+		contentType    string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:           "Missing required fields",
+			method:         http.MethodPost,
+			path:           "/V1/subscribe",
+			body:           `{"name":"Jane","email":"jane@example.com","job_titles":["Backend Developer"],"salary_min":"high"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/V1/subscribe","code":"validation_failed","errors":[{"field":"salary_min","code":"type","message":"value must be an integer"},{"field":"country","code":"required","message":"property \"country\" is missing"}]}`,
+		},
+		{
+			name:           "Malformed JSON",
+			method:         http.MethodPost,
+			path:           "/V1/subscribe",
+			body:           `{"name":`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_json","title":"Unprocessable Entity","status":422,"detail":"The request body is not valid JSON","instance":"/V1/subscribe","code":"invalid_json"}`,
+		},
+		{
+			name:           "Unsupported content type",
+			method:         http.MethodPost,
+			path:           "/V1/subscribe",
+			body:           `name=Jane`,
+			contentType:    "text/plain",
+			expectedStatus: http.StatusUnsupportedMediaType,
+			expectedBody:   `{"type":"urn:jobs:problem:unsupported_media_type","title":"Unsupported Media Type","status":415,"detail":"Content-Type text/plain is not supported","instance":"/V1/subscribe","code":"unsupported_media_type"}`,
+		},
+		{
+			name:           "Malformed query parameter",
+			method:         http.MethodGet,
+			path:           "/V1/jobs?posted_date=yesterday",
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_parameter","title":"Unprocessable Entity","status":422,"detail":"Invalid posted_date parameter","instance":"/V1/jobs","code":"invalid_parameter","errors":[{"field":"posted_date","code":"format","message":"must be an RFC 3339 date-time"}]}`,
+		},
+	}
+
+	s, _ := newTestRouterServer(t, new(MockJobsService), map[string][]string{"jsk_admin": {types.ScopeAdmin}})
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			req.Header.Set(APIKeyHeader, "jsk_admin")
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+		})
+	}
+}
+
+func TestJobsHandlerCountryFilter(t *testing.T) {
+	svc := new(MockJobsService)
+	subscriber := uuid.New()
+	svc.On("ListJobs", mock.Anything, types.JobQuery{
+		SubscriberID: subscriber,
+		JobTitles:    []string{"Backend Developer"},
+		Countries:    []string{"USA", "UK"},
+	}).Return(types.JobList{List: types.List[types.Job]{
+		Items: []types.Job{{Source: types.SourceExternal, Title: "Backend Developer", Country: "USA", SalaryMin: 5000, Skills: []string{"Go"}}},
+		Total: 1,
+	}}, nil)
+	sm := new(MockSessionManager)
+	sm.On("ValidateSessionToken", mock.Anything, "jwt").Return(types.SessionClaims{SubscriberID: subscriber, Scopes: []string{types.ScopeJobsRead}}, nil)
+	s, _ := newTestRouterServer(t, svc, nil)
+	s.Sessions = sm
+
+	req := httptest.NewRequest(http.MethodGet, "/V1/jobs?job_titles=Backend+Developer&country=USA&country=UK", nil)
+	req.Header.Set("Authorization", "Bearer jwt")
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `{"internal_jobs":[],"external_jobs":[{"title":"Backend Developer","salary":5000,"skills":{"skills":["Go"]}}]}`, w.Body.String())
+	svc.AssertExpectations(t)
+}
+
+func TestOpenAPIDocs(t *testing.T) {
+	s, _ := newTestRouterServer(t, new(MockJobsService), nil)
+
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/V1/openapi.yml", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/yaml", w.Header().Get("Content-Type"))
+	assert.Equal(t, openapi.Spec, w.Body.Bytes())
+
+	w = httptest.NewRecorder()
+	s.Router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/V1/docs/", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/V1/openapi.yml")
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jobs/logging"
+	"jobs/ratelimit"
+
+	"go.uber.org/zap"
+)
+
+// RateLimitMiddleware limits requests per client IP on every route.
+// Authenticated clients are additionally limited per API key or subscriber in RequireScope.
+func (s *Server) RateLimitMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.Limiter == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		res, limited, err := s.Limiter.AllowIP(r.Context(), routeTemplate(r), s.clientIP(r))
+		if !s.checkRateLimit(w, r, res, limited, err) {
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowClient takes a token from the authenticated client bucket and reports whether the request may proceed
+func (s *Server) allowClient(w http.ResponseWriter, r *http.Request, clientID string) bool {
+	if s.Limiter == nil {
+		return true
+	}
+	res, limited, err := s.Limiter.AllowKey(r.Context(), routeTemplate(r), clientID)
+	return s.checkRateLimit(w, r, res, limited, err)
+}
+
+// checkRateLimit sets the RateLimit-* headers and answers 429 when the bucket is empty.
+// Store failures let the request through so that a broken shared store does not take the API down.
+func (s *Server) checkRateLimit(w http.ResponseWriter, r *http.Request, res ratelimit.Result, limited bool, err error) bool {
+	if err != nil {
+		logging.FromContext(r.Context(), s.Logger).Warn("Rate limit store unavailable, allowing request", zap.Error(err))
+		return true
+	}
+	if !limited {
+		return true
+	}
+
+	// The per client check runs after the per IP one, the most restrictive bucket wins the headers
+	h := w.Header()
+	if prev, err := strconv.Atoi(h.Get("RateLimit-Remaining")); err != nil || res.Remaining <= prev {
+		h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+		h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+	}
+	if res.Allowed {
+		return true
+	}
+
+	h.Set("Retry-After", strconv.Itoa(ceilSeconds(res.RetryAfter)))
+	sendErrorResponse(w, r, http.StatusTooManyRequests, "rate_limited", "Rate limit exceeded, retry later")
+	return false
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"jobs/ratelimit"
+	"jobs/setup"
+	types "jobs/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type failingStore struct{}
+
+func (failingStore) Take(context.Context, string, ratelimit.Limit) (ratelimit.Result, error) {
+	return ratelimit.Result{}, errors.New("connection refused")
+}
+
+func TestRateLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   ratelimit.Policy
+		requests []*http.Request
+		// expected status of each request
+		expectedStatus []int
+		expectedBody   string
+	}{
+		{
+			name:   "Per API key limit",
+			policy: ratelimit.Policy{PerKey: ratelimit.Limit{Rate: 0.5, Burst: 2}},
+			requests: []*http.Request{
+				adminRequest("jsk_admin", "10.0.0.1"),
+				adminRequest("jsk_admin", "10.0.0.2"),
+				adminRequest("jsk_other", "10.0.0.1"),
+				adminRequest("jsk_admin", "10.0.0.3"),
+			},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			expectedBody:   `{"type":"urn:jobs:problem:rate_limited","title":"Too Many Requests","status":429,"detail":"Rate limit exceeded, retry later","instance":"/V1/admin/keys","code":"rate_limited"}`,
+		},
+		{
+			name:   "Per IP limit ignores forwarded headers from untrusted clients",
+			policy: ratelimit.Policy{PerIP: ratelimit.Limit{Rate: 0.5, Burst: 1}},
+			requests: []*http.Request{
+				forwardedRequest(adminRequest("jsk_admin", "10.0.0.1"), "203.0.113.1"),
+				forwardedRequest(adminRequest("jsk_admin", "10.0.0.1"), "203.0.113.2"),
+			},
+			expectedStatus: []int{http.StatusOK, http.StatusTooManyRequests},
+			expectedBody:   `{"type":"urn:jobs:problem:rate_limited","title":"Too Many Requests","status":429,"detail":"Rate limit exceeded, retry later","instance":"/V1/admin/keys","code":"rate_limited"}`,
+		},
+		{
+			name:   "Per IP limit applies before authentication",
+			policy: ratelimit.Policy{PerIP: ratelimit.Limit{Rate: 0.5, Burst: 1}},
+			requests: []*http.Request{
+				adminRequest("jsk_admin", "10.0.0.1"),
+				adminRequest("jsk_admin", "10.0.0.2"),
+				adminRequest("jsk_unknown", "10.0.0.1"),
+			},
+			expectedStatus: []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests},
+			expectedBody:   `{"type":"urn:jobs:problem:rate_limited","title":"Too Many Requests","status":429,"detail":"Rate limit exceeded, retry later","instance":"/V1/admin/keys","code":"rate_limited"}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, km := newTestRouterServer(t, new(MockJobsService), map[string][]string{
+				"jsk_admin": {types.ScopeAdmin},
+				"jsk_other": {types.ScopeAdmin},
+			})
+			km.On("ListAPIKeys", mock.Anything).Return([]types.APIKey{}, nil)
+			s.Limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), tt.policy, nil)
+
+			var w *httptest.ResponseRecorder
+			for i, req := range tt.requests {
+				w = httptest.NewRecorder()
+				s.Router.ServeHTTP(w, req)
+				assert.Equal(t, tt.expectedStatus[i], w.Code, "request %d", i)
+				assert.NotEmpty(t, w.Header().Get("RateLimit-Limit"), "request %d", i)
+			}
+
+			assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			assert.Equal(t, "2", w.Header().Get("Retry-After"))
+			assert.Equal(t, "0", w.Header().Get("RateLimit-Remaining"))
+			assert.NotEmpty(t, w.Header().Get("RateLimit-Reset"))
+		})
+	}
+}
+
+func TestRateLimitMagicLinkPerEmail(t *testing.T) {
+	sm := new(MockSessionManager)
+	sm.On("RequestMagicLink", mock.Anything, mock.Anything).Return(nil)
+	logger, _ := setup.SetupLogger()
+	s := NewServer(context.Background(), new(MockJobsService), logger)
+	s.Keys = new(MockKeyManager)
+	s.Sessions = sm
+	s.Limiter = ratelimit.NewLimiter(ratelimit.NewMemoryStore(), ratelimit.Policy{}, map[string]ratelimit.Policy{
+		"/V1/auth/magic-link": {PerKey: ratelimit.Limit{Rate: 0.001, Burst: 1}},
+	})
+	withOpenAPIValidation(t, s)
+	s.SetupRouter()
+
+	send := func(email, ip string) int {
+		req := httptest.NewRequest(http.MethodPost, "/V1/auth/magic-link", strings.NewReader(`{"email":"`+email+`"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.RemoteAddr = ip + ":41000"
+		w := httptest.NewRecorder()
+		s.Router.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	assert.Equal(t, http.StatusAccepted, send("jane@example.com", "10.0.0.1"))
+	// Another IP does not get a fresh bucket for the same email
+	assert.Equal(t, http.StatusTooManyRequests, send("Jane@example.com", "10.0.0.2"))
+	assert.Equal(t, http.StatusAccepted, send("john@example.com", "10.0.0.2"))
+	sm.AssertNumberOfCalls(t, "RequestMagicLink", 2)
+}
+
+func TestRateLimitStoreFailureAllowsRequests(t *testing.T) {
+	s, km := newTestRouterServer(t, new(MockJobsService), map[string][]string{"jsk_admin": {types.ScopeAdmin}})
+	km.On("ListAPIKeys", mock.Anything).Return([]types.APIKey{}, nil)
+	limit := ratelimit.Limit{Rate: 1, Burst: 1}
+	s.Limiter = ratelimit.NewLimiter(failingStore{}, ratelimit.Policy{PerKey: limit, PerIP: limit}, nil)
+
+	w := httptest.NewRecorder()
+	s.Router.ServeHTTP(w, adminRequest("jsk_admin", "10.0.0.1"))
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get("RateLimit-Limit"))
+}
+
+// forwardedRequest sets the client IP a proxy would forward
+func forwardedRequest(req *http.Request, ip string) *http.Request {
+	req.Header.Set("X-Forwarded-For", ip)
+	req.Header.Set("X-Real-IP", ip)
+	return req
+}
+
+// adminRequest lists the API keys with the given key, coming from ip
+func adminRequest(apiKey, ip string) *http.Request {
+	req := httptest.NewRequest(http.MethodGet, "/V1/admin/keys", nil)
+	req.Header.Set(APIKeyHeader, apiKey)
+	req.RemoteAddr = ip + ":41000"
+	return req
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"jobs/apperr"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// CreateSavedSearchHandler records a saved search of the subscriber of the session, or of subscriber_id for API keys
+func (s *Server) CreateSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.SavedSearchInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	owner, err := s.resolveSubscriber(r, reqBody.SubscriberID)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if owner == uuid.Nil {
+		s.sendError(w, r, apperr.Validation("validation_failed", "The request body failed validation", t.FieldError{
+			Field:   "subscriber_id",
+			Code:    "required",
+			Message: "is required with an API key",
+		}))
+		return
+	}
+
+	search, err := s.Svc.CreateSavedSearch(r.Context(), owner, reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/V1/saved-searches/"+search.ID.String())
+	s.sendJSONResponse(w, r, http.StatusCreated, search)
+}
+
+// ListSavedSearchesHandler lists the saved searches of the subscriber of the session, or of subscriber_id
+func (s *Server) ListSavedSearchesHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.subscriberFromQuery(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	searches, err := s.Svc.ListSavedSearches(r.Context(), id)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, searches)
+}
+
+// GetSavedSearchHandler returns a saved search
+func (s *Server) GetSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	search, err := s.subscriberSavedSearch(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, search)
+}
+
+// ReplaceSavedSearchHandler overwrites every field of a saved search but its subscriber
+func (s *Server) ReplaceSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	search, err := s.subscriberSavedSearch(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	var reqBody t.SavedSearchInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	search, err = s.Svc.ReplaceSavedSearch(r.Context(), search.ID, reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, search)
+}
+
+// DeleteSavedSearchHandler removes a saved search
+func (s *Server) DeleteSavedSearchHandler(w http.ResponseWriter, r *http.Request) {
+	search, err := s.subscriberSavedSearch(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	if err := s.Svc.DeleteSavedSearch(r.Context(), search.ID); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// SavedSearchJobsHandler lists a page of the jobs matching a saved search
+func (s *Server) SavedSearchJobsHandler(w http.ResponseWriter, r *http.Request) {
+	search, err := s.subscriberSavedSearch(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	cursor, limit, err := pageFromRequest(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	since, err := sinceLastSeen(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	list, err := s.Svc.ListSavedSearchJobs(r.Context(), search, cursor, limit, since)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, list)
+}
+
+// subscriberSavedSearch loads the saved search of the {id} path variable, reading or changing it is reading or
+// changing its subscriber's data
+func (s *Server) subscriberSavedSearch(r *http.Request) (t.SavedSearch, error) {
+	search, err := s.savedSearchFromPath(r)
+	if err != nil {
+		return t.SavedSearch{}, err
+	}
+	if _, err := s.resolveSubscriber(r, search.SubscriberID); err != nil {
+		return t.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+func (s *Server) savedSearchFromPath(r *http.Request) (t.SavedSearch, error) {
+	id, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		return t.SavedSearch{}, apperr.InvalidParameter("id", "must be a UUID")
+	}
+	return s.Svc.GetSavedSearch(r.Context(), id)
+}
@@ -0,0 +1,251 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"jobs/service"
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSavedSearchHandlers(t *testing.T) {
+	id := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	own := uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+	other := uuid.MustParse("00000000-0000-0000-0000-0000000000bb")
+	created := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	input := types.SavedSearchInput{SubscriberID: own, Name: "Go in the UK", Countries: []string{"UK"}, Skills: []string{"Go"}}
+	search := types.SavedSearch{ID: id, SubscriberID: own, Name: "Go in the UK", JobTitles: []string{}, Countries: []string{"UK"},
+		Skills: []string{"Go"}, Channel: types.ChannelEmail, Frequency: types.FrequencyDaily, CreatedAt: created, UpdatedAt: created}
+	searchJSON := `{"id":"00000000-0000-0000-0000-000000000001","subscriber_id":"00000000-0000-0000-0000-0000000000aa","name":"Go in the UK",` +
+		`"job_titles":[],"countries":["UK"],"salary_min":0,"skills":["Go"],"channel":"email","frequency":"daily",` +
+		`"created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}`
+	inputJSON := `{"subscriber_id":"00000000-0000-0000-0000-0000000000aa","name":"Go in the UK","countries":["UK"],"skills":["Go"]}`
+	session := map[string]string{"Authorization": "Bearer valid-token"}
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		headers          map[string]string
+		body             string
+		setupMock        func(svc *MockJobsService)
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+		// audited tells whether the request is recorded as an impersonation
+		audited bool
+	}{
+		{
+			name:    "Create a saved search for a subscriber",
+			method:  http.MethodPost,
+			path:    "/V1/saved-searches",
+			headers: map[string]string{APIKeyHeader: "jsk_admin"},
+			body:    inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("CreateSavedSearch", mock.Anything, own, input).Return(search, nil)
+			},
+			audited:          true,
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     searchJSON,
+			expectedLocation: "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+		},
+		{
+			name:           "API keys name the subscriber",
+			method:         http.MethodPost,
+			path:           "/V1/saved-searches",
+			headers:        map[string]string{APIKeyHeader: "jsk_admin"},
+			body:           `{"name":"Go in the UK"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/V1/saved-searches","code":"validation_failed","errors":[{"field":"subscriber_id","code":"required","message":"is required with an API key"}]}`,
+		},
+		{
+			name:           "Webhooks need a URL",
+			method:         http.MethodPost,
+			path:           "/V1/saved-searches",
+			headers:        map[string]string{APIKeyHeader: "jsk_admin"},
+			body:           `{"subscriber_id":"00000000-0000-0000-0000-0000000000aa","name":"Go","channel":"webhook"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/V1/saved-searches","code":"validation_failed","errors":[{"field":"webhook_url","code":"required_if","message":"is required when channel is webhook"}]}`,
+		},
+		{
+			name:    "Sessions create their saved searches",
+			method:  http.MethodPost,
+			path:    "/V1/saved-searches",
+			headers: session,
+			body:    `{"name":"Go in the UK","countries":["UK"],"skills":["Go"]}`,
+			setupMock: func(svc *MockJobsService) {
+				body := input
+				body.SubscriberID = uuid.Nil
+				svc.On("CreateSavedSearch", mock.Anything, own, body).Return(search, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     searchJSON,
+			expectedLocation: "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+		},
+		{
+			name:           "Sessions cannot create saved searches for others",
+			method:         http.MethodPost,
+			path:           "/V1/saved-searches",
+			headers:        session,
+			body:           `{"subscriber_id":"00000000-0000-0000-0000-0000000000bb","name":"Go in the UK"}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:           "API keys need the impersonation scope to create saved searches",
+			method:         http.MethodPost,
+			path:           "/V1/saved-searches",
+			headers:        map[string]string{APIKeyHeader: "jsk_tracker"},
+			body:           inputJSON,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the admin:impersonate scope","instance":"/V1/saved-searches","code":"insufficient_scope"}`,
+		},
+		{
+			name:           "The subscribe scope no longer writes saved searches",
+			method:         http.MethodPost,
+			path:           "/V1/saved-searches",
+			headers:        map[string]string{APIKeyHeader: "jsk_subscribe"},
+			body:           inputJSON,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "Sessions list their saved searches",
+			method:  http.MethodGet,
+			path:    "/V1/saved-searches",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("ListSavedSearches", mock.Anything, own).Return([]types.SavedSearch{search}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   "[" + searchJSON + "]",
+		},
+		{
+			name:           "API keys list the saved searches of a subscriber",
+			method:         http.MethodGet,
+			path:           "/V1/saved-searches",
+			headers:        map[string]string{APIKeyHeader: "jsk_reader"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Sessions cannot read the saved searches of others",
+			method:  http.MethodGet,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				foreign := search
+				foreign.SubscriberID = other
+				svc.On("GetSavedSearch", mock.Anything, id).Return(foreign, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "List the jobs of a saved search",
+			method:  http.MethodGet,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001/jobs?limit=5",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSavedSearch", mock.Anything, id).Return(search, nil)
+				svc.On("ListSavedSearchJobs", mock.Anything, search, "", 5, false).Return(types.JobList{List: types.List[types.Job]{Items: []types.Job{}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[],"total":0}`,
+		},
+		{
+			name:    "Replace a saved search",
+			method:  http.MethodPut,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+			headers: map[string]string{APIKeyHeader: "jsk_admin"},
+			body:    inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSavedSearch", mock.Anything, id).Return(search, nil)
+				svc.On("ReplaceSavedSearch", mock.Anything, id, input).Return(search, nil)
+			},
+			audited:        true,
+			expectedStatus: http.StatusOK,
+			expectedBody:   searchJSON,
+		},
+		{
+			name:    "Sessions delete their saved searches",
+			method:  http.MethodDelete,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSavedSearch", mock.Anything, id).Return(search, nil)
+				svc.On("DeleteSavedSearch", mock.Anything, id).Return(nil)
+			},
+			expectedStatus: http.StatusNoContent,
+		},
+		{
+			name:    "Sessions cannot replace the saved searches of others",
+			method:  http.MethodPut,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+			headers: session,
+			body:    inputJSON,
+			setupMock: func(svc *MockJobsService) {
+				foreign := search
+				foreign.SubscriberID = other
+				svc.On("GetSavedSearch", mock.Anything, id).Return(foreign, nil)
+			},
+			expectedStatus: http.StatusForbidden,
+		},
+		{
+			name:    "Delete an unknown saved search",
+			method:  http.MethodDelete,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001",
+			headers: map[string]string{APIKeyHeader: "jsk_admin"},
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSavedSearch", mock.Anything, id).Return(types.SavedSearch{}, service.ErrSavedSearchNotFound)
+			},
+			expectedStatus: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			sm := new(MockSessionManager)
+			sm.On("ValidateSessionToken", mock.Anything, "valid-token").Return(types.SessionClaims{SubscriberID: own, Scopes: []string{types.ScopeJobsRead, types.ScopeTrack}}, nil).Maybe()
+			sm.On("RecordImpersonation", mock.Anything, mock.Anything).Return(nil).Maybe()
+			tt.setupMock(svc)
+
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_admin":     {types.ScopeJobsRead, types.ScopeTrack, types.ScopeImpersonate},
+				"jsk_subscribe": {types.ScopeSubscribe},
+				"jsk_tracker":   {types.ScopeTrack},
+				"jsk_reader":    {types.ScopeJobsRead},
+			})
+			s.Sessions = sm
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			svc.AssertExpectations(t)
+			if tt.audited {
+				sm.AssertCalled(t, "RecordImpersonation", mock.Anything, mock.Anything)
+			} else {
+				sm.AssertNotCalled(t, "RecordImpersonation", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
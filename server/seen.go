@@ -0,0 +1,91 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"jobs/apperr"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+)
+
+// AcknowledgeJobsV1Handler advances the high-water mark of the subscriber of the session, or of the id parameter
+func (s *Server) AcknowledgeJobsV1Handler(w http.ResponseWriter, r *http.Request) {
+	var requested uuid.UUID
+	if v := r.URL.Query().Get("id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			s.sendError(w, r, apperr.InvalidParameter("id", "must be a UUID"))
+			return
+		}
+		requested = id
+	}
+	id, err := s.resolveSubscriber(r, requested)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if id == uuid.Nil {
+		s.sendError(w, r, apperr.InvalidParameter("id", "is required with an API key"))
+		return
+	}
+	s.acknowledgeJobs(w, r, id)
+}
+
+// AcknowledgeJobsHandler advances the high-water mark of a subscriber
+func (s *Server) AcknowledgeJobsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.subscriberFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.acknowledgeJobs(w, r, id)
+}
+
+// AcknowledgeSavedSearchJobsHandler advances the high-water mark of a saved search
+func (s *Server) AcknowledgeSavedSearchJobsHandler(w http.ResponseWriter, r *http.Request) {
+	search, err := s.subscriberSavedSearch(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	input, err := readAcknowledgement(r)
+	if err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+
+	mark, err := s.Svc.AcknowledgeSavedSearchJobs(r.Context(), search.ID, input)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, mark)
+}
+
+func (s *Server) acknowledgeJobs(w http.ResponseWriter, r *http.Request, subscriberID uuid.UUID) {
+	input, err := readAcknowledgement(r)
+	if err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+
+	mark, err := s.Svc.AcknowledgeJobs(r.Context(), subscriberID, input)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, mark)
+}
+
+// readAcknowledgement decodes the optional body of an acknowledgement, without one the mark moves to now
+func readAcknowledgement(r *http.Request) (t.AcknowledgeInput, error) {
+	var input t.AcknowledgeInput
+	if err := json.NewDecoder(r.Body).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		return t.AcknowledgeInput{}, err
+	}
+	return input, nil
+}
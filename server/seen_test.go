@@ -0,0 +1,170 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSinceLastSeenHandlers(t *testing.T) {
+	own := uuid.MustParse("00000000-0000-0000-0000-0000000000aa")
+	searchID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	jobID := uuid.MustParse("00000000-0000-0000-0000-000000000002")
+	seen := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	search := types.SavedSearch{ID: searchID, SubscriberID: own, Name: "Go", JobTitles: []string{}, Countries: []string{}, Skills: []string{},
+		Channel: types.ChannelEmail, Frequency: types.FrequencyDaily, CreatedAt: seen, UpdatedAt: seen}
+	session := map[string]string{"Authorization": "Bearer valid-token"}
+	markJSON := `{"last_seen_at":"2024-11-01T10:00:00Z"}`
+
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		headers        map[string]string
+		body           string
+		setupMock      func(svc *MockJobsService)
+		expectedStatus int
+		expectedBody   string
+	}{
+		{
+			name:    "List the jobs since the last seen one",
+			method:  http.MethodGet,
+			path:    "/V2/subscribers/me/jobs?since=last_seen",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				query := types.JobQuery{SubscriberID: own, SinceLastSeen: true, Limit: defaultPageSize}
+				svc.On("ListJobs", mock.Anything, query).Return(types.JobList{
+					List: types.List[types.Job]{
+						Items: []types.Job{{ID: &jobID, Source: types.SourceInternal, Title: "Backend Developer", Country: "USA",
+							Skills: []string{}, FirstSeenAt: &seen}},
+						Total: 1,
+					},
+					SeenUntil: &seen,
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"id":"00000000-0000-0000-0000-000000000002","source":"internal","title":"Backend Developer","country":"USA","salary_min":0,"skills":[],"first_seen_at":"2024-11-01T10:00:00Z"}],"total":1,"seen_until":"2024-11-01T10:00:00Z"}`,
+		},
+		{
+			name:    "V1 reports the mark to acknowledge",
+			method:  http.MethodGet,
+			path:    "/V1/jobs?since=last_seen",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				query := types.JobQuery{SubscriberID: own, SinceLastSeen: true}
+				svc.On("ListJobs", mock.Anything, query).Return(types.JobList{SeenUntil: &seen}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"internal_jobs":[],"external_jobs":[],"seen_until":"2024-11-01T10:00:00Z"}`,
+		},
+		{
+			name:           "Unknown since mode",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/me/jobs?since=yesterday",
+			headers:        session,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:    "Acknowledge until a mark",
+			method:  http.MethodPost,
+			path:    "/V2/subscribers/me/jobs:acknowledge",
+			headers: session,
+			body:    `{"seen_until":"2024-11-01T10:00:00Z"}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("AcknowledgeJobs", mock.Anything, own, types.AcknowledgeInput{SeenUntil: &seen}).Return(types.SeenMark{LastSeenAt: seen}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   markJSON,
+		},
+		{
+			name:    "Acknowledge without a body",
+			method:  http.MethodPost,
+			path:    "/V1/jobs:acknowledge",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("AcknowledgeJobs", mock.Anything, own, types.AcknowledgeInput{}).Return(types.SeenMark{LastSeenAt: seen}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   markJSON,
+		},
+		{
+			name:           "API keys name the subscriber to acknowledge for",
+			method:         http.MethodPost,
+			path:           "/V1/jobs:acknowledge",
+			headers:        map[string]string{APIKeyHeader: "jsk_tracker"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Acknowledging needs the jobs:track scope",
+			method:         http.MethodPost,
+			path:           "/V1/jobs:acknowledge?id=00000000-0000-0000-0000-0000000000aa",
+			headers:        map[string]string{APIKeyHeader: "jsk_reader"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the jobs:track scope","instance":"/V1/jobs:acknowledge","code":"insufficient_scope"}`,
+		},
+		{
+			name:    "Acknowledge the jobs of a saved search",
+			method:  http.MethodPost,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001/jobs:acknowledge",
+			headers: session,
+			body:    `{}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSavedSearch", mock.Anything, searchID).Return(search, nil)
+				svc.On("AcknowledgeSavedSearchJobs", mock.Anything, searchID, types.AcknowledgeInput{}).Return(types.SeenMark{LastSeenAt: seen}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   markJSON,
+		},
+		{
+			name:    "List the jobs of a saved search since the last seen one",
+			method:  http.MethodGet,
+			path:    "/V1/saved-searches/00000000-0000-0000-0000-000000000001/jobs?since=last_seen",
+			headers: session,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSavedSearch", mock.Anything, searchID).Return(search, nil)
+				svc.On("ListSavedSearchJobs", mock.Anything, search, "", defaultPageSize, true).Return(types.JobList{List: types.List[types.Job]{Items: []types.Job{}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[],"total":0}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			sm := new(MockSessionManager)
+			sm.On("ValidateSessionToken", mock.Anything, "valid-token").Return(types.SessionClaims{SubscriberID: own, Scopes: []string{types.ScopeJobsRead, types.ScopeTrack}}, nil).Maybe()
+			tt.setupMock(svc)
+
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_reader":  {types.ScopeJobsRead, types.ScopeImpersonate},
+				"jsk_tracker": {types.ScopeJobsRead, types.ScopeTrack},
+			})
+			s.Sessions = sm
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -4,15 +4,27 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"net/netip"
+	"reflect"
+	"slices"
+	"strings"
 	"time"
 
+	"jobs/apperr"
+	"jobs/logging"
+	"jobs/ratelimit"
 	"jobs/service"
 	t "jobs/types"
 
+	"github.com/getkin/kin-openapi/routers"
 	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
@@ -20,15 +32,43 @@ import (
 type Server struct {
 	Logger   *zap.Logger
 	Svc      service.Service // Use the Service interface
-	ctx      context.Context
-	validate *validator.Validate
-	Router   *mux.Router
+	Keys     service.KeyManager
+	Sessions service.SessionManager
+	// Limiter rate limits requests, nil disables rate limiting
+	Limiter *ratelimit.Limiter
+	// TrustedProxies are the proxies whose X-Forwarded-For and X-Real-IP headers tell the client IP
+	TrustedProxies []netip.Prefix
+	// OpenAPI validates requests against the embedded spec, nil disables validation
+	OpenAPI routers.Router
+	// OnResponseMismatch, when set, also validates responses and reports the ones that do not match the spec
+	OnResponseMismatch func(r *http.Request, err error)
+	ctx                context.Context
+	validate           *validator.Validate
+	Router             *mux.Router
+	LogLevel           zap.AtomicLevel
 }
 
 var errorResponse = "could not send response"
 
 func NewServer(ctx context.Context, svc service.Service, logger *zap.Logger) *Server {
 	v := validator.New()
+	// Report JSON field names in validation errors
+	v.RegisterTagNameFunc(func(f reflect.StructField) string {
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || name == "" {
+			return f.Name
+		}
+		return name
+	})
+	// enum=<type> checks a value against the vocabulary of a Postgres enum
+	_ = v.RegisterValidation("enum", func(fl validator.FieldLevel) bool {
+		return slices.Contains(t.Vocabularies[fl.Param()], fl.Field().String())
+	})
+	// wildcard rejects lists mixing ALL with specific values
+	_ = v.RegisterValidation("wildcard", func(fl validator.FieldLevel) bool {
+		values, ok := fl.Field().Interface().([]string)
+		return !ok || !t.MixesWildcard(values)
+	})
 	return &Server{
 		Svc:      svc,
 		Logger:   logger,
@@ -49,42 +89,42 @@ func (s *Server) validateRequestBody(body interface{}) error {
 // SubscribeHandler handles subscription requests
 func (s *Server) SubscribeHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		sendErrorResponse(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		sendErrorResponse(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method "+r.Method+" is not allowed")
 		return
 	}
 
 	var reqBody t.SubscribeInput
 	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
-		sendErrorResponse(w, http.StatusUnprocessableEntity, "Invalid JSON format")
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
 		return
 	}
 
 	if err := s.validateRequestBody(reqBody); err != nil {
-		sendErrorResponse(w, http.StatusUnprocessableEntity, fmt.Sprintf("Validation error: %s", err))
+		s.sendError(w, r, err)
 		return
 	}
 
-	resp, err := s.Svc.Subscribe(s.ctx, reqBody)
+	sub, _, err := s.Svc.SaveSubscriber(r.Context(), subscriberFromV1(reqBody))
 	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		s.sendError(w, r, err)
 		return
 	}
 
-	respBytes, err := json.Marshal(resp)
+	respBytes, err := json.Marshal(subscribeOutputV1(sub))
 	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, "Failed to marshal response")
+		s.sendError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if _, err = w.Write(respBytes); err != nil {
-		s.Logger.Error(errorResponse, zap.Error(err))
+		logging.FromContext(r.Context(), s.Logger).Error(errorResponse, zap.Error(err))
 	}
 }
 func (s *Server) JobsHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		sendErrorResponse(w, http.StatusMethodNotAllowed, http.StatusText(http.StatusMethodNotAllowed))
+		sendErrorResponse(w, r, http.StatusMethodNotAllowed, "method_not_allowed", "Method "+r.Method+" is not allowed")
 		return
 	}
 
@@ -92,74 +132,182 @@ func (s *Server) JobsHandler(w http.ResponseWriter, r *http.Request) {
 
 	idStr := queryParams.Get("id")
 	postedDateStr := queryParams.Get("posted_date")
-	var input t.JobsInput
+	var input t.JobQuery
+	var requestedID uuid.UUID
 	if idStr != "" {
 		id, err := uuid.Parse(idStr)
 		if err != nil {
-			sendErrorResponse(w, http.StatusUnprocessableEntity, "Invalid User ID format")
+			s.sendError(w, r, apperr.InvalidParameter("id", "must be a UUID"))
 			return
 		}
-		input.UserID = id
+		requestedID = id
 	}
+	userID, err := s.resolveSubscriber(r, requestedID)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	input.SubscriberID = userID
 
 	if postedDateStr != "" {
 		postedDate, err := time.Parse(time.RFC3339, postedDateStr)
 		if err != nil {
-			sendErrorResponse(w, http.StatusUnprocessableEntity, "Invalid posted_date format")
+			s.sendError(w, r, apperr.InvalidParameter("posted_date", "must be an RFC 3339 date-time"))
 			return
 		}
-		input.PostedDate = postedDate
+		input.PostedAfter = postedDate
 	}
 	input.JobTitles = queryParams["job_titles"]
+	input.Countries = queryParams["country"]
+	if err := wildcardFilter("job_titles", input.JobTitles); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if err := wildcardFilter("country", input.Countries); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if input.Statuses, err = statusFilter(r); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if input.Text, err = searchText(r); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if input.SinceLastSeen, err = sinceLastSeen(r); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if input.BookmarkedFirst, err = bookmarkedFirst(r); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
 
-	output, err := s.Svc.GetJobs(s.ctx, input)
+	list, err := s.Svc.ListJobs(r.Context(), input)
 	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, err.Error())
+		s.sendError(w, r, err)
 		return
 	}
 
-	respBytes, err := json.Marshal(output)
+	respBytes, err := json.Marshal(jobsOutputV1(list))
 	if err != nil {
-		sendErrorResponse(w, http.StatusInternalServerError, "Failed to marshal response")
+		s.sendError(w, r, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	if _, err = w.Write(respBytes); err != nil {
-		s.Logger.Error("Error writing response", zap.Error(err))
+		logging.FromContext(r.Context(), s.Logger).Error("Error writing response", zap.Error(err))
 	}
 }
 
-// ServerSetup sets up the server and routes
-func ServerSetup(svc service.Service, port string, logger *zap.Logger) *Server {
-	s := NewServer(context.Background(), svc, logger)
+// SetupRouter registers the routes and middlewares
+func (s *Server) SetupRouter() *mux.Router {
 	s.Router = mux.NewRouter()
+	s.Router.Use(otelmux.Middleware("jobs"), s.RequestIDMiddleware, s.AccessLogMiddleware, s.RateLimitMiddleware)
 
 	protectedRoutes := s.Router.PathPrefix("/V1").Subrouter()
-	protectedRoutes.HandleFunc("/subscribe", s.SubscribeHandler).Methods("POST")
-	protectedRoutes.HandleFunc("/jobs", s.JobsHandler).Methods("GET")
+	// The spec and its docs are public
+	protectedRoutes.HandleFunc("/openapi.yml", s.OpenAPISpecHandler).Methods("GET")
+	protectedRoutes.PathPrefix("/docs/").Handler(SwaggerUIHandler()).Methods("GET")
+	protectedRoutes.HandleFunc("/subscribe", s.RequireScope(t.ScopeSubscribe, s.SubscribeHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/subscribers:batch", s.RequireScope(t.ScopeSubscribe, s.BatchSubscribeHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/jobs", s.RequireScope(t.ScopeJobsRead, s.JobsHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/jobs:acknowledge", s.RequireScope(t.ScopeTrack, s.AcknowledgeJobsV1Handler)).Methods("POST")
+	protectedRoutes.HandleFunc("/jobs", s.RequireScope(t.ScopeJobsWrite, s.CreateJobHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/jobs/{id}", s.RequireScope(t.ScopeJobsRead, s.GetJobHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/jobs/{id}", s.RequireScope(t.ScopeJobsWrite, s.ReplaceJobHandler)).Methods("PUT")
+	protectedRoutes.HandleFunc("/jobs/{id}", s.RequireScope(t.ScopeJobsWrite, s.PatchJobHandler)).Methods("PATCH")
+	protectedRoutes.HandleFunc("/jobs/{id}", s.RequireScope(t.ScopeJobsWrite, s.DeleteJobHandler)).Methods("DELETE")
+	protectedRoutes.HandleFunc("/jobs/{id}/bookmark", s.RequireScope(t.ScopeTrack, s.BookmarkJobHandler)).Methods("PUT")
+	protectedRoutes.HandleFunc("/jobs/{id}/bookmark", s.RequireScope(t.ScopeTrack, s.DeleteBookmarkHandler)).Methods("DELETE")
+	protectedRoutes.HandleFunc("/jobs/{id}/dismissal", s.RequireScope(t.ScopeTrack, s.DismissJobHandler)).Methods("PUT")
+	protectedRoutes.HandleFunc("/jobs/{id}/dismissal", s.RequireScope(t.ScopeTrack, s.DeleteDismissalHandler)).Methods("DELETE")
+	protectedRoutes.HandleFunc("/bookmarks", s.RequireScope(t.ScopeJobsRead, s.ListBookmarksHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/dismissals", s.RequireScope(t.ScopeJobsRead, s.ListDismissalsHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/companies", s.RequireScope(t.ScopeJobsRead, s.ListCompaniesHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/companies", s.RequireScope(t.ScopeJobsWrite, s.CreateCompanyHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/companies/{id}", s.RequireScope(t.ScopeJobsRead, s.GetCompanyHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/companies/{id}", s.RequireScope(t.ScopeJobsWrite, s.ReplaceCompanyHandler)).Methods("PUT")
+	protectedRoutes.HandleFunc("/companies/{id}", s.RequireScope(t.ScopeJobsWrite, s.DeleteCompanyHandler)).Methods("DELETE")
+	protectedRoutes.HandleFunc("/saved-searches", s.RequireScope(t.ScopeTrack, s.CreateSavedSearchHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/saved-searches", s.RequireScope(t.ScopeJobsRead, s.ListSavedSearchesHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/saved-searches/{id}", s.RequireScope(t.ScopeJobsRead, s.GetSavedSearchHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/saved-searches/{id}", s.RequireScope(t.ScopeTrack, s.ReplaceSavedSearchHandler)).Methods("PUT")
+	protectedRoutes.HandleFunc("/saved-searches/{id}", s.RequireScope(t.ScopeTrack, s.DeleteSavedSearchHandler)).Methods("DELETE")
+	protectedRoutes.HandleFunc("/saved-searches/{id}/jobs", s.RequireScope(t.ScopeJobsRead, s.SavedSearchJobsHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/saved-searches/{id}/jobs:acknowledge", s.RequireScope(t.ScopeTrack, s.AcknowledgeSavedSearchJobsHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/applications", s.RequireScope(t.ScopeJobsRead, s.ListApplicationsHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/applications", s.RequireScope(t.ScopeTrack, s.CreateApplicationHandler)).Methods("POST")
+	protectedRoutes.HandleFunc("/applications:summary", s.RequireScope(t.ScopeJobsRead, s.ApplicationSummaryHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/applications/{id}", s.RequireScope(t.ScopeJobsRead, s.GetApplicationHandler)).Methods("GET")
+	protectedRoutes.HandleFunc("/applications/{id}", s.RequireScope(t.ScopeTrack, s.PatchApplicationHandler)).Methods("PATCH")
+	protectedRoutes.HandleFunc("/applications/{id}", s.RequireScope(t.ScopeTrack, s.DeleteApplicationHandler)).Methods("DELETE")
+	protectedRoutes.HandleFunc("/applications/{id}/events", s.RequireScope(t.ScopeJobsRead, s.ApplicationEventsHandler)).Methods("GET")
 
-	s.Logger.Sugar().Infof("Listening port %s", port)
-	s.Logger.Sugar().Fatal(http.ListenAndServe(port, s.Router))
+	// Sign-in endpoints are public, the magic link proves the email ownership
+	if s.Sessions != nil {
+		protectedRoutes.Handle("/auth/magic-link", s.OpenAPIValidationMiddleware(http.HandlerFunc(s.MagicLinkHandler))).Methods("POST")
+		protectedRoutes.Handle("/auth/token", s.OpenAPIValidationMiddleware(http.HandlerFunc(s.SessionTokenHandler))).Methods("POST")
+	}
 
-	return s
+	v2Routes := s.Router.PathPrefix("/V2").Subrouter()
+	v2Routes.HandleFunc("/subscribers", s.RequireScope(t.ScopeSubscribe, s.SaveSubscriberHandler)).Methods("POST")
+	v2Routes.HandleFunc("/subscribers/{id}", s.RequireScope(t.ScopeJobsRead, s.GetSubscriberHandler)).Methods("GET")
+	v2Routes.HandleFunc("/subscribers/{id}/jobs", s.RequireScope(t.ScopeJobsRead, s.ListJobsHandler)).Methods("GET")
+	v2Routes.HandleFunc("/subscribers/{id}/jobs:acknowledge", s.RequireScope(t.ScopeTrack, s.AcknowledgeJobsHandler)).Methods("POST")
+
+	adminRoutes := protectedRoutes.PathPrefix("/admin").Subrouter()
+	adminRoutes.Handle("/log-level", s.RequireScope(t.ScopeAdmin, s.LogLevelHandler)).Methods("GET", "PUT")
+	adminRoutes.HandleFunc("/keys", s.RequireScope(t.ScopeAdmin, s.IssueAPIKeyHandler)).Methods("POST")
+	adminRoutes.HandleFunc("/keys", s.RequireScope(t.ScopeAdmin, s.ListAPIKeysHandler)).Methods("GET")
+	adminRoutes.HandleFunc("/keys/{id}", s.RequireScope(t.ScopeAdmin, s.RevokeAPIKeyHandler)).Methods("DELETE")
+	adminRoutes.HandleFunc("/keys/{id}/rotate", s.RequireScope(t.ScopeAdmin, s.RotateAPIKeyHandler)).Methods("POST")
+
+	return s.Router
 }
 
-// SendErrorResponse sends an error response in JSON format
-func sendErrorResponse(w http.ResponseWriter, code int, message string) {
+// LogLevelHandler reads or changes the log level at runtime
+func (s *Server) LogLevelHandler(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(code)
-	errorResponse := t.ErrorResponse{
-		Code:    code,
-		Message: message,
+	s.LogLevel.ServeHTTP(w, r)
+}
+
+// ServerSetup sets up the routes and starts the server
+func ServerSetup(s *Server, port string) *Server {
+	s.SetupRouter()
+
+	srv := &http.Server{
+		Addr:        port,
+		Handler:     s.Router,
+		BaseContext: func(net.Listener) context.Context { return s.ctx },
 	}
-	response, err := json.Marshal(errorResponse)
+	s.Logger.Sugar().Infof("Listening port %s", port)
+	s.Logger.Sugar().Fatal(srv.ListenAndServe())
+
+	return s
+}
+
+// sendJSONResponse writes body as JSON with the given status code
+func (s *Server) sendJSONResponse(w http.ResponseWriter, r *http.Request, code int, body interface{}) {
+	respBytes, err := json.Marshal(body)
 	if err != nil {
-		http.Error(w, "Internal Server Error", http.StatusInternalServerError)
+		s.sendError(w, r, err)
 		return
 	}
-	if _, err = w.Write(response); err != nil {
-		fmt.Print(errorResponse)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if _, err = w.Write(respBytes); err != nil {
+		logging.FromContext(r.Context(), s.Logger).Error(errorResponse, zap.Error(err))
 	}
 }
+
+// recordError marks the request span as failed
+func recordError(r *http.Request, err error) {
+	span := trace.SpanFromContext(r.Context())
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -10,6 +10,7 @@ import (
 	"testing"
 	"time"
 
+	"jobs/service"
 	"jobs/setup"
 	types "jobs/types"
 
@@ -23,15 +24,189 @@ type MockJobsService struct {
 	mock.Mock
 }
 
-func (m *MockJobsService) Subscribe(ctx context.Context, input types.SubscribeInput) (types.SubscribeOutput, error) {
+func (m *MockJobsService) SaveSubscriber(ctx context.Context, input types.SubscriberInput) (types.Subscriber, bool, error) {
 	args := m.Called(ctx, input)
-	return args.Get(0).(types.SubscribeOutput), args.Error(1)
+	return args.Get(0).(types.Subscriber), args.Bool(1), args.Error(2)
 }
 
-func (m *MockJobsService) GetJobs(ctx context.Context, input types.JobsInput) (types.JobsOutput, error) {
+func (m *MockJobsService) GetSubscriber(ctx context.Context, id uuid.UUID) (types.Subscriber, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Subscriber), args.Error(1)
+}
+
+func (m *MockJobsService) ImportSubscribers(ctx context.Context, rows []types.SubscriberImportRow, atomic bool) (types.SubscriberImportOutput, error) {
+	args := m.Called(ctx, rows, atomic)
+	return args.Get(0).(types.SubscriberImportOutput), args.Error(1)
+}
+
+func (m *MockJobsService) CreateJob(ctx context.Context, input types.JobInput) (types.Job, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockJobsService) GetJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockJobsService) ReplaceJob(ctx context.Context, id uuid.UUID, input types.JobInput, version *time.Time) (types.Job, error) {
+	args := m.Called(ctx, id, input, version)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockJobsService) PatchJob(ctx context.Context, id uuid.UUID, patch types.JobPatch, version *time.Time) (types.Job, error) {
+	args := m.Called(ctx, id, patch, version)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockJobsService) DeleteJob(ctx context.Context, id uuid.UUID, version *time.Time) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *MockJobsService) CreateCompany(ctx context.Context, input types.CompanyInput) (types.Company, error) {
 	args := m.Called(ctx, input)
-	return args.Get(0).(types.JobsOutput), args.Error(1)
+	return args.Get(0).(types.Company), args.Error(1)
+}
+
+func (m *MockJobsService) GetCompany(ctx context.Context, id uuid.UUID) (types.Company, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Company), args.Error(1)
+}
+
+func (m *MockJobsService) ListCompanies(ctx context.Context) ([]types.Company, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]types.Company), args.Error(1)
+}
+
+func (m *MockJobsService) ReplaceCompany(ctx context.Context, id uuid.UUID, input types.CompanyInput) (types.Company, error) {
+	args := m.Called(ctx, id, input)
+	return args.Get(0).(types.Company), args.Error(1)
+}
+
+func (m *MockJobsService) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockJobsService) CreateSavedSearch(ctx context.Context, subscriberID uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	args := m.Called(ctx, subscriberID, input)
+	return args.Get(0).(types.SavedSearch), args.Error(1)
+}
+
+func (m *MockJobsService) GetSavedSearch(ctx context.Context, id uuid.UUID) (types.SavedSearch, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.SavedSearch), args.Error(1)
+}
+
+func (m *MockJobsService) ListSavedSearches(ctx context.Context, subscriberID uuid.UUID) ([]types.SavedSearch, error) {
+	args := m.Called(ctx, subscriberID)
+	return args.Get(0).([]types.SavedSearch), args.Error(1)
+}
+
+func (m *MockJobsService) ReplaceSavedSearch(ctx context.Context, id uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	args := m.Called(ctx, id, input)
+	return args.Get(0).(types.SavedSearch), args.Error(1)
+}
+
+func (m *MockJobsService) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockJobsService) ListSavedSearchJobs(ctx context.Context, search types.SavedSearch, cursor string, limit int, sinceLastSeen bool) (types.JobList, error) {
+	args := m.Called(ctx, search, cursor, limit, sinceLastSeen)
+	return args.Get(0).(types.JobList), args.Error(1)
+}
+
+func (m *MockJobsService) AcknowledgeJobs(ctx context.Context, subscriberID uuid.UUID, input types.AcknowledgeInput) (types.SeenMark, error) {
+	args := m.Called(ctx, subscriberID, input)
+	return args.Get(0).(types.SeenMark), args.Error(1)
+}
+
+func (m *MockJobsService) AcknowledgeSavedSearchJobs(ctx context.Context, id uuid.UUID, input types.AcknowledgeInput) (types.SeenMark, error) {
+	args := m.Called(ctx, id, input)
+	return args.Get(0).(types.SeenMark), args.Error(1)
+}
+
+func (m *MockJobsService) CreateApplication(ctx context.Context, subscriberID uuid.UUID, input types.ApplicationInput) (types.Application, error) {
+	args := m.Called(ctx, subscriberID, input)
+	return args.Get(0).(types.Application), args.Error(1)
+}
+
+func (m *MockJobsService) GetApplication(ctx context.Context, id uuid.UUID) (types.Application, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Application), args.Error(1)
+}
+
+func (m *MockJobsService) ListApplications(ctx context.Context, subscriberID uuid.UUID, status string) ([]types.Application, error) {
+	args := m.Called(ctx, subscriberID, status)
+	return args.Get(0).([]types.Application), args.Error(1)
+}
+
+func (m *MockJobsService) PatchApplication(ctx context.Context, app types.Application, patch types.ApplicationPatch) (types.Application, error) {
+	args := m.Called(ctx, app, patch)
+	return args.Get(0).(types.Application), args.Error(1)
+}
+
+func (m *MockJobsService) DeleteApplication(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockJobsService) ListApplicationEvents(ctx context.Context, id uuid.UUID) ([]types.ApplicationEvent, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]types.ApplicationEvent), args.Error(1)
+}
+
+func (m *MockJobsService) SummarizeApplications(ctx context.Context, subscriberID uuid.UUID) (types.ApplicationSummary, error) {
+	args := m.Called(ctx, subscriberID)
+	return args.Get(0).(types.ApplicationSummary), args.Error(1)
+}
+
+func (m *MockJobsService) SaveJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) (types.JobAction, error) {
+	args := m.Called(ctx, action, subscriberID, jobID)
+	return args.Get(0).(types.JobAction), args.Error(1)
+}
+
+func (m *MockJobsService) DeleteJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) error {
+	args := m.Called(ctx, action, subscriberID, jobID)
+	return args.Error(0)
+}
+
+func (m *MockJobsService) ListJobActions(ctx context.Context, action string, subscriberID uuid.UUID) ([]types.JobAction, error) {
+	args := m.Called(ctx, action, subscriberID)
+	return args.Get(0).([]types.JobAction), args.Error(1)
+}
+
+func (m *MockJobsService) ListJobs(ctx context.Context, query types.JobQuery) (types.JobList, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).(types.JobList), args.Error(1)
+}
+
+// internalJob returns an internal job with the given ID
+func internalJob(id string) types.Job {
+	jobID := uuid.MustParse(id)
+	return types.Job{ID: &jobID, Source: types.SourceInternal, Title: "Backend Developer", Country: "USA"}
 }
+
+// withoutRequestID drops the generated request_id from problem responses so that bodies can be compared
+func withoutRequestID(body string) string {
+	var problem map[string]interface{}
+	if err := json.Unmarshal([]byte(body), &problem); err != nil {
+		return body
+	}
+	delete(problem, "request_id")
+	b, _ := json.Marshal(problem)
+	return string(b)
+}
+
+// withSession authenticates the request as the given subscriber, like RequireScope does
+func withSession(req *http.Request, subscriberID uuid.UUID) *http.Request {
+	claims := types.SessionClaims{SubscriberID: subscriberID, Scopes: []string{types.ScopeJobsRead}}
+	return req.WithContext(context.WithValue(req.Context(), sessionCtxKey{}, claims))
+}
+
 func TestSubscribeHandler(t *testing.T) {
 	logger, _ := setup.SetupLogger()
 
@@ -60,22 +235,27 @@ func TestSubscribeHandler(t *testing.T) {
 				"salary_min": 10000,
 			},
 			expectedStatus: http.StatusCreated,
-			expectedBody:   `{"id":"00000000-0000-0000-0000-000000000000", "name":"Romina Bareiro", "timestamp":"2023-11-23T16:42:23Z"}`,
+			expectedBody:   `{"id":"00000000-0000-0000-0000-000000000000", "name":"Romina Bareiro", "timestamp":"2023-11-23T16:42:23Z", "message":"User successfully subscribed"}`,
 			setupMock: func() {
 				fixedTime, _ := time.Parse(time.RFC3339, "2023-11-23T16:42:23Z")
-				validInput := types.SubscribeInput{
-					Name:               "Romina Bareiro",
-					Email:              "bareiro.romina@gmail.com",
-					JobTitles:          []string{"SSr Java Developer", "Sr Java Developer"},
-					PreferredCountries: []string{"USA", "Canada"},
-					SalaryMin:          10000,
+				validInput := types.SubscriberInput{
+					Name:      "Romina Bareiro",
+					Email:     "bareiro.romina@gmail.com",
+					JobTitles: []string{"SSr Java Developer", "Sr Java Developer"},
+					Countries: []string{"USA", "Canada"},
+					SalaryMin: 10000,
 				}
-				validOutput := types.SubscribeOutput{
-					UserID:    uuid.Nil,
+				validOutput := types.Subscriber{
+					ID:        uuid.Nil,
 					Name:      "Romina Bareiro",
-					TimeStamp: fixedTime,
+					Email:     "bareiro.romina@gmail.com",
+					JobTitles: validInput.JobTitles,
+					Countries: validInput.Countries,
+					SalaryMin: 10000,
+					CreatedAt: fixedTime,
+					UpdatedAt: fixedTime,
 				}
-				svc.On("Subscribe", mock.Anything, validInput).Return(validOutput, nil)
+				svc.On("SaveSubscriber", mock.Anything, validInput).Return(validOutput, true, nil)
 			},
 		},
 		{
@@ -83,7 +263,7 @@ func TestSubscribeHandler(t *testing.T) {
 			method:         http.MethodGet,
 			body:           nil,
 			expectedStatus: http.StatusMethodNotAllowed,
-			expectedBody:   `{"code":405, "message":"Method Not Allowed"}`,
+			expectedBody:   `{"type":"urn:jobs:problem:method_not_allowed","title":"Method Not Allowed","status":405,"detail":"Method GET is not allowed","instance":"/subscribe","code":"method_not_allowed"}`,
 			setupMock:      func() {}, // No mock setup needed for this case
 		},
 		{
@@ -91,7 +271,7 @@ func TestSubscribeHandler(t *testing.T) {
 			method:         http.MethodPost,
 			body:           make(chan int), // Invalid JSON
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"code":422, "message":"Invalid JSON format"}`,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_json","title":"Unprocessable Entity","status":422,"detail":"The request body is not valid JSON","instance":"/subscribe","code":"invalid_json"}`,
 			setupMock:      func() {}, // No mock setup needed for this case
 		},
 		{
@@ -105,11 +285,25 @@ func TestSubscribeHandler(t *testing.T) {
 				"salary_min": 10000,
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"code":422, "message":"Validation error: validation failed: Key: 'SubscribeInput.Email' Error:Field validation for 'Email' failed on the 'email' tag"}`,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/subscribe","code":"validation_failed","errors":[{"field":"email","code":"email","message":"must be a valid email address"}]}`,
 			setupMock: func() {
-				svc.On("Subscribe", mock.Anything, mock.AnythingOfType("types.SubscribeInput")).Return(types.SubscribeOutput{}, errors.New("Validation error: Subscription failed"))
+				svc.On("SaveSubscriber", mock.Anything, mock.AnythingOfType("types.SubscriberInput")).Return(types.Subscriber{}, false, errors.New("Validation error: Subscription failed"))
 			},
 		},
+		{
+			name:   "Wildcard mixed with countries",
+			method: http.MethodPost,
+			body: map[string]interface{}{
+				"name":       "Romina Bareiro",
+				"email":      "bareiro.romina@gmail.com",
+				"job_titles": []string{"ALL"},
+				"country":    []string{"ALL", "USA"},
+				"salary_min": 10000,
+			},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/subscribe","code":"validation_failed","errors":[{"field":"country","code":"wildcard","message":"cannot combine ALL with other values"}]}`,
+			setupMock:      func() {},
+		},
 		{
 			name:   "Invalid preferred country",
 			method: http.MethodPost,
@@ -120,9 +314,9 @@ func TestSubscribeHandler(t *testing.T) {
 				"salary_min": 10000,
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"code":422, "message":"Validation error: validation failed: Key: 'SubscribeInput.PreferredCountries' Error:Field validation for 'PreferredCountries' failed on the 'required' tag"}`,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/subscribe","code":"validation_failed","errors":[{"field":"country","code":"required","message":"is required"}]}`,
 			setupMock: func() {
-				svc.On("Subscribe", mock.Anything, mock.AnythingOfType("types.SubscribeInput")).Return(types.SubscribeOutput{}, errors.New("Validation error: Subscription failed"))
+				svc.On("SaveSubscriber", mock.Anything, mock.AnythingOfType("types.SubscriberInput")).Return(types.Subscriber{}, false, errors.New("Validation error: Subscription failed"))
 			},
 		},
 	}
@@ -148,7 +342,7 @@ func TestSubscribeHandler(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
 
 			responseBody := w.Body.String()
-			assert.JSONEq(t, tt.expectedBody, responseBody)
+			assert.JSONEq(t, tt.expectedBody, withoutRequestID(responseBody))
 
 			// Verify mock was called with expected parameters
 			svc.AssertExpectations(t)
@@ -181,18 +375,18 @@ func TestJobsHandler(t *testing.T) {
 			expectedStatus: http.StatusOK,
 			expectedBody:   `{"internal_jobs":["00000000-0000-0000-0000-000000000001","00000000-0000-0000-0000-000000000002"],"external_jobs":[]}`,
 			setupMock: func() {
-				validInput := types.JobsInput{
-					UserID:     uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
-					PostedDate: time.Date(2023, time.November, 23, 16, 42, 23, 0, time.UTC),
+				validInput := types.JobQuery{
+					SubscriberID: uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+					PostedAfter:  time.Date(2023, time.November, 23, 16, 42, 23, 0, time.UTC),
 				}
-				validOutput := types.JobsOutput{
-					InternalJobs: []uuid.UUID{
-						uuid.MustParse("00000000-0000-0000-0000-000000000001"),
-						uuid.MustParse("00000000-0000-0000-0000-000000000002"),
+				validOutput := types.JobList{List: types.List[types.Job]{
+					Items: []types.Job{
+						internalJob("00000000-0000-0000-0000-000000000001"),
+						internalJob("00000000-0000-0000-0000-000000000002"),
 					},
-					ExternalJobs: []types.Job{},
-				}
-				svc.On("GetJobs", mock.Anything, validInput).Return(validOutput, nil)
+					Total: 2,
+				}}
+				svc.On("ListJobs", mock.Anything, validInput).Return(validOutput, nil)
 			},
 		},
 		{
@@ -200,7 +394,7 @@ func TestJobsHandler(t *testing.T) {
 			method:         http.MethodPost,
 			queryParams:    nil,
 			expectedStatus: http.StatusMethodNotAllowed,
-			expectedBody:   `{"code":405, "message":"Method Not Allowed"}`,
+			expectedBody:   `{"type":"urn:jobs:problem:method_not_allowed","title":"Method Not Allowed","status":405,"detail":"Method POST is not allowed","instance":"/V1/jobs","code":"method_not_allowed"}`,
 			setupMock:      func() {}, // No mock setup needed for this case
 		},
 		{
@@ -211,11 +405,81 @@ func TestJobsHandler(t *testing.T) {
 				"posted_date": "invalid-date-format",
 			},
 			expectedStatus: http.StatusUnprocessableEntity,
-			expectedBody:   `{"code":422, "message":"Invalid User ID format"}`,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_parameter","title":"Unprocessable Entity","status":422,"detail":"Invalid id parameter","instance":"/V1/jobs","code":"invalid_parameter","errors":[{"field":"id","code":"format","message":"must be a UUID"}]}`,
 			setupMock: func() {
 				// No mocking required, as the handler should handle validation errors
 			},
 		},
+		{
+			name:           "External jobs are split out and warnings become the message",
+			method:         http.MethodGet,
+			queryParams:    map[string]string{"posted_date": "2023-11-26T00:00:00Z"},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"internal_jobs":["00000000-0000-0000-0000-000000000001"],"external_jobs":[{"title":"Backend Developer","salary":5000,"skills":{"skills":["Go","SQL"]}}],"message":"Warning: failed to fetch external jobs"}`,
+			setupMock: func() {
+				input := types.JobQuery{
+					SubscriberID: uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+					PostedAfter:  time.Date(2023, time.November, 26, 0, 0, 0, 0, time.UTC),
+				}
+				output := types.JobList{
+					List: types.List[types.Job]{Items: []types.Job{
+						internalJob("00000000-0000-0000-0000-000000000001"),
+						{Source: types.SourceExternal, Title: "Backend Developer", Country: "USA", SalaryMin: 5000, Skills: []string{"Go", "SQL"}},
+					}, Total: 2},
+					Warnings: []string{service.WarningExternalJobsUnavailable},
+				}
+				svc.On("ListJobs", mock.Anything, input).Return(output, nil)
+			},
+		},
+		{
+			name:           "Search snippets are listed by internal job ID",
+			method:         http.MethodGet,
+			queryParams:    map[string]string{"posted_date": "2023-11-27T00:00:00Z", "q": " golang "},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"internal_jobs":["00000000-0000-0000-0000-000000000001"],"external_jobs":[{"title":"Backend Developer","salary":5000,"skills":{"skills":["Go"]},"company":"Acme"}],"snippets":{"00000000-0000-0000-0000-000000000001":"Services in <mark>Golang</mark>"}}`,
+			setupMock: func() {
+				input := types.JobQuery{
+					SubscriberID: uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+					PostedAfter:  time.Date(2023, time.November, 27, 0, 0, 0, 0, time.UTC),
+					Text:         "golang",
+				}
+				job := internalJob("00000000-0000-0000-0000-000000000001")
+				job.Snippet = "Services in <mark>Golang</mark>"
+				output := types.JobList{List: types.List[types.Job]{Items: []types.Job{
+					job,
+					{Source: types.SourceExternal, Title: "Backend Developer", SalaryMin: 5000, Skills: []string{"Go"}, Company: "Acme", Snippet: "Backend Developer"},
+				}, Total: 2}}
+				svc.On("ListJobs", mock.Anything, input).Return(output, nil)
+			},
+		},
+		{
+			name:           "External provider unavailable",
+			method:         http.MethodGet,
+			queryParams:    map[string]string{"posted_date": "2023-11-24T00:00:00Z"},
+			expectedStatus: http.StatusServiceUnavailable,
+			expectedBody:   `{"type":"urn:jobs:problem:external_jobs_unavailable","title":"Service Unavailable","status":503,"detail":"The external job provider is unavailable","instance":"/V1/jobs","code":"external_jobs_unavailable"}`,
+			setupMock: func() {
+				input := types.JobQuery{
+					SubscriberID: uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+					PostedAfter:  time.Date(2023, time.November, 24, 0, 0, 0, 0, time.UTC),
+				}
+				svc.On("ListJobs", mock.Anything, input).Return(types.JobList{}, service.ErrExternalJobsUnavailable.Wrap(errors.New("dial tcp: connection refused")))
+			},
+		},
+		{
+			name:           "Internal errors are not leaked",
+			method:         http.MethodGet,
+			queryParams:    map[string]string{"posted_date": "2023-11-25T00:00:00Z"},
+			expectedStatus: http.StatusInternalServerError,
+			expectedBody:   `{"type":"urn:jobs:problem:internal_error","title":"Internal Server Error","status":500,"detail":"An internal error occurred","instance":"/V1/jobs","code":"internal_error"}`,
+			setupMock: func() {
+				input := types.JobQuery{
+					SubscriberID: uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+					PostedAfter:  time.Date(2023, time.November, 25, 0, 0, 0, 0, time.UTC),
+				}
+				svc.On("ListJobs", mock.Anything, input).Return(types.JobList{}, errors.New("could not get internal jobs: pq: relation \"jobs\" does not exist"))
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -229,6 +493,7 @@ func TestJobsHandler(t *testing.T) {
 				q.Add(key, value)
 			}
 			req.URL.RawQuery = q.Encode()
+			req = withSession(req, uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"))
 
 			w := httptest.NewRecorder()
 
@@ -238,7 +503,10 @@ func TestJobsHandler(t *testing.T) {
 			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
 
 			responseBody := w.Body.String()
-			assert.JSONEq(t, tt.expectedBody, responseBody)
+			assert.JSONEq(t, tt.expectedBody, withoutRequestID(responseBody))
+			if tt.expectedStatus >= http.StatusBadRequest {
+				assert.Equal(t, ProblemContentType, resp.Header.Get("Content-Type"))
+			}
 
 			// Verify mock was called with expected parameters
 			svc.AssertExpectations(t)
@@ -246,36 +514,27 @@ func TestJobsHandler(t *testing.T) {
 	}
 }
 
-type MockExternalJobAPI struct{}
-
-func (m *MockExternalJobAPI) FetchJobs(ctx context.Context, input types.JobsInput) (*types.JobsOutput, error) {
-	return &types.JobsOutput{
-		InternalJobs: []uuid.UUID{uuid.New()},
-		ExternalJobs: []types.Job{
-			{Title: "Software Engineer", Salary: 120000},
-		},
-	}, nil
-}
 func TestIntegration_UserSubscriptionAndJobSearch(t *testing.T) {
 	// Setup the mock service
 	logger, _ := setup.SetupLogger()
-	jobOutput := types.JobsOutput{
-		InternalJobs: []uuid.UUID{uuid.New()},
-		ExternalJobs: []types.Job{
-			{Title: "Software Engineer", Salary: 100000},
+	jobOutput := types.JobList{List: types.List[types.Job]{
+		Items: []types.Job{
+			internalJob(uuid.NewString()),
+			{Source: types.SourceExternal, Title: "Software Engineer", SalaryMin: 100000},
 		},
+		Total: 2,
+	}}
+	subscribeOutput := types.Subscriber{
+		ID:   uuid.New(),
+		Name: "John Doe",
 	}
-	subscribeOutput := types.SubscribeOutput{
-		UserID: uuid.New(),
-		Name:   "John Doe",
-	}
-	validInput := types.JobsInput{
-		UserID:     uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
-		PostedDate: time.Date(2023, time.November, 23, 16, 42, 23, 0, time.UTC),
+	validInput := types.JobQuery{
+		SubscriberID: uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+		PostedAfter:  time.Date(2023, time.November, 23, 16, 42, 23, 0, time.UTC),
 	}
 	mockService := new(MockJobsService)
-	mockService.On("Subscribe", mock.AnythingOfType("types.SubscribeInput")).Return(subscribeOutput, nil)
-	mockService.On("GetJobs", mock.Anything, validInput).Return(jobOutput, nil)
+	mockService.On("SaveSubscriber", mock.AnythingOfType("types.SubscriberInput")).Return(subscribeOutput, true, nil)
+	mockService.On("ListJobs", mock.Anything, validInput).Return(jobOutput, nil)
 
 	// Create the server without actually starting the HTTP server
 	server := NewServer(context.Background(), mockService, logger) // Mock the service
@@ -289,6 +548,7 @@ func TestIntegration_UserSubscriptionAndJobSearch(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
+	req = withSession(req, validInput.SubscriberID)
 	rr := httptest.NewRecorder()
 
 	// Call the handler directly
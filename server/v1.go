@@ -0,0 +1,86 @@
+package server
+
+import (
+	"jobs/service"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+)
+
+// The V1 handlers keep their original payloads, these adapters translate them to and from the V2 resources
+
+// subscribeMessageV1 is the confirmation V1 has always returned
+const subscribeMessageV1 = "User successfully subscribed"
+
+// warningMessageV1 replaces the V2 warnings in the V1 jobs response
+const warningMessageV1 = "Warning: failed to fetch external jobs"
+
+func subscriberFromV1(in t.SubscribeInput) t.SubscriberInput {
+	return t.SubscriberInput{
+		Name:              in.Name,
+		Email:             in.Email,
+		JobTitles:         in.JobTitles,
+		Countries:         in.PreferredCountries,
+		SalaryMin:         in.SalaryMin,
+		SalaryCurrency:    in.SalaryCurrency,
+		SalaryPeriod:      in.SalaryPeriod,
+		FollowedCompanies: in.FollowedCompanies,
+		BlockedCompanies:  in.BlockedCompanies,
+		Locations:         in.Locations,
+	}
+}
+
+func subscribeOutputV1(sub t.Subscriber) t.SubscribeOutput {
+	return t.SubscribeOutput{
+		UserID:    sub.ID,
+		Name:      sub.Name,
+		TimeStamp: sub.UpdatedAt,
+		Message:   subscribeMessageV1,
+	}
+}
+
+// jobsOutputV1 splits the jobs by source: V1 lists internal jobs by ID and external jobs as served by the provider
+func jobsOutputV1(list t.JobList) t.JobsOutput {
+	out := t.JobsOutput{SeenUntil: list.SeenUntil}
+	for _, job := range list.Items {
+		if job.Source == t.SourceInternal && job.ID != nil {
+			out.InternalJobs = append(out.InternalJobs, *job.ID)
+			if job.Snippet != "" {
+				if out.Snippets == nil {
+					out.Snippets = map[string]string{}
+				}
+				out.Snippets[job.ID.String()] = job.Snippet
+			}
+			continue
+		}
+		out.ExternalJobs = append(out.ExternalJobs, externalJobV1(job))
+	}
+	if out.InternalJobs == nil {
+		out.InternalJobs = []uuid.UUID{}
+	}
+	if out.ExternalJobs == nil {
+		out.ExternalJobs = []t.ExternalJob{}
+	}
+	for _, warning := range list.Warnings {
+		if warning == service.WarningExternalJobsUnavailable {
+			out.Message = warningMessageV1
+		}
+	}
+	return out
+}
+
+func externalJobV1(job t.Job) t.ExternalJob {
+	skills := make([]t.Skill, 0, len(job.Skills))
+	for _, name := range job.Skills {
+		skills = append(skills, t.Skill{Name: name})
+	}
+	return t.ExternalJob{
+		Title:    job.Title,
+		Salary:   int(job.SalaryMin),
+		Skills:   t.Skills{Skills: skills},
+		Company:  job.Company,
+		WorkMode: job.WorkMode,
+		Region:   job.Region,
+		City:     job.City,
+	}
+}
@@ -0,0 +1,201 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"jobs/apperr"
+	t "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+// Page sizes of the V2 lists
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+// SaveSubscriberHandler creates a subscriber, or replaces the preferences of the one with the same email
+func (s *Server) SaveSubscriberHandler(w http.ResponseWriter, r *http.Request) {
+	var reqBody t.SubscriberInput
+	if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+		sendErrorResponse(w, r, http.StatusUnprocessableEntity, "invalid_json", "The request body is not valid JSON")
+		return
+	}
+	if err := s.validateRequestBody(reqBody); err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	sub, created, err := s.Svc.SaveSubscriber(r.Context(), reqBody)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	if !created {
+		s.sendJSONResponse(w, r, http.StatusOK, sub)
+		return
+	}
+	w.Header().Set("Location", "/V2/subscribers/"+sub.ID.String())
+	s.sendJSONResponse(w, r, http.StatusCreated, sub)
+}
+
+// GetSubscriberHandler returns a subscriber
+func (s *Server) GetSubscriberHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.subscriberFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	sub, err := s.Svc.GetSubscriber(r.Context(), id)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, sub)
+}
+
+// ListJobsHandler lists the jobs matching a subscriber, query filters override the subscriber preferences
+func (s *Server) ListJobsHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := s.subscriberFromPath(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+
+	query, err := jobQueryFromRequest(r)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	query.SubscriberID = id
+
+	list, err := s.Svc.ListJobs(r.Context(), query)
+	if err != nil {
+		s.sendError(w, r, err)
+		return
+	}
+	s.sendJSONResponse(w, r, http.StatusOK, list)
+}
+
+// subscriberFromPath resolves the {id} path variable, "me" is the subscriber of the session
+func (s *Server) subscriberFromPath(r *http.Request) (uuid.UUID, error) {
+	raw := mux.Vars(r)["id"]
+	var requested uuid.UUID
+	if raw != "me" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return uuid.Nil, apperr.InvalidParameter("id", "must be a UUID or me")
+		}
+		requested = id
+	}
+
+	id, err := s.resolveSubscriber(r, requested)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if id == uuid.Nil {
+		return uuid.Nil, apperr.InvalidParameter("id", "me is only available to subscriber sessions")
+	}
+	return id, nil
+}
+
+// subscriberFromQuery resolves the subscriber_id query parameter, which API keys must pass and sessions may omit
+func (s *Server) subscriberFromQuery(r *http.Request) (uuid.UUID, error) {
+	var requested uuid.UUID
+	if v := r.URL.Query().Get("subscriber_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			return uuid.Nil, apperr.InvalidParameter("subscriber_id", "must be a UUID")
+		}
+		requested = id
+	}
+	id, err := s.resolveSubscriber(r, requested)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	if id == uuid.Nil {
+		return uuid.Nil, apperr.InvalidParameter("subscriber_id", "is required with an API key")
+	}
+	return id, nil
+}
+
+// jobQueryFromRequest parses the filters and the page of a jobs list
+func jobQueryFromRequest(r *http.Request) (t.JobQuery, error) {
+	params := r.URL.Query()
+	query := t.JobQuery{
+		JobTitles: params["job_titles"],
+		Countries: params["countries"],
+	}
+
+	if err := wildcardFilter("job_titles", query.JobTitles); err != nil {
+		return t.JobQuery{}, err
+	}
+	if err := wildcardFilter("countries", query.Countries); err != nil {
+		return t.JobQuery{}, err
+	}
+	if v := params.Get("salary_min"); v != "" {
+		salary, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || salary < 0 {
+			return t.JobQuery{}, apperr.InvalidParameter("salary_min", "must be a non-negative integer")
+		}
+		query.SalaryMin = salary
+	}
+	if v := params.Get("salary_max"); v != "" {
+		salary, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || salary < 0 {
+			return t.JobQuery{}, apperr.InvalidParameter("salary_max", "must be a non-negative integer")
+		}
+		if salary < query.SalaryMin {
+			return t.JobQuery{}, apperr.InvalidParameter("salary_max", "must be greater than or equal to salary_min")
+		}
+		query.SalaryMax = salary
+	}
+	if v := params.Get("posted_after"); v != "" {
+		postedAfter, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return t.JobQuery{}, apperr.InvalidParameter("posted_after", "must be an RFC 3339 date-time")
+		}
+		query.PostedAfter = postedAfter
+	}
+	statuses, err := statusFilter(r)
+	if err != nil {
+		return t.JobQuery{}, err
+	}
+	query.Statuses = statuses
+	if query.Locations, err = locationFilter(r); err != nil {
+		return t.JobQuery{}, err
+	}
+	if query.Text, err = searchText(r); err != nil {
+		return t.JobQuery{}, err
+	}
+	if query.SinceLastSeen, err = sinceLastSeen(r); err != nil {
+		return t.JobQuery{}, err
+	}
+	if query.BookmarkedFirst, err = bookmarkedFirst(r); err != nil {
+		return t.JobQuery{}, err
+	}
+	if query.Cursor, query.Limit, err = pageFromRequest(r); err != nil {
+		return t.JobQuery{}, err
+	}
+	return query, nil
+}
+
+// pageFromRequest parses the cursor and limit parameters of a list
+func pageFromRequest(r *http.Request) (string, int, error) {
+	params := r.URL.Query()
+	limit := defaultPageSize
+	if v := params.Get("limit"); v != "" {
+		l, err := strconv.Atoi(v)
+		if err != nil || l < 1 || l > maxPageSize {
+			return "", 0, apperr.InvalidParameter("limit", "must be between 1 and "+strconv.Itoa(maxPageSize))
+		}
+		limit = l
+	}
+	return params.Get("cursor"), limit, nil
+}
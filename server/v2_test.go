@@ -0,0 +1,226 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	types "jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestV2Handlers(t *testing.T) {
+	own := uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6")
+	jobID := uuid.MustParse("00000000-0000-0000-0000-000000000001")
+	created := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	input := types.SubscriberInput{
+		Name:      "Jane",
+		Email:     "jane@example.com",
+		JobTitles: []string{"Backend Developer"},
+		Countries: []string{"USA"},
+		SalaryMin: 1000,
+	}
+	subscriber := types.Subscriber{
+		ID:             own,
+		Name:           "Jane",
+		Email:          "jane@example.com",
+		JobTitles:      []string{"Backend Developer"},
+		Countries:      []string{"USA"},
+		SalaryMin:      1000,
+		SalaryCurrency: "USD",
+		SalaryPeriod:   "year",
+		CreatedAt:      created,
+		UpdatedAt:      created,
+	}
+	subscriberJSON := `{"id":"b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6","name":"Jane","email":"jane@example.com","job_titles":["Backend Developer"],"countries":["USA"],"salary_min":1000,"salary_currency":"USD","salary_period":"year","created_at":"2024-11-01T10:00:00Z","updated_at":"2024-11-01T10:00:00Z"}`
+
+	tests := []struct {
+		name             string
+		method           string
+		path             string
+		headers          map[string]string
+		body             string
+		setupMock        func(svc *MockJobsService)
+		expectedStatus   int
+		expectedBody     string
+		expectedLocation string
+	}{
+		{
+			name:    "Create a subscriber",
+			method:  http.MethodPost,
+			path:    "/V2/subscribers",
+			headers: map[string]string{APIKeyHeader: "jsk_subscribe"},
+			body:    `{"name":"Jane","email":"jane@example.com","job_titles":["Backend Developer"],"countries":["USA"],"salary_min":1000}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("SaveSubscriber", mock.Anything, input).Return(subscriber, true, nil)
+			},
+			expectedStatus:   http.StatusCreated,
+			expectedBody:     subscriberJSON,
+			expectedLocation: "/V2/subscribers/b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6",
+		},
+		{
+			name:    "Update a subscriber",
+			method:  http.MethodPost,
+			path:    "/V2/subscribers",
+			headers: map[string]string{APIKeyHeader: "jsk_subscribe"},
+			body:    `{"name":"Jane","email":"jane@example.com","job_titles":["Backend Developer"],"countries":["USA"],"salary_min":1000}`,
+			setupMock: func(svc *MockJobsService) {
+				svc.On("SaveSubscriber", mock.Anything, input).Return(subscriber, false, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   subscriberJSON,
+		},
+		{
+			name:           "Invalid email",
+			method:         http.MethodPost,
+			path:           "/V2/subscribers",
+			headers:        map[string]string{APIKeyHeader: "jsk_subscribe"},
+			body:           `{"name":"Jane","email":"jane","job_titles":["Backend Developer"],"countries":["USA"]}`,
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:validation_failed","title":"Unprocessable Entity","status":422,"detail":"The request body failed validation","instance":"/V2/subscribers","code":"validation_failed","errors":[{"field":"email","code":"email","message":"must be a valid email address"}]}`,
+		},
+		{
+			name:    "Session reads itself as me",
+			method:  http.MethodGet,
+			path:    "/V2/subscribers/me",
+			headers: map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock: func(svc *MockJobsService) {
+				svc.On("GetSubscriber", mock.Anything, own).Return(subscriber, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   subscriberJSON,
+		},
+		{
+			name:           "API keys cannot use me",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/me",
+			headers:        map[string]string{APIKeyHeader: "jsk_reader"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_parameter","title":"Unprocessable Entity","status":422,"detail":"Invalid id parameter","instance":"/V2/subscribers/me","code":"invalid_parameter","errors":[{"field":"id","code":"format","message":"me is only available to subscriber sessions"}]}`,
+		},
+		{
+			name:           "API key without impersonation scope cannot read a subscriber",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/" + own.String(),
+			headers:        map[string]string{APIKeyHeader: "jsk_reader"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"API key is missing the admin:impersonate scope","instance":"/V2/subscribers/b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6","code":"insufficient_scope"}`,
+		},
+		{
+			name:    "List a page of jobs",
+			method:  http.MethodGet,
+			path:    "/V2/subscribers/me/jobs?countries=USA&countries=UK&salary_min=2000&posted_after=2024-11-01T00:00:00Z&status=open&limit=1&cursor=MQ",
+			headers: map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock: func(svc *MockJobsService) {
+				query := types.JobQuery{
+					SubscriberID: own,
+					Countries:    []string{"USA", "UK"},
+					SalaryMin:    2000,
+					PostedAfter:  time.Date(2024, time.November, 1, 0, 0, 0, 0, time.UTC),
+					Statuses:     []string{types.JobOpen},
+					Limit:        1,
+					Cursor:       "MQ",
+				}
+				svc.On("ListJobs", mock.Anything, query).Return(types.JobList{
+					List: types.List[types.Job]{
+						Items:      []types.Job{{ID: &jobID, Source: types.SourceInternal, Title: "Backend Developer", Country: "USA", SalaryMin: 3000, Skills: []string{}, PostedAt: &created}},
+						NextCursor: "Mg",
+						Total:      3,
+					},
+				}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[{"id":"00000000-0000-0000-0000-000000000001","source":"internal","title":"Backend Developer","country":"USA","salary_min":3000,"skills":[],"posted_at":"2024-11-01T10:00:00Z"}],"next_cursor":"Mg","total":3}`,
+		},
+		{
+			name:    "Filter by work mode and city",
+			method:  http.MethodGet,
+			path:    "/V2/subscribers/me/jobs?work_mode=remote&work_mode=hybrid&city=Buenos+Aires",
+			headers: map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock: func(svc *MockJobsService) {
+				query := types.JobQuery{
+					SubscriberID: own,
+					Locations: []types.LocationPreference{
+						{WorkMode: types.WorkRemote, City: "Buenos Aires"},
+						{WorkMode: types.WorkHybrid, City: "Buenos Aires"},
+					},
+					Limit: defaultPageSize,
+				}
+				svc.On("ListJobs", mock.Anything, query).Return(types.JobList{List: types.List[types.Job]{Items: []types.Job{}}}, nil)
+			},
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"items":[],"total":0}`,
+		},
+		{
+			name:           "Wildcard mixed with countries",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/me/jobs?countries=ALL&countries=UK",
+			headers:        map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedBody:   `{"type":"urn:jobs:problem:invalid_parameter","title":"Unprocessable Entity","status":422,"detail":"Invalid countries parameter","instance":"/V2/subscribers/me/jobs","code":"invalid_parameter","errors":[{"field":"countries","code":"format","message":"cannot combine ALL with other values"}]}`,
+		},
+		{
+			name:           "Unknown work mode",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/me/jobs?work_mode=office",
+			headers:        map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+		{
+			name:           "Sessions only list open jobs",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/me/jobs?status=closed",
+			headers:        map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusForbidden,
+			expectedBody:   `{"type":"urn:jobs:problem:insufficient_scope","title":"Forbidden","status":403,"detail":"Listing closed jobs requires the admin scope","instance":"/V2/subscribers/me/jobs","code":"insufficient_scope"}`,
+		},
+		{
+			name:           "Page size is capped",
+			method:         http.MethodGet,
+			path:           "/V2/subscribers/me/jobs?limit=500",
+			headers:        map[string]string{"Authorization": "Bearer valid-token"},
+			setupMock:      func(svc *MockJobsService) {},
+			expectedStatus: http.StatusUnprocessableEntity,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := new(MockJobsService)
+			sm := new(MockSessionManager)
+			sm.On("ValidateSessionToken", mock.Anything, "valid-token").Return(types.SessionClaims{SubscriberID: own, Scopes: []string{types.ScopeJobsRead}}, nil).Maybe()
+			tt.setupMock(svc)
+
+			s, _ := newTestRouterServer(t, svc, map[string][]string{
+				"jsk_subscribe": {types.ScopeSubscribe},
+				"jsk_reader":    {types.ScopeJobsRead},
+			})
+			s.Sessions = sm
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			s.Router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedBody != "" {
+				assert.JSONEq(t, tt.expectedBody, withoutRequestID(w.Body.String()))
+			}
+			assert.Equal(t, tt.expectedLocation, w.Header().Get("Location"))
+			svc.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"jobs/apperr"
+	d "jobs/db"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+var (
+	// ErrApplicationNotFound is returned when no application has the given ID
+	ErrApplicationNotFound = d.ErrApplicationNotFound
+	// ErrInvalidTransition is returned when a status change goes back in the pipeline or leaves rejected
+	ErrInvalidTransition = apperr.Conflict("invalid_transition", "The application cannot move to this status")
+)
+
+// CreateApplication records a subscriber applying to an internal or ingested external job
+func (s *JobsService) CreateApplication(ctx context.Context, subscriberID uuid.UUID, input types.ApplicationInput) (types.Application, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.CreateApplication")
+	defer span.End()
+
+	job, err := s.referencedJob(ctx, input.JobID)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, err
+	}
+	app := types.Application{
+		SubscriberID: subscriberID,
+		JobID:        job.ID,
+		Source:       job.Source,
+		Title:        job.Title,
+		Company:      job.Company,
+		Country:      job.Country,
+		Status:       input.Status,
+		Notes:        input.Notes,
+		AppliedAt:    input.AppliedAt,
+	}
+	if app.Status == "" {
+		app.Status = types.ApplicationSaved
+	}
+	app.AppliedAt = appliedAt(app.Status, app.AppliedAt, time.Now())
+	span.SetAttributes(attribute.String("jobs.source", app.Source), attribute.String("application.status", app.Status))
+
+	app, err = s.DB.CreateApplication(ctx, app)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("could not create application: %w", err)
+	}
+	return app, nil
+}
+
+// GetApplication returns an application
+func (s *JobsService) GetApplication(ctx context.Context, id uuid.UUID) (types.Application, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.GetApplication")
+	defer span.End()
+
+	app, err := s.DB.GetApplication(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, err
+	}
+	return app, nil
+}
+
+// ListApplications returns the applications of a subscriber, in status when set
+func (s *JobsService) ListApplications(ctx context.Context, subscriberID uuid.UUID, status string) ([]types.Application, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ListApplications")
+	defer span.End()
+
+	apps, err := s.DB.ListApplications(ctx, subscriberID, status)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("could not list applications: %w", err)
+	}
+	return apps, nil
+}
+
+// PatchApplication changes the fields set in patch. The status only moves forward in the pipeline, or to rejected.
+func (s *JobsService) PatchApplication(ctx context.Context, app types.Application, patch types.ApplicationPatch) (types.Application, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.PatchApplication")
+	defer span.End()
+
+	updated := app
+	if patch.Status != nil && *patch.Status != app.Status {
+		if !canMove(app.Status, *patch.Status) {
+			err := ErrInvalidTransition.WithMessage("An application cannot move from %s to %s", app.Status, *patch.Status)
+			recordError(span, err)
+			return types.Application{}, err
+		}
+		updated.Status = *patch.Status
+	}
+	if patch.Notes != nil {
+		updated.Notes = *patch.Notes
+	}
+	if patch.AppliedAt != nil {
+		updated.AppliedAt = patch.AppliedAt
+	}
+	updated.AppliedAt = appliedAt(updated.Status, updated.AppliedAt, time.Now())
+	span.SetAttributes(attribute.String("application.status", updated.Status))
+
+	updated, err := s.DB.UpdateApplication(ctx, app.Status, updated, patch.Note)
+	if err != nil {
+		recordError(span, err)
+		return types.Application{}, fmt.Errorf("could not update application: %w", err)
+	}
+	return updated, nil
+}
+
+// DeleteApplication removes an application and its history
+func (s *JobsService) DeleteApplication(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "JobsService.DeleteApplication")
+	defer span.End()
+
+	if err := s.DB.DeleteApplication(ctx, id); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not delete application: %w", err)
+	}
+	return nil
+}
+
+// ListApplicationEvents returns the status history of an application, oldest first
+func (s *JobsService) ListApplicationEvents(ctx context.Context, id uuid.UUID) ([]types.ApplicationEvent, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ListApplicationEvents")
+	defer span.End()
+
+	events, err := s.DB.ListApplicationEvents(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("could not list application events: %w", err)
+	}
+	return events, nil
+}
+
+// SummarizeApplications counts the applications of a subscriber by status
+func (s *JobsService) SummarizeApplications(ctx context.Context, subscriberID uuid.UUID) (types.ApplicationSummary, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.SummarizeApplications")
+	defer span.End()
+
+	counts, err := s.DB.CountApplications(ctx, subscriberID)
+	if err != nil {
+		recordError(span, err)
+		return types.ApplicationSummary{}, fmt.Errorf("could not count applications: %w", err)
+	}
+	summary := types.ApplicationSummary{Counts: make(map[string]int, len(types.ApplicationStatuses))}
+	for _, status := range types.ApplicationStatuses {
+		summary.Counts[status] = counts[status]
+		summary.Total += counts[status]
+	}
+	return summary, nil
+}
+
+// referencedJob looks up the job an application references among the internal jobs, then the ingested external ones
+func (s *JobsService) referencedJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	job, err := s.DB.GetJob(ctx, id)
+	if errors.Is(err, ErrJobNotFound) {
+		job, err = s.DB.GetExternalJob(ctx, id)
+	}
+	if errors.Is(err, ErrJobNotFound) {
+		return types.Job{}, unknownJob("job_id", err)
+	}
+	if err != nil {
+		return types.Job{}, fmt.Errorf("could not get job: %w", err)
+	}
+	return job, nil
+}
+
+// unknownJob reports that field does not reference an existing job
+func unknownJob(field string, err error) error {
+	e := d.ErrUnknownReference.WithMessage("Unknown %s", field)
+	e.Fields = []types.FieldError{{Field: field, Code: "exists", Message: "does not reference an existing job"}}
+	return e.Wrap(err)
+}
+
+// canMove reports whether an application may move between two statuses: forward in the pipeline, or to rejected
+// from any status but rejected
+func canMove(from, to string) bool {
+	if from == types.ApplicationRejected {
+		return false
+	}
+	if to == types.ApplicationRejected {
+		return true
+	}
+	return slices.Index(types.ApplicationStatuses, to) > slices.Index(types.ApplicationStatuses, from)
+}
+
+// appliedAt defaults the application date to now once the subscriber applied, rejected applications keep theirs
+func appliedAt(status string, at *time.Time, now time.Time) *time.Time {
+	if at != nil || status == types.ApplicationSaved || status == types.ApplicationRejected {
+		return at
+	}
+	return &now
+}
@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobs/apperr"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCanMove(t *testing.T) {
+	tests := []struct {
+		from, to string
+		expected bool
+	}{
+		{from: types.ApplicationSaved, to: types.ApplicationApplied, expected: true},
+		{from: types.ApplicationSaved, to: types.ApplicationInterviewing, expected: true},
+		{from: types.ApplicationInterviewing, to: types.ApplicationOffer, expected: true},
+		{from: types.ApplicationOffer, to: types.ApplicationRejected, expected: true},
+		{from: types.ApplicationSaved, to: types.ApplicationRejected, expected: true},
+		{from: types.ApplicationInterviewing, to: types.ApplicationApplied, expected: false},
+		{from: types.ApplicationRejected, to: types.ApplicationApplied, expected: false},
+		{from: types.ApplicationRejected, to: types.ApplicationSaved, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.expected, canMove(tt.from, tt.to))
+		})
+	}
+}
+
+func TestCreateApplication(t *testing.T) {
+	jobID := uuid.New()
+	owner := uuid.New()
+
+	tests := []struct {
+		name         string
+		setupMock    func(mockDB *MockDB)
+		input        types.ApplicationInput
+		expectedCode string
+	}{
+		{
+			name: "Apply to an ingested external job",
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, jobID).Return(types.Job{}, ErrJobNotFound)
+				mockDB.On("GetExternalJob", mock.Anything, jobID).Return(types.Job{ID: &jobID, Source: types.SourceExternal,
+					Title: "Backend Developer", Country: "UK", Company: "Acme"}, nil)
+				mockDB.On("CreateApplication", mock.Anything, mock.MatchedBy(func(app types.Application) bool {
+					return app.SubscriberID == owner && *app.JobID == jobID && app.Source == types.SourceExternal &&
+						app.Title == "Backend Developer" && app.Company == "Acme" && app.Status == types.ApplicationApplied &&
+						app.AppliedAt != nil
+				})).Return(types.Application{ID: uuid.New()}, nil)
+			},
+			input: types.ApplicationInput{JobID: jobID, Status: types.ApplicationApplied},
+		},
+		{
+			name: "Saved by default",
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, jobID).Return(types.Job{ID: &jobID, Source: types.SourceInternal, Title: "Backend Developer"}, nil)
+				mockDB.On("CreateApplication", mock.Anything, mock.MatchedBy(func(app types.Application) bool {
+					return app.Source == types.SourceInternal && app.Status == types.ApplicationSaved && app.AppliedAt == nil
+				})).Return(types.Application{ID: uuid.New()}, nil)
+			},
+			input: types.ApplicationInput{JobID: jobID},
+		},
+		{
+			name: "Unknown job",
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, jobID).Return(types.Job{}, ErrJobNotFound)
+				mockDB.On("GetExternalJob", mock.Anything, jobID).Return(types.Job{}, ErrJobNotFound)
+			},
+			input:        types.ApplicationInput{JobID: jobID},
+			expectedCode: "unknown_reference",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			mockDB := new(MockDB)
+			service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+			tt.setupMock(mockDB)
+
+			_, err := service.CreateApplication(context.Background(), owner, tt.input)
+
+			if tt.expectedCode != "" {
+				e, ok := apperr.From(err)
+				if assert.True(t, ok) {
+					assert.Equal(t, tt.expectedCode, e.Code)
+				}
+			} else {
+				assert.NoError(t, err)
+			}
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestPatchApplication(t *testing.T) {
+	applied := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	app := types.Application{ID: uuid.New(), Status: types.ApplicationApplied, AppliedAt: &applied}
+	interviewing := types.ApplicationInterviewing
+	saved := types.ApplicationSaved
+
+	t.Run("Move forward", func(t *testing.T) {
+		l, _ := setup.SetupLogger()
+		mockDB := new(MockDB)
+		service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+		expected := app
+		expected.Status = types.ApplicationInterviewing
+		mockDB.On("UpdateApplication", mock.Anything, types.ApplicationApplied, expected, "Call on Monday").Return(expected, nil)
+
+		updated, err := service.PatchApplication(context.Background(), app, types.ApplicationPatch{Status: &interviewing, Note: "Call on Monday"})
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, updated)
+		mockDB.AssertExpectations(t)
+	})
+
+	t.Run("Never back", func(t *testing.T) {
+		l, _ := setup.SetupLogger()
+		mockDB := new(MockDB)
+		service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+		_, err := service.PatchApplication(context.Background(), app, types.ApplicationPatch{Status: &saved})
+
+		assert.ErrorIs(t, err, ErrInvalidTransition)
+		mockDB.AssertNotCalled(t, "UpdateApplication", mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+	})
+}
+
+func TestSummarizeApplications(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+	owner := uuid.New()
+	mockDB.On("CountApplications", mock.Anything, owner).Return(map[string]int{types.ApplicationApplied: 2, types.ApplicationOffer: 1}, nil)
+
+	summary, err := service.SummarizeApplications(context.Background(), owner)
+
+	assert.NoError(t, err)
+	assert.Equal(t, types.ApplicationSummary{
+		Counts: map[string]int{"saved": 0, "applied": 2, "interviewing": 0, "offer": 1, "rejected": 0},
+		Total:  3,
+	}, summary)
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"jobs/apperr"
+	d "jobs/db"
+	"jobs/types"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrCompanyNotFound is returned when no company has the given ID
+	ErrCompanyNotFound = d.ErrCompanyNotFound
+	// ErrCompanyFollowedAndBlocked rejects subscriber preferences that both follow and block a company
+	ErrCompanyFollowedAndBlocked = apperr.Validation("company_followed_and_blocked", "A company cannot be both followed and blocked")
+)
+
+// CreateCompany records a company
+func (s *JobsService) CreateCompany(ctx context.Context, input types.CompanyInput) (types.Company, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.CreateCompany")
+	defer span.End()
+
+	company, err := s.DB.CreateCompany(ctx, input)
+	if err != nil {
+		recordError(span, err)
+		return types.Company{}, fmt.Errorf("could not create company: %w", err)
+	}
+	return company, nil
+}
+
+// GetCompany returns a company
+func (s *JobsService) GetCompany(ctx context.Context, id uuid.UUID) (types.Company, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.GetCompany")
+	defer span.End()
+
+	company, err := s.DB.GetCompany(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return types.Company{}, err
+	}
+	return company, nil
+}
+
+// ListCompanies returns every company by name
+func (s *JobsService) ListCompanies(ctx context.Context) ([]types.Company, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ListCompanies")
+	defer span.End()
+
+	companies, err := s.DB.ListCompanies(ctx, nil)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("could not list companies: %w", err)
+	}
+	return companies, nil
+}
+
+// ReplaceCompany overwrites every field of a company
+func (s *JobsService) ReplaceCompany(ctx context.Context, id uuid.UUID, input types.CompanyInput) (types.Company, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ReplaceCompany")
+	defer span.End()
+
+	company, err := s.DB.ReplaceCompany(ctx, id, input)
+	if err != nil {
+		recordError(span, err)
+		return types.Company{}, fmt.Errorf("could not replace company: %w", err)
+	}
+	return company, nil
+}
+
+// DeleteCompany removes a company, its jobs are kept without a company
+func (s *JobsService) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "JobsService.DeleteCompany")
+	defer span.End()
+
+	if err := s.DB.DeleteCompany(ctx, id); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not delete company: %w", err)
+	}
+	return nil
+}
+
+// checkCompanyPreferences rejects preferences that both follow and block a company
+func checkCompanyPreferences(input types.SubscriberInput) error {
+	var fields []types.FieldError
+	for i, id := range input.BlockedCompanies {
+		if slices.Contains(input.FollowedCompanies, id) {
+			fields = append(fields, types.FieldError{
+				Field:   "blocked_companies[" + strconv.Itoa(i) + "]",
+				Code:    "followed",
+				Message: id.String() + " is also followed",
+			})
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return apperr.Validation(ErrCompanyFollowedAndBlocked.Code, ErrCompanyFollowedAndBlocked.Message, fields...)
+}
+
+// applyCompanyPreferences drops the external jobs of blocked companies and moves those of followed
+// companies first. The provider only names companies, so they are matched by name.
+func (s *JobsService) applyCompanyPreferences(ctx context.Context, query types.JobQuery, jobs []types.Job) ([]types.Job, error) {
+	if len(jobs) == 0 || len(query.FollowedCompanies)+len(query.BlockedCompanies) == 0 {
+		return jobs, nil
+	}
+	companies, err := s.DB.ListCompanies(ctx, append(slices.Clone(query.FollowedCompanies), query.BlockedCompanies...))
+	if err != nil {
+		return nil, fmt.Errorf("could not get company preferences: %w", err)
+	}
+	followed := map[string]bool{}
+	blocked := map[string]bool{}
+	for _, company := range companies {
+		name := strings.ToLower(company.Name)
+		if slices.Contains(query.BlockedCompanies, company.ID) {
+			blocked[name] = true
+		} else {
+			followed[name] = true
+		}
+	}
+
+	var first, rest []types.Job
+	for _, job := range jobs {
+		name := strings.ToLower(job.Company)
+		switch {
+		case blocked[name]:
+		case followed[name]:
+			first = append(first, job)
+		default:
+			rest = append(rest, job)
+		}
+	}
+	return append(first, rest...), nil
+}
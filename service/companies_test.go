@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"jobs/apperr"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListJobsCompanyPreferences(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	acme := types.Company{ID: uuid.New(), Name: "Acme"}
+	globex := types.Company{ID: uuid.New(), Name: "Globex"}
+	sub := subscriber
+	sub.FollowedCompanies = []uuid.UUID{acme.ID}
+	sub.BlockedCompanies = []uuid.UUID{globex.ID}
+
+	mockDB.On("GetSubscriber", mock.Anything, sub.ID).Return(sub, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, sub.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return assert.ObjectsAreEqual(sub.FollowedCompanies, q.FollowedCompanies) && assert.ObjectsAreEqual(sub.BlockedCompanies, q.BlockedCompanies)
+	})).Return([]types.Job{}, nil)
+	mockDB.On("ListCompanies", mock.Anything, []uuid.UUID{acme.ID, globex.ID}).Return([]types.Company{acme, globex}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]types.ExternalJob{
+		{Title: "Backend Developer", Salary: 3000, Company: "Initech"},
+		{Title: "Backend Developer", Salary: 4000, Company: "globex"},
+		{Title: "Backend Developer", Salary: 5000},
+		{Title: "Backend Developer", Salary: 6000, Company: "Acme"},
+	}, nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: sub.ID, JobTitles: []string{"Backend Developer"}, Countries: []string{"USA"}})
+
+	assert.NoError(t, err)
+	var companies []string
+	for _, job := range output.Items {
+		companies = append(companies, job.Company)
+	}
+	assert.Equal(t, []string{"Acme", "Initech", ""}, companies)
+	mockDB.AssertExpectations(t)
+}
+
+func TestSaveSubscriberRejectsFollowedAndBlockedCompany(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+	id := uuid.New()
+	input := types.SubscriberInput{
+		Name:              "Jane",
+		Email:             "jane@example.com",
+		JobTitles:         []string{"Backend Developer"},
+		Countries:         []string{"USA"},
+		FollowedCompanies: []uuid.UUID{id},
+		BlockedCompanies:  []uuid.UUID{uuid.New(), id},
+	}
+
+	_, _, err := service.SaveSubscriber(context.Background(), input)
+
+	assert.ErrorIs(t, err, ErrCompanyFollowedAndBlocked)
+	e, ok := apperr.From(err)
+	assert.True(t, ok)
+	assert.Equal(t, []types.FieldError{{Field: "blocked_companies[1]", Code: "followed", Message: id.String() + " is also followed"}}, e.Fields)
+	mockDB.AssertNotCalled(t, "SaveSubscriber", mock.Anything, mock.Anything)
+}
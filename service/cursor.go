@@ -0,0 +1,48 @@
+package service
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"jobs/apperr"
+	"jobs/types"
+)
+
+// ErrInvalidCursor is returned for a cursor that was not issued by a previous page
+var ErrInvalidCursor = apperr.InvalidParameter("cursor", "is not a cursor returned by a previous page")
+
+// encodeCursor returns the opaque cursor of the page starting at offset
+func encodeCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+// decodeCursor returns the offset of the page, an empty cursor is the first page
+func decodeCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(b))
+	if err != nil || offset < 0 {
+		return 0, ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+// paginate returns the page of items starting at offset, a zero limit returns every remaining item
+func paginate[T any](items []T, offset, limit int) types.List[T] {
+	list := types.List[T]{Items: []T{}, Total: len(items)}
+	if offset >= len(items) {
+		return list
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+		list.NextCursor = encodeCursor(end)
+	}
+	list.Items = items[offset:end]
+	return list
+}
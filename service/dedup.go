@@ -0,0 +1,186 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"jobs/types"
+
+	"github.com/google/uuid"
+)
+
+// descriptionSimilarity is the share of description words two records of the same opening have in common at least
+const descriptionSimilarity = 0.6
+
+// salaryTolerance is how far apart, relatively, two records of the same opening may put its salary
+const salaryTolerance = 0.1
+
+// legalSuffixes are dropped from employer names, "Acme Inc." and "ACME" are the same employer
+var legalSuffixes = map[string]bool{
+	"inc": true, "llc": true, "ltd": true, "corp": true, "co": true, "sa": true, "srl": true, "gmbh": true, "plc": true,
+}
+
+// fingerprint is the normalized form of a job used to find the records of the same opening.
+// Fields missing from a record match any value.
+type fingerprint struct {
+	title    string
+	country  string
+	company  string
+	city     string
+	workMode string
+	// salary is yearly in USD, 0 when unknown
+	salary float64
+	words  map[string]bool
+}
+
+func (s *JobsService) fingerprint(job types.Job) fingerprint {
+	var company []string
+	for _, word := range tokenize(job.Company) {
+		if !legalSuffixes[word] {
+			company = append(company, word)
+		}
+	}
+	fp := fingerprint{
+		title:    strings.Join(tokenize(job.Title), " "),
+		country:  job.Country,
+		company:  strings.Join(company, " "),
+		city:     strings.ToLower(strings.TrimSpace(job.City)),
+		workMode: job.WorkMode,
+		words:    map[string]bool{},
+	}
+	currency, period := salaryUnit(job.SalaryCurrency, job.SalaryPeriod)
+	if rate, ok := s.rates().Rate(currency, types.DefaultCurrency); ok && job.SalaryMin > 0 {
+		fp.salary = float64(job.SalaryMin) * rate * types.PeriodsPerYear[period]
+	}
+	for _, word := range tokenize(job.Description) {
+		fp.words[word] = true
+	}
+	return fp
+}
+
+// key groups the fingerprints that may match, the other fields are compared by sameOpening
+func (fp fingerprint) key() string {
+	return fp.title + "|" + fp.country
+}
+
+// sameOpening reports whether two fingerprints with the same key describe the same opening
+func (fp fingerprint) sameOpening(other fingerprint) bool {
+	return compatible(fp.company, other.company) &&
+		compatible(fp.city, other.city) &&
+		compatible(fp.workMode, other.workMode) &&
+		similarSalaries(fp.salary, other.salary) &&
+		similarWords(fp.words, other.words)
+}
+
+// compatible reports whether two normalized values are equal or one of them is unknown
+func compatible(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
+
+func similarSalaries(a, b float64) bool {
+	if a == 0 || b == 0 {
+		return true
+	}
+	low, high := min(a, b), max(a, b)
+	return high <= low*(1+salaryTolerance)
+}
+
+// similarWords compares the descriptions with the Jaccard index of their words
+func similarWords(a, b map[string]bool) bool {
+	if len(a) == 0 || len(b) == 0 {
+		return true
+	}
+	common := 0
+	for word := range a {
+		if b[word] {
+			common++
+		}
+	}
+	return float64(common)/float64(len(a)+len(b)-common) >= descriptionSimilarity
+}
+
+// dedupJobs merges the records of the same opening, such as an internal job also served by a provider. The best
+// record is kept at the position of the first one, with the IDs of every record merged into it, its own included.
+// Merges are reported at debug level.
+func (s *JobsService) dedupJobs(ctx context.Context, jobs []types.Job) ([]types.Job, [][]uuid.UUID, int) {
+	var (
+		kept   []types.Job
+		ids    [][]uuid.UUID
+		prints []fingerprint
+		merged int
+		byKey  = map[string][]int{}
+	)
+	for _, job := range jobs {
+		fp := s.fingerprint(job)
+		duplicate := -1
+		for _, i := range byKey[fp.key()] {
+			if prints[i].sameOpening(fp) {
+				duplicate = i
+				break
+			}
+		}
+		if duplicate < 0 {
+			byKey[fp.key()] = append(byKey[fp.key()], len(kept))
+			kept = append(kept, job)
+			ids = append(ids, appendID(nil, job))
+			prints = append(prints, fp)
+			continue
+		}
+
+		merged++
+		ids[duplicate] = appendID(ids[duplicate], job)
+		best, dropped := kept[duplicate], job
+		if betterRecord(job, best) {
+			best, dropped = job, best
+			kept[duplicate], prints[duplicate] = job, fp
+		}
+		s.log(ctx).Debugw("Merged duplicate jobs", "fingerprint", fp.key(), "kept", jobReference(best), "dropped", jobReference(dropped))
+	}
+	return kept, ids, merged
+}
+
+// appendID appends the ID of a job to ids when it has one
+func appendID(ids []uuid.UUID, job types.Job) []uuid.UUID {
+	if job.ID == nil {
+		return ids
+	}
+	return append(ids, *job.ID)
+}
+
+// betterRecord reports whether a is a better record of an opening than b: internal jobs win, then the most
+// complete record, then the most recent one
+func betterRecord(a, b types.Job) bool {
+	if (a.Source == types.SourceInternal) != (b.Source == types.SourceInternal) {
+		return a.Source == types.SourceInternal
+	}
+	if ca, cb := completeness(a), completeness(b); ca != cb {
+		return ca > cb
+	}
+	return a.PostedAt != nil && (b.PostedAt == nil || a.PostedAt.After(*b.PostedAt))
+}
+
+// completeness counts the optional fields a record has
+func completeness(job types.Job) int {
+	n := 0
+	for _, set := range []bool{
+		job.Description != "", job.Company != "", job.City != "", job.Region != "", job.WorkMode != "",
+		job.SalaryMin > 0, job.SalaryMax != nil, len(job.Skills) > 0, job.PostedAt != nil,
+	} {
+		if set {
+			n++
+		}
+	}
+	return n
+}
+
+// jobReference names a job in logs, by ID when it has one
+func jobReference(job types.Job) string {
+	if job.ID != nil {
+		return job.Source + ":" + job.ID.String()
+	}
+	ref := job.Source + ":" + job.Title + "@" + job.Country
+	if job.Company != "" {
+		ref += "/" + job.Company
+	}
+	return ref
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSameOpening(t *testing.T) {
+	service := &JobsService{}
+	job := types.Job{Title: "Backend Developer", Country: "USA", Company: "Acme Inc.", City: "Austin", WorkMode: types.WorkHybrid,
+		SalaryMin: 100000, SalaryCurrency: "USD", SalaryPeriod: types.PayYear, Description: "Build payment APIs in Go for our checkout team"}
+
+	tests := []struct {
+		name     string
+		other    types.Job
+		expected bool
+	}{
+		{name: "Same record", other: job, expected: true},
+		{name: "Employer without legal suffix", other: with(job, func(j *types.Job) { j.Company = "ACME" }), expected: true},
+		{name: "Unknown employer and city", other: with(job, func(j *types.Job) { j.Company, j.City = "", "" }), expected: true},
+		{name: "Salary in the same band", other: with(job, func(j *types.Job) { j.SalaryMin = 105000 }), expected: true},
+		{name: "Salary in another currency and period", other: with(job, func(j *types.Job) {
+			j.SalaryMin, j.SalaryCurrency, j.SalaryPeriod = 8500, "USD", types.PayMonth
+		}), expected: true},
+		{name: "Reworded description", other: with(job, func(j *types.Job) { j.Description = "Build the payment APIs in Go for the checkout team" }), expected: true},
+		{name: "Other employer", other: with(job, func(j *types.Job) { j.Company = "Globex" })},
+		{name: "Other city", other: with(job, func(j *types.Job) { j.City = "Dallas" })},
+		{name: "Other work mode", other: with(job, func(j *types.Job) { j.WorkMode = types.WorkRemote })},
+		{name: "Other salary band", other: with(job, func(j *types.Job) { j.SalaryMin = 130000 })},
+		{name: "Other description", other: with(job, func(j *types.Job) { j.Description = "Maintain our mobile apps in Kotlin" })},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := service.fingerprint(job), service.fingerprint(tt.other)
+			assert.Equal(t, a.key(), b.key())
+			assert.Equal(t, tt.expected, a.sameOpening(b))
+		})
+	}
+	assert.NotEqual(t, service.fingerprint(job).key(), service.fingerprint(with(job, func(j *types.Job) { j.Country = "UK" })).key())
+}
+
+func TestBetterRecord(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-time.Hour)
+	external := types.Job{Source: types.SourceExternal, Title: "Backend Developer", Company: "Acme", City: "Austin", SalaryMin: 100}
+
+	assert.True(t, betterRecord(types.Job{Source: types.SourceInternal}, external))
+	assert.True(t, betterRecord(with(external, func(j *types.Job) { j.Description = "Go" }), external))
+	assert.False(t, betterRecord(external, with(external, func(j *types.Job) { j.Description = "Go" })))
+	assert.True(t, betterRecord(with(external, func(j *types.Job) { j.PostedAt = &now }), with(external, func(j *types.Job) { j.PostedAt = &earlier })))
+	assert.False(t, betterRecord(external, external))
+}
+
+func TestListJobsMergesDuplicates(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	id := uuid.New()
+	internal := types.Job{ID: &id, Source: types.SourceInternal, Title: "Backend Developer", Country: "USA", Company: "Acme",
+		SalaryMin: 3000, SalaryCurrency: "USD", SalaryPeriod: types.PayYear}
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return([]types.Job{internal}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]types.ExternalJob{
+			{Title: "Backend Developer", Salary: 3000, Company: "Acme Inc"},
+			{Title: "Backend Developer", Salary: 5000, Company: "Globex"},
+			{Title: "Backend Developer", Salary: 5100, Company: "Globex", City: "Austin"},
+		}, nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, output.Total)
+	if assert.Len(t, output.Items, 2) {
+		assert.Equal(t, &id, output.Items[0].ID)
+		assert.Equal(t, "Austin", output.Items[1].City)
+	}
+}
+
+func TestListJobsActionsOnMergedRecords(t *testing.T) {
+	id := uuid.New()
+	internal := types.Job{ID: &id, Source: types.SourceInternal, Title: "Backend Developer", Country: "USA", Company: "Acme",
+		SalaryMin: 3000, SalaryCurrency: "USD", SalaryPeriod: types.PayYear}
+	served := []types.ExternalJob{
+		{Title: "Backend Developer", Salary: 3000, Company: "Acme Inc"},
+		{Title: "Backend Developer", Salary: 5000, Company: "Globex"},
+	}
+	// The external record of Acme is merged into the internal one
+	mergedAway := *fromExternalJob(types.SourceExternal, served[0], "USA").ID
+
+	tests := []struct {
+		name       string
+		action     string
+		companies  []string
+		bookmarked bool
+	}{
+		{name: "Dismissing a merged record dismisses the opening", action: types.ActionDismiss, companies: []string{"Globex"}},
+		{name: "Bookmarking a merged record bookmarks the opening", action: types.ActionBookmark, companies: []string{"Acme", "Globex"}, bookmarked: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			mockDB := new(MockDB)
+			mockFetcher := new(MockExternalJobsFetcher)
+			service := NewJobsService(l, mockDB, mockFetcher)
+
+			mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+			mockDB.On("ListJobActions", mock.Anything, tt.action, subscriber.ID).Return([]types.JobAction{{JobID: mergedAway}}, nil)
+			mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+			mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return([]types.Job{internal}, nil)
+			mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(served, nil)
+
+			output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID})
+
+			assert.NoError(t, err)
+			var companies []string
+			for _, job := range output.Items {
+				companies = append(companies, job.Company)
+			}
+			assert.Equal(t, tt.companies, companies)
+			assert.Equal(t, tt.bookmarked, output.Items[0].Bookmarked)
+		})
+	}
+}
+
+// with returns a copy of job changed by change
+func with(job types.Job, change func(*types.Job)) types.Job {
+	change(&job)
+	return job
+}
@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// importChunkSize is the number of subscribers upserted per statement
+const importChunkSize = 100
+
+// ErrDuplicateEmail rejects a row repeating the email of an earlier row of the same batch
+var ErrDuplicateEmail = apperr.Validation("duplicate_email", "The email is already used by an earlier row")
+
+// ImportSubscribers saves a batch of subscribers and reports the outcome of every row.
+//
+// Rows carrying an error are reported as failed without being saved. With atomic set, nothing is
+// saved when any row fails and a batch_rejected error lists the failing rows.
+func (s *JobsService) ImportSubscribers(ctx context.Context, rows []types.SubscriberImportRow, atomic bool) (types.SubscriberImportOutput, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ImportSubscribers")
+	defer span.End()
+	span.SetAttributes(attribute.Int("import.rows", len(rows)), attribute.Bool("import.atomic", atomic))
+
+	results := make([]types.SubscriberImportResult, len(rows))
+	var (
+		pending []int
+		inputs  []types.SubscriberInput
+		seen    = map[string]bool{}
+	)
+	for i, row := range rows {
+		results[i] = types.SubscriberImportResult{Row: row.Row, Email: row.Input.Email}
+		err := row.Err
+		if err == nil {
+			err = checkCompanyPreferences(row.Input)
+		}
+		if err == nil {
+			err = s.checkCurrency(row.Input.SalaryCurrency)
+		}
+		switch {
+		case err != nil:
+			s.failRow(ctx, &results[i], err)
+		case seen[row.Input.Email]:
+			s.failRow(ctx, &results[i], ErrDuplicateEmail)
+		default:
+			seen[row.Input.Email] = true
+			pending = append(pending, i)
+			input := row.Input
+			input.SalaryCurrency, input.SalaryPeriod = salaryUnit(input.SalaryCurrency, input.SalaryPeriod)
+			inputs = append(inputs, input)
+		}
+	}
+
+	if len(inputs) > 0 && !(atomic && len(inputs) < len(rows)) {
+		saved, err := s.DB.SaveSubscribers(ctx, inputs, importChunkSize, atomic)
+		if err != nil {
+			recordError(span, err)
+			return types.SubscriberImportOutput{}, fmt.Errorf("could not import subscribers: %w", err)
+		}
+		for j, i := range pending {
+			if saved[j].Err != nil {
+				s.failRow(ctx, &results[i], saved[j].Err)
+				continue
+			}
+			id := saved[j].ID
+			results[i].ID = &id
+			results[i].Status = types.ImportUpdated
+			if saved[j].Created {
+				results[i].Status = types.ImportCreated
+			}
+		}
+	}
+
+	output := types.SubscriberImportOutput{Results: results}
+	for _, result := range results {
+		switch result.Status {
+		case types.ImportCreated:
+			output.Created++
+		case types.ImportUpdated:
+			output.Updated++
+		default:
+			output.Failed++
+		}
+	}
+	span.SetAttributes(
+		attribute.Int("import.created", output.Created),
+		attribute.Int("import.updated", output.Updated),
+		attribute.Int("import.failed", output.Failed),
+	)
+
+	if atomic && output.Failed > 0 {
+		err := batchRejected(results, output.Failed)
+		recordError(span, err)
+		return types.SubscriberImportOutput{}, err
+	}
+	return output, nil
+}
+
+// failRow marks a row as failed, domain errors keep their code and message while others are only logged
+func (s *JobsService) failRow(ctx context.Context, result *types.SubscriberImportResult, err error) {
+	result.Status = types.ImportFailed
+	e, ok := apperr.From(err)
+	if !ok || e.Kind == apperr.KindInternal {
+		s.log(ctx).Errorf("Could not import row %d: %v", result.Row, err)
+		result.Code = "internal_error"
+		result.Reason = "An internal error occurred"
+		return
+	}
+	result.Code = e.Code
+	result.Reason = e.Message
+	result.Errors = e.Fields
+}
+
+// batchRejected lists the failing rows of an atomic import, fields are prefixed with rows[<row>]
+func batchRejected(results []types.SubscriberImportResult, failed int) error {
+	var fields []types.FieldError
+	for _, result := range results {
+		if result.Status != types.ImportFailed {
+			continue
+		}
+		prefix := "rows[" + strconv.Itoa(result.Row) + "]"
+		if len(result.Errors) == 0 {
+			fields = append(fields, types.FieldError{Field: prefix, Code: result.Code, Message: result.Reason})
+			continue
+		}
+		for _, fe := range result.Errors {
+			fields = append(fields, types.FieldError{Field: prefix + "." + fe.Field, Code: fe.Code, Message: fe.Message})
+		}
+	}
+	message := fmt.Sprintf("%d of %d rows failed, nothing was saved", failed, len(results))
+	return apperr.Validation("batch_rejected", message, fields...)
+}
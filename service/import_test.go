@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"jobs/apperr"
+	d "jobs/db"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestImportSubscribers(t *testing.T) {
+	jane := types.SubscriberInput{Name: "Jane", Email: "jane@example.com", JobTitles: []string{"Backend Developer"}, Countries: []string{"USA"}, SalaryMin: 1000}
+	john := types.SubscriberInput{Name: "John", Email: "john@example.com", JobTitles: []string{"Designer"}, Countries: []string{"UK"}, SalaryMin: 2000,
+		SalaryCurrency: "GBP", SalaryPeriod: types.PayMonth}
+	// Rows without a salary unit are saved in USD per year
+	saved := jane
+	saved.SalaryCurrency, saved.SalaryPeriod = types.DefaultCurrency, types.DefaultPayPeriod
+	invalid := apperr.Validation("validation_failed", "The request body failed validation",
+		types.FieldError{Field: "email", Code: "email", Message: "must be a valid email address"})
+	janeID, johnID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name            string
+		rows            []types.SubscriberImportRow
+		atomic          bool
+		setupMock       func(db *MockDB)
+		expectedStatus  []string
+		expectedCodes   []string
+		expectedErrCode string
+	}{
+		{
+			name: "Created and updated rows",
+			rows: []types.SubscriberImportRow{{Row: 1, Input: jane}, {Row: 2, Input: john}},
+			setupMock: func(db *MockDB) {
+				db.On("SaveSubscribers", mock.Anything, []types.SubscriberInput{saved, john}, importChunkSize, false).
+					Return([]d.SubscriberSaveResult{{ID: janeID, Created: true}, {ID: johnID}}, nil)
+			},
+			expectedStatus: []string{types.ImportCreated, types.ImportUpdated},
+			expectedCodes:  []string{"", ""},
+		},
+		{
+			name: "Invalid and duplicate rows are not saved",
+			rows: []types.SubscriberImportRow{{Row: 1, Input: jane}, {Row: 2, Err: invalid}, {Row: 3, Input: jane}},
+			setupMock: func(db *MockDB) {
+				db.On("SaveSubscribers", mock.Anything, []types.SubscriberInput{saved}, importChunkSize, false).
+					Return([]d.SubscriberSaveResult{{ID: janeID, Created: true}}, nil)
+			},
+			expectedStatus: []string{types.ImportCreated, types.ImportFailed, types.ImportFailed},
+			expectedCodes:  []string{"", "validation_failed", "duplicate_email"},
+		},
+		{
+			name: "Rows rejected by the database fail on their own",
+			rows: []types.SubscriberImportRow{{Row: 1, Input: jane}, {Row: 2, Input: john}},
+			setupMock: func(db *MockDB) {
+				db.On("SaveSubscribers", mock.Anything, []types.SubscriberInput{saved, john}, importChunkSize, false).
+					Return([]d.SubscriberSaveResult{{ID: janeID, Created: true}, {Err: d.ErrInvalidValue}}, nil)
+			},
+			expectedStatus: []string{types.ImportCreated, types.ImportFailed},
+			expectedCodes:  []string{"", d.ErrInvalidValue.Code},
+		},
+		{
+			name: "Unexpected row errors are not leaked",
+			rows: []types.SubscriberImportRow{{Row: 1, Input: jane}},
+			setupMock: func(db *MockDB) {
+				db.On("SaveSubscribers", mock.Anything, []types.SubscriberInput{saved}, importChunkSize, false).
+					Return([]d.SubscriberSaveResult{{Err: fmt.Errorf("connection reset")}}, nil)
+			},
+			expectedStatus: []string{types.ImportFailed},
+			expectedCodes:  []string{"internal_error"},
+		},
+		{
+			name:            "Atomic import skips the database when a row is invalid",
+			rows:            []types.SubscriberImportRow{{Row: 1, Input: jane}, {Row: 2, Err: invalid}},
+			atomic:          true,
+			setupMock:       func(db *MockDB) {},
+			expectedErrCode: "batch_rejected",
+		},
+		{
+			name:   "Atomic import is rejected when the database refuses a row",
+			rows:   []types.SubscriberImportRow{{Row: 1, Input: jane}, {Row: 2, Input: john}},
+			atomic: true,
+			setupMock: func(db *MockDB) {
+				db.On("SaveSubscribers", mock.Anything, []types.SubscriberInput{saved, john}, importChunkSize, true).
+					Return([]d.SubscriberSaveResult{{ID: janeID, Created: true}, {Err: d.ErrInvalidValue}}, nil)
+			},
+			expectedErrCode: "batch_rejected",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			mockDB := new(MockDB)
+			tt.setupMock(mockDB)
+			service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+			output, err := service.ImportSubscribers(context.Background(), tt.rows, tt.atomic)
+
+			if tt.expectedErrCode != "" {
+				e, ok := apperr.From(err)
+				assert.True(t, ok)
+				assert.Equal(t, tt.expectedErrCode, e.Code)
+				assert.NotEmpty(t, e.Fields)
+				mockDB.AssertExpectations(t)
+				return
+			}
+			assert.NoError(t, err)
+
+			var status, codes []string
+			counts := map[string]int{}
+			for i, result := range output.Results {
+				assert.Equal(t, tt.rows[i].Row, result.Row)
+				status = append(status, result.Status)
+				codes = append(codes, result.Code)
+				counts[result.Status]++
+			}
+			assert.Equal(t, tt.expectedStatus, status)
+			assert.Equal(t, tt.expectedCodes, codes)
+			assert.Equal(t, counts[types.ImportCreated], output.Created)
+			assert.Equal(t, counts[types.ImportUpdated], output.Updated)
+			assert.Equal(t, counts[types.ImportFailed], output.Failed)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
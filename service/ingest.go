@@ -0,0 +1,143 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	e "jobs/external"
+	"jobs/types"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ExternalSource is an external job provider, its jobs are stored under Name
+type ExternalSource struct {
+	Name    string
+	Fetcher e.ExternalJobsFetcher
+}
+
+// sources returns the ingested providers, the JobsFetcher when none is configured
+func (s *JobsService) sources() []ExternalSource {
+	if len(s.Sources) == 0 {
+		return []ExternalSource{{Name: types.SourceExternal, Fetcher: s.JobsFetcher}}
+	}
+	return s.Sources
+}
+
+// RunExternalIngestion ingests external jobs right away and then every interval, until ctx is done
+func (s *JobsService) RunExternalIngestion(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Failures are logged by IngestExternalJobs, the next tick retries
+		_, _ = s.IngestExternalJobs(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// IngestExternalJobs asks every source for the jobs of every title and country subscribers care about, without
+// salary filters, and stores them. A failing source or search does not stop the others, the failures are returned
+// together with the number of stored jobs.
+func (s *JobsService) IngestExternalJobs(ctx context.Context) (int64, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.IngestExternalJobs")
+	defer span.End()
+
+	searches, err := s.DB.ListExternalSearches(ctx)
+	if err != nil {
+		recordError(span, err)
+		s.log(ctx).Errorf("Could not list external searches: %v", err)
+		return 0, fmt.Errorf("could not list external searches: %w", err)
+	}
+	searches = expandSearches(searches)
+	span.SetAttributes(attribute.Int("jobs.searches.count", len(searches)))
+
+	var (
+		stored int64
+		errs   []error
+	)
+	for _, source := range s.sources() {
+		n, err := s.ingestSource(ctx, source, searches)
+		stored += n
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	span.SetAttributes(attribute.Int64("jobs.stored.count", stored))
+	if err := errors.Join(errs...); err != nil {
+		recordError(span, err)
+		s.log(ctx).Errorf("Ingested %d external jobs with failures: %v", stored, err)
+		return stored, err
+	}
+	s.log(ctx).Infof("Ingested %d external jobs", stored)
+	return stored, nil
+}
+
+// ingestSource stores the jobs one source serves for searches, the searches that failed are skipped
+func (s *JobsService) ingestSource(ctx context.Context, source ExternalSource, searches []types.ExternalSearch) (int64, error) {
+	var (
+		jobs []types.Job
+		errs []error
+	)
+	for _, search := range searches {
+		found, err := source.Fetcher.FetchExternalJobs(ctx, search.Title, 0, 0, search.Country)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not fetch %v jobs %v/%v: %w", source.Name, search.Title, search.Country, err))
+			continue
+		}
+		for _, job := range found {
+			jobs = append(jobs, fromExternalJob(source.Name, job, search.Country))
+		}
+	}
+	if len(jobs) == 0 {
+		return 0, errors.Join(errs...)
+	}
+	n, err := s.DB.UpsertExternalJobs(ctx, source.Name, jobs)
+	if err != nil {
+		errs = append(errs, fmt.Errorf("could not store %v jobs: %w", source.Name, err))
+	}
+	return n, errors.Join(errs...)
+}
+
+// expandSearches replaces ALL and missing countries with the values the providers know, once each
+func expandSearches(searches []types.ExternalSearch) []types.ExternalSearch {
+	var (
+		expanded []types.ExternalSearch
+		seen     = map[types.ExternalSearch]bool{}
+	)
+	for _, search := range searches {
+		countries := []string{defaultExternalCountry}
+		if search.Country != "" {
+			countries = expandWildcard([]string{search.Country}, "country")
+		}
+		for _, title := range expandWildcard([]string{search.Title}, "job_title") {
+			for _, country := range countries {
+				search := types.ExternalSearch{Title: title, Country: country}
+				if !seen[search] {
+					seen[search] = true
+					expanded = append(expanded, search)
+				}
+			}
+		}
+	}
+	return expanded
+}
+
+// storedExternalJobs returns the ingested external jobs matching the titles and countries of the query, leaving out
+// the ones the providers stopped serving ExternalJobsMaxAge ago
+func (s *JobsService) storedExternalJobs(ctx context.Context, query types.JobQuery) ([]types.Job, error) {
+	var seenAfter time.Time
+	if s.ExternalJobsMaxAge > 0 {
+		seenAfter = time.Now().Add(-s.ExternalJobsMaxAge)
+	}
+	jobs, err := s.DB.GetExternalJobs(ctx, query, seenAfter)
+	if err != nil {
+		return nil, fmt.Errorf("could not get stored external jobs: %w", err)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,142 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	d "jobs/db"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestExpandSearches(t *testing.T) {
+	searches := expandSearches([]types.ExternalSearch{
+		{Title: "Backend Developer"},
+		{Title: "Backend Developer", Country: "Argentina"},
+		{Title: types.Wildcard, Country: "UK"},
+		{Title: "Frontend Developer", Country: types.Wildcard},
+	})
+
+	assert.Equal(t, types.ExternalSearch{Title: "Backend Developer", Country: "Argentina"}, searches[0])
+	// Frontend Developer in the UK is asked for once
+	assert.Len(t, searches, 1+len(types.Vocabularies["job_title"])+len(types.Vocabularies["country"])-1)
+	assert.NotContains(t, searches, types.ExternalSearch{Title: types.Wildcard, Country: "UK"})
+	assert.Contains(t, searches, types.ExternalSearch{Title: "Frontend Developer", Country: "UK"})
+}
+
+func TestIngestExternalJobs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	backend := new(MockExternalJobsFetcher)
+	other := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, backend)
+	service.Sources = []ExternalSource{{Name: "backend", Fetcher: backend}, {Name: "other", Fetcher: other}}
+
+	mockDB.On("ListExternalSearches", mock.Anything).Return([]types.ExternalSearch{
+		{Title: "Backend Developer"},
+		{Title: "Frontend Developer", Country: "UK"},
+	}, nil)
+	backend.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(0), int64(0), "Argentina").
+		Return([]types.ExternalJob{{Title: "Backend Developer", Salary: 3000, Company: "Acme"}}, nil)
+	backend.On("FetchExternalJobs", mock.Anything, "Frontend Developer", int64(0), int64(0), "UK").
+		Return([]types.ExternalJob(nil), fmt.Errorf("timeout"))
+	other.On("FetchExternalJobs", mock.Anything, mock.Anything, int64(0), int64(0), mock.Anything).Return([]types.ExternalJob{}, nil)
+	stored := types.Job{
+		Source: types.SourceExternal, Title: "Backend Developer", Country: "Argentina", SalaryMin: 3000, SalaryCurrency: "ARS",
+		SalaryPeriod: types.PayYear, Skills: []string{}, Company: "Acme",
+	}
+	id := d.ExternalJobID("backend", stored)
+	stored.ID = &id
+	mockDB.On("UpsertExternalJobs", mock.Anything, "backend", []types.Job{stored}).Return(int64(1), nil)
+
+	n, err := service.IngestExternalJobs(context.Background())
+
+	assert.Equal(t, int64(1), n)
+	assert.ErrorContains(t, err, "could not fetch backend jobs Frontend Developer/UK: timeout")
+	mockDB.AssertExpectations(t)
+	other.AssertNumberOfCalls(t, "FetchExternalJobs", 2)
+	mockDB.AssertNumberOfCalls(t, "UpsertExternalJobs", 1)
+}
+
+func TestListJobsServesStoredExternalJobs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+	service.StoredExternalJobs = true
+	service.ExternalJobsMaxAge = 24 * time.Hour
+
+	id := uuid.New()
+	stored := types.Job{ID: &id, Source: types.SourceExternal, Title: "Backend Developer", Country: "USA", SalaryMin: 3000,
+		SalaryCurrency: "USD", SalaryPeriod: types.PayYear, Skills: []string{}}
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return([]types.Job{}, nil)
+	mockDB.On("GetExternalJobs", mock.Anything, mock.Anything, mock.MatchedBy(func(seenAfter time.Time) bool {
+		return time.Since(seenAfter) >= 24*time.Hour && time.Since(seenAfter) < 25*time.Hour
+	})).Return([]types.Job{stored}, nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Job{stored}, output.Items)
+	mockFetcher.AssertNotCalled(t, "FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestIngestExternalJobsKeepsIDs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	var upserted []types.Job
+	mockDB.On("ListExternalSearches", mock.Anything).Return([]types.ExternalSearch{{Title: "Backend Developer", Country: "UK"}}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(0), int64(0), "UK").
+		Return([]types.ExternalJob{{Title: "Backend Developer", Salary: 60000, Company: "Acme"}}, nil).Once()
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(0), int64(0), "UK").
+		Return([]types.ExternalJob{{Title: "Backend Developer", Salary: 70000, Company: "Acme", City: "London"}}, nil).Once()
+	mockDB.On("UpsertExternalJobs", mock.Anything, types.SourceExternal, mock.Anything).
+		Run(func(args mock.Arguments) { upserted = append(upserted, args.Get(2).([]types.Job)...) }).Return(int64(1), nil)
+
+	for range 2 {
+		_, err := service.IngestExternalJobs(context.Background())
+		assert.NoError(t, err)
+	}
+
+	// The salary and city changed, the job is the same
+	if assert.Len(t, upserted, 2) {
+		assert.Equal(t, int64(70000), upserted[1].SalaryMin)
+		assert.Equal(t, *upserted[0].ID, *upserted[1].ID)
+	}
+}
+
+func TestIngestExternalJobsWithoutCompany(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	var upserted []types.Job
+	mockDB.On("ListExternalSearches", mock.Anything).Return([]types.ExternalSearch{{Title: "Backend Developer", Country: "UK"}}, nil)
+	// The provider seldom sends the company of the jobs of a search
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(0), int64(0), "UK").
+		Return([]types.ExternalJob{
+			{Title: "Backend Developer", Salary: 60000, Skills: types.Skills{Skills: []types.Skill{{Name: "Go"}}}},
+			{Title: "Backend Developer", Salary: 60000, Skills: types.Skills{Skills: []types.Skill{{Name: "Java"}}}},
+		}, nil)
+	mockDB.On("UpsertExternalJobs", mock.Anything, types.SourceExternal, mock.Anything).
+		Run(func(args mock.Arguments) { upserted = args.Get(2).([]types.Job) }).Return(int64(2), nil)
+
+	_, err := service.IngestExternalJobs(context.Background())
+
+	assert.NoError(t, err)
+	if assert.Len(t, upserted, 2) {
+		assert.NotEqual(t, *upserted[0].ID, *upserted[1].ID)
+	}
+}
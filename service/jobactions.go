@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	d "jobs/db"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrJobActionNotFound is returned when removing a bookmark or dismissal the subscriber does not have
+var ErrJobActionNotFound = d.ErrJobActionNotFound
+
+// SaveJobAction bookmarks or dismisses an internal or external job for a subscriber, undoing the opposite action
+func (s *JobsService) SaveJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) (types.JobAction, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.SaveJobAction")
+	defer span.End()
+	span.SetAttributes(attribute.String("jobs.action", action))
+
+	source, err := s.jobSource(ctx, jobID)
+	if err != nil {
+		recordError(span, err)
+		return types.JobAction{}, err
+	}
+	saved, err := s.DB.SaveJobAction(ctx, action, types.JobAction{SubscriberID: subscriberID, JobID: jobID, Source: source})
+	if err != nil {
+		recordError(span, err)
+		return types.JobAction{}, fmt.Errorf("could not save job action: %w", err)
+	}
+	return saved, nil
+}
+
+// DeleteJobAction removes a bookmark or dismissal of a subscriber
+func (s *JobsService) DeleteJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "JobsService.DeleteJobAction")
+	defer span.End()
+	span.SetAttributes(attribute.String("jobs.action", action))
+
+	if err := s.DB.DeleteJobAction(ctx, action, subscriberID, jobID); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not delete job action: %w", err)
+	}
+	return nil
+}
+
+// ListJobActions lists the bookmarks or dismissals of a subscriber, newest first
+func (s *JobsService) ListJobActions(ctx context.Context, action string, subscriberID uuid.UUID) ([]types.JobAction, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ListJobActions")
+	defer span.End()
+	span.SetAttributes(attribute.String("jobs.action", action))
+
+	actions, err := s.DB.ListJobActions(ctx, action, subscriberID)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("could not list job actions: %w", err)
+	}
+	return actions, nil
+}
+
+// jobSource tells whether a job is internal or external. Ingested external jobs are looked up like internal ones. The
+// external jobs fetched from the provider on every request are not stored, their IDs are accepted when they are
+// version 5 UUIDs as ExternalJobID derives them, internal jobs having random ones.
+func (s *JobsService) jobSource(ctx context.Context, id uuid.UUID) (string, error) {
+	_, err := s.DB.GetJob(ctx, id)
+	switch {
+	case err == nil:
+		return types.SourceInternal, nil
+	case !errors.Is(err, ErrJobNotFound):
+		return "", fmt.Errorf("could not get job: %w", err)
+	case !s.StoredExternalJobs && id.Version() == 5:
+		return types.SourceExternal, nil
+	case !s.StoredExternalJobs:
+		return "", unknownJob("id", err)
+	}
+	_, err = s.DB.GetExternalJob(ctx, id)
+	if errors.Is(err, ErrJobNotFound) {
+		return "", unknownJob("id", err)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not get external job: %w", err)
+	}
+	return types.SourceExternal, nil
+}
+
+// applyJobActions leaves out the jobs the subscriber dismissed and flags the bookmarked ones, listed first when
+// the query asks for it. ids are the IDs of the records merged into each job: a job is dismissed or bookmarked
+// through any of them.
+func (s *JobsService) applyJobActions(ctx context.Context, query types.JobQuery, jobs []types.Job, ids [][]uuid.UUID) ([]types.Job, error) {
+	if len(jobs) == 0 {
+		return jobs, nil
+	}
+	dismissed, err := s.jobActionIDs(ctx, types.ActionDismiss, query.SubscriberID)
+	if err != nil {
+		return nil, err
+	}
+	bookmarked, err := s.jobActionIDs(ctx, types.ActionBookmark, query.SubscriberID)
+	if err != nil {
+		return nil, err
+	}
+
+	var first, rest []types.Job
+	for i, job := range jobs {
+		switch {
+		case slices.ContainsFunc(ids[i], func(id uuid.UUID) bool { return dismissed[id] }):
+		case slices.ContainsFunc(ids[i], func(id uuid.UUID) bool { return bookmarked[id] }):
+			job.Bookmarked = true
+			if query.BookmarkedFirst {
+				first = append(first, job)
+			} else {
+				rest = append(rest, job)
+			}
+		default:
+			rest = append(rest, job)
+		}
+	}
+	return append(first, rest...), nil
+}
+
+// jobActionIDs returns the IDs of the jobs a subscriber bookmarked or dismissed
+func (s *JobsService) jobActionIDs(ctx context.Context, action string, subscriberID uuid.UUID) (map[uuid.UUID]bool, error) {
+	actions, err := s.DB.ListJobActions(ctx, action, subscriberID)
+	if err != nil {
+		return nil, fmt.Errorf("could not get job actions: %w", err)
+	}
+	ids := make(map[uuid.UUID]bool, len(actions))
+	for _, a := range actions {
+		ids[a.JobID] = true
+	}
+	return ids, nil
+}
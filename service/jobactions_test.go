@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	d "jobs/db"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListJobsJobActions(t *testing.T) {
+	jobs := internalJobs(3)
+	external := fromExternalJob(types.SourceExternal, types.ExternalJob{Title: "Backend Developer", Salary: 3000, Company: "Acme"}, "USA")
+
+	tests := []struct {
+		name            string
+		bookmarkedFirst bool
+		expected        []string
+	}{
+		{name: "Dismissed jobs are left out", expected: []string{"Employer 0", "Employer 2", "Acme"}},
+		{name: "Bookmarked jobs first", bookmarkedFirst: true, expected: []string{"Acme", "Employer 0", "Employer 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			mockDB := new(MockDB)
+			mockFetcher := new(MockExternalJobsFetcher)
+			service := NewJobsService(l, mockDB, mockFetcher)
+
+			mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+			mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return(jobs, nil)
+			mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+				Return([]types.ExternalJob{{Title: "Backend Developer", Salary: 3000, Company: "Acme"}}, nil)
+			mockDB.On("ListJobActions", mock.Anything, types.ActionDismiss, subscriber.ID).Return([]types.JobAction{{JobID: *jobs[1].ID}}, nil)
+			mockDB.On("ListJobActions", mock.Anything, types.ActionBookmark, subscriber.ID).Return([]types.JobAction{{JobID: *external.ID}}, nil)
+
+			output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, BookmarkedFirst: tt.bookmarkedFirst})
+
+			assert.NoError(t, err)
+			var companies []string
+			for _, job := range output.Items {
+				companies = append(companies, job.Company)
+				assert.Equal(t, job.Company == "Acme", job.Bookmarked)
+			}
+			assert.Equal(t, tt.expected, companies)
+		})
+	}
+}
+
+func TestSaveJobAction(t *testing.T) {
+	owner := uuid.New()
+	jobID := uuid.New()
+	// Listed by the live path when external jobs are not ingested
+	liveID := *fromExternalJob(types.SourceExternal, types.ExternalJob{Title: "Backend Developer", Salary: 3000}, "USA").ID
+
+	tests := []struct {
+		name           string
+		storedExternal bool
+		jobID          uuid.UUID
+		setupMock      func(mockDB *MockDB)
+		expectedErr    error
+	}{
+		{
+			name:  "Bookmark an internal job",
+			jobID: jobID,
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, jobID).Return(types.Job{ID: &jobID, Source: types.SourceInternal}, nil)
+				mockDB.On("SaveJobAction", mock.Anything, types.ActionBookmark,
+					types.JobAction{SubscriberID: owner, JobID: jobID, Source: types.SourceInternal}).Return(types.JobAction{}, nil)
+			},
+		},
+		{
+			name:  "Bookmark an external job fetched on every request",
+			jobID: liveID,
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, liveID).Return(types.Job{}, ErrJobNotFound)
+				mockDB.On("SaveJobAction", mock.Anything, types.ActionBookmark,
+					types.JobAction{SubscriberID: owner, JobID: liveID, Source: types.SourceExternal}).Return(types.JobAction{}, nil)
+			},
+		},
+		{
+			name:  "Random IDs are not external jobs",
+			jobID: jobID,
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, jobID).Return(types.Job{}, ErrJobNotFound)
+			},
+			expectedErr: d.ErrUnknownReference,
+		},
+		{
+			name:           "Bookmark an ingested external job",
+			storedExternal: true,
+			jobID:          liveID,
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, liveID).Return(types.Job{}, ErrJobNotFound)
+				mockDB.On("GetExternalJob", mock.Anything, liveID).Return(types.Job{ID: &liveID, Source: types.SourceExternal}, nil)
+				mockDB.On("SaveJobAction", mock.Anything, types.ActionBookmark,
+					types.JobAction{SubscriberID: owner, JobID: liveID, Source: types.SourceExternal}).Return(types.JobAction{}, nil)
+			},
+		},
+		{
+			name:           "Stored external jobs must be ingested",
+			storedExternal: true,
+			jobID:          liveID,
+			setupMock: func(mockDB *MockDB) {
+				mockDB.On("GetJob", mock.Anything, liveID).Return(types.Job{}, ErrJobNotFound)
+				mockDB.On("GetExternalJob", mock.Anything, liveID).Return(types.Job{}, d.ErrJobNotFound)
+			},
+			expectedErr: d.ErrUnknownReference,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			mockDB := new(MockDB)
+			service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+			service.StoredExternalJobs = tt.storedExternal
+			tt.setupMock(mockDB)
+
+			_, err := service.SaveJobAction(context.Background(), types.ActionBookmark, owner, tt.jobID)
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			mockDB.AssertExpectations(t)
+			if !tt.storedExternal {
+				mockDB.AssertNotCalled(t, "GetExternalJob", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	d "jobs/db"
+	"jobs/types"
+
+	"github.com/google/uuid"
+)
+
+var (
+	// ErrJobNotFound is returned when no internal job has the given ID
+	ErrJobNotFound = d.ErrJobNotFound
+	// ErrJobModified is returned when a write is conditioned on a version the job no longer has
+	ErrJobModified = d.ErrJobModified
+)
+
+// CreateJob publishes an internal job
+func (s *JobsService) CreateJob(ctx context.Context, input types.JobInput) (types.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.CreateJob")
+	defer span.End()
+
+	if input.Status == "" {
+		input.Status = types.JobOpen
+	}
+	if input.WorkMode == "" {
+		input.WorkMode = types.WorkOnsite
+	}
+	if err := s.checkCurrency(input.SalaryCurrency); err != nil {
+		recordError(span, err)
+		return types.Job{}, err
+	}
+	input.SalaryCurrency, input.SalaryPeriod = salaryUnit(input.SalaryCurrency, input.SalaryPeriod)
+	job, err := s.DB.CreateJob(ctx, input)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("could not create job: %w", err)
+	}
+	return job, nil
+}
+
+// GetJob returns an internal job
+func (s *JobsService) GetJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.GetJob")
+	defer span.End()
+
+	job, err := s.DB.GetJob(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, err
+	}
+	return job, nil
+}
+
+// ReplaceJob overwrites an internal job, only if it was last updated at version when version is set
+func (s *JobsService) ReplaceJob(ctx context.Context, id uuid.UUID, input types.JobInput, version *time.Time) (types.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ReplaceJob")
+	defer span.End()
+
+	if input.Status == "" {
+		input.Status = types.JobOpen
+	}
+	if input.WorkMode == "" {
+		input.WorkMode = types.WorkOnsite
+	}
+	if err := s.checkCurrency(input.SalaryCurrency); err != nil {
+		recordError(span, err)
+		return types.Job{}, err
+	}
+	input.SalaryCurrency, input.SalaryPeriod = salaryUnit(input.SalaryCurrency, input.SalaryPeriod)
+	job, err := s.DB.ReplaceJob(ctx, id, input, version)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("could not replace job: %w", err)
+	}
+	return job, nil
+}
+
+// PatchJob changes some fields of an internal job, version is checked as in ReplaceJob
+func (s *JobsService) PatchJob(ctx context.Context, id uuid.UUID, patch types.JobPatch, version *time.Time) (types.Job, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.PatchJob")
+	defer span.End()
+
+	if patch.SalaryCurrency != nil {
+		if err := s.checkCurrency(*patch.SalaryCurrency); err != nil {
+			recordError(span, err)
+			return types.Job{}, err
+		}
+	}
+	job, err := s.DB.PatchJob(ctx, id, patch, version)
+	if err != nil {
+		recordError(span, err)
+		return types.Job{}, fmt.Errorf("could not patch job: %w", err)
+	}
+	return job, nil
+}
+
+// DeleteJob removes an internal job, version is checked as in ReplaceJob
+func (s *JobsService) DeleteJob(ctx context.Context, id uuid.UUID, version *time.Time) error {
+	ctx, span := tracer.Start(ctx, "JobsService.DeleteJob")
+	defer span.End()
+
+	if err := s.DB.DeleteJob(ctx, id, version); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not delete job: %w", err)
+	}
+	return nil
+}
+
+// SweepExpiredJobs expires stale jobs right away and then every interval, until ctx is done.
+// maxAge, when set, also expires the open jobs without expires_at once they were posted that long ago.
+func (s *JobsService) SweepExpiredJobs(ctx context.Context, interval, maxAge time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		// Failures are logged by ExpireJobs, the next tick retries
+		_, _ = s.ExpireJobs(ctx, maxAge)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireJobs runs one sweep and returns the number of expired jobs
+func (s *JobsService) ExpireJobs(ctx context.Context, maxAge time.Duration) (int64, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ExpireJobs")
+	defer span.End()
+
+	n, err := s.DB.ExpireJobs(ctx, time.Now(), maxAge)
+	if err != nil {
+		recordError(span, err)
+		s.log(ctx).Errorf("Could not expire jobs: %v", err)
+		return 0, fmt.Errorf("could not expire jobs: %w", err)
+	}
+	if n > 0 {
+		s.log(ctx).Infof("Expired %d jobs", n)
+	}
+	return n, nil
+}
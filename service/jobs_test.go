@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateJobDefaults(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+	draft := types.JobInput{Title: "Backend Developer", Country: "USA", Status: types.JobDraft, SalaryCurrency: "EUR", SalaryPeriod: types.PayMonth,
+		WorkMode: types.WorkRemote}
+	open := types.JobInput{Title: "Backend Developer", Country: "USA", Status: types.JobOpen, SalaryCurrency: "USD", SalaryPeriod: types.PayYear,
+		WorkMode: types.WorkOnsite}
+	mockDB.On("CreateJob", mock.Anything, open).Return(types.Job{Status: types.JobOpen}, nil).Once()
+	mockDB.On("CreateJob", mock.Anything, draft).Return(types.Job{Status: types.JobDraft}, nil).Once()
+
+	job, err := service.CreateJob(context.Background(), types.JobInput{Title: "Backend Developer", Country: "USA"})
+	assert.NoError(t, err)
+	assert.Equal(t, types.JobOpen, job.Status)
+
+	job, err = service.CreateJob(context.Background(), draft)
+	assert.NoError(t, err)
+	assert.Equal(t, types.JobDraft, job.Status)
+
+	_, err = service.CreateJob(context.Background(), types.JobInput{Title: "Backend Developer", Country: "USA", SalaryCurrency: "JPY"})
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+	mockDB.AssertExpectations(t)
+}
+
+func TestListJobsWithoutOpenStatusSkipsExternalJobs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return assert.ObjectsAreEqual([]string{types.JobClosed}, q.Statuses)
+	})).Return(internalJobs(2), nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, Statuses: []string{types.JobClosed}})
+
+	assert.NoError(t, err)
+	assert.Len(t, output.Items, 2)
+	mockDB.AssertExpectations(t)
+	mockFetcher.AssertNotCalled(t, "FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestExpireJobs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+
+	t.Run("Counts expired jobs", func(t *testing.T) {
+		mockDB := new(MockDB)
+		service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+		mockDB.On("ExpireJobs", mock.Anything, mock.AnythingOfType("time.Time"), 30*24*time.Hour).Return(int64(3), nil)
+
+		n, err := service.ExpireJobs(context.Background(), 30*24*time.Hour)
+
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), n)
+	})
+
+	t.Run("Reports failures", func(t *testing.T) {
+		mockDB := new(MockDB)
+		service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+		mockDB.On("ExpireJobs", mock.Anything, mock.Anything, time.Duration(0)).Return(int64(0), fmt.Errorf("db down"))
+
+		_, err := service.ExpireJobs(context.Background(), 0)
+
+		assert.ErrorContains(t, err, "could not expire jobs: db down")
+	})
+}
+
+func TestSweepExpiredJobs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+	// The sweeper keeps running after a failure and stops with its context
+	ctx, cancel := context.WithCancel(context.Background())
+	sweeps := 0
+	mockDB.On("ExpireJobs", mock.Anything, mock.Anything, time.Duration(0)).Return(int64(0), fmt.Errorf("db down")).Once()
+	mockDB.On("ExpireJobs", mock.Anything, mock.Anything, time.Duration(0)).Return(int64(1), nil).Run(func(mock.Arguments) {
+		sweeps++
+		if sweeps == 2 {
+			cancel()
+		}
+	})
+
+	done := make(chan struct{})
+	go func() {
+		service.SweepExpiredJobs(ctx, time.Millisecond, 0)
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the sweeper did not stop")
+	}
+	mockDB.AssertNumberOfCalls(t, "ExpireJobs", 3)
+}
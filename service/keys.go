@@ -0,0 +1,192 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"jobs/apperr"
+	d "jobs/db"
+	"jobs/logging"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// APIKeyPrefix starts every issued API key so that leaked keys are easy to spot
+const APIKeyPrefix = "jsk_"
+
+var (
+	// ErrInvalidAPIKey is returned for unknown, expired or revoked keys
+	ErrInvalidAPIKey = apperr.Unauthenticated("invalid_api_key", "Invalid API key")
+	// ErrAPIKeyNotFound is returned when revoking or rotating an unknown key
+	ErrAPIKeyNotFound = apperr.NotFound("api_key_not_found", "API key not found")
+)
+
+// KeyManager issues, rotates and authenticates API keys
+type KeyManager interface {
+	IssueAPIKey(ctx context.Context, input types.IssueAPIKeyInput) (types.IssueAPIKeyOutput, error)
+	ListAPIKeys(ctx context.Context) ([]types.APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID) error
+	RotateAPIKey(ctx context.Context, id uuid.UUID, gracePeriod time.Duration) (types.IssueAPIKeyOutput, error)
+	AuthenticateAPIKey(ctx context.Context, key string) (types.APIKey, error)
+}
+
+// KeysService implements the KeyManager interface
+type KeysService struct {
+	DB     d.APIKeyStore
+	Logger *zap.Logger
+	now    func() time.Time
+}
+
+// NewKeysService creates a new instance of KeysService
+func NewKeysService(logger *zap.Logger, store d.APIKeyStore) *KeysService {
+	return &KeysService{Logger: logger, DB: store, now: time.Now}
+}
+
+// IssueAPIKey creates a new key; the plaintext is only part of this response
+func (s *KeysService) IssueAPIKey(ctx context.Context, input types.IssueAPIKeyInput) (types.IssueAPIKeyOutput, error) {
+	ctx, span := tracer.Start(ctx, "KeysService.IssueAPIKey")
+	defer span.End()
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		recordError(span, err)
+		return types.IssueAPIKeyOutput{}, err
+	}
+	key := types.APIKey{
+		Name:      input.Name,
+		Prefix:    prefix,
+		Scopes:    input.Scopes,
+		ExpiresAt: input.ExpiresAt,
+	}
+	if err := s.DB.CreateAPIKey(ctx, &key, HashToken(plain)); err != nil {
+		recordError(span, err)
+		return types.IssueAPIKeyOutput{}, fmt.Errorf("could not store API key: %w", err)
+	}
+
+	s.log(ctx).Infof("Issued API key %s (%s) with scopes %v", key.ID, key.Prefix, key.Scopes)
+	return types.IssueAPIKeyOutput{APIKey: key, Key: plain}, nil
+}
+
+// ListAPIKeys returns every key without secrets
+func (s *KeysService) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	keys, err := s.DB.ListAPIKeys(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list API keys: %w", err)
+	}
+	return keys, nil
+}
+
+// RevokeAPIKey disables a key immediately
+func (s *KeysService) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	if err := s.DB.RevokeAPIKey(ctx, id); err != nil {
+		if errors.Is(err, d.ErrAPIKeyNotFound) {
+			return ErrAPIKeyNotFound
+		}
+		return fmt.Errorf("could not revoke API key: %w", err)
+	}
+	s.log(ctx).Infof("Revoked API key %s", id)
+	return nil
+}
+
+// RotateAPIKey issues a replacement with the same name and scopes.
+// The old key keeps working for gracePeriod so that clients can switch over.
+func (s *KeysService) RotateAPIKey(ctx context.Context, id uuid.UUID, gracePeriod time.Duration) (types.IssueAPIKeyOutput, error) {
+	ctx, span := tracer.Start(ctx, "KeysService.RotateAPIKey")
+	defer span.End()
+
+	plain, prefix, err := generateAPIKey()
+	if err != nil {
+		recordError(span, err)
+		return types.IssueAPIKeyOutput{}, err
+	}
+	key := types.APIKey{Prefix: prefix}
+	if err := s.DB.RotateAPIKey(ctx, id, s.now().UTC().Add(gracePeriod), &key, HashToken(plain)); err != nil {
+		if errors.Is(err, d.ErrAPIKeyNotFound) {
+			return types.IssueAPIKeyOutput{}, ErrAPIKeyNotFound
+		}
+		recordError(span, err)
+		return types.IssueAPIKeyOutput{}, fmt.Errorf("could not rotate API key: %w", err)
+	}
+
+	s.log(ctx).Infof("Rotated API key %s into %s, grace period %s", id, key.ID, gracePeriod)
+	return types.IssueAPIKeyOutput{APIKey: key, Key: plain}, nil
+}
+
+// AuthenticateAPIKey returns the key matching the plaintext if it is still valid
+func (s *KeysService) AuthenticateAPIKey(ctx context.Context, plain string) (types.APIKey, error) {
+	if !strings.HasPrefix(plain, APIKeyPrefix) {
+		return types.APIKey{}, ErrInvalidAPIKey
+	}
+	key, err := s.DB.UseAPIKey(ctx, HashToken(plain))
+	if err != nil {
+		if errors.Is(err, d.ErrAPIKeyNotFound) {
+			return types.APIKey{}, ErrInvalidAPIKey
+		}
+		return types.APIKey{}, fmt.Errorf("could not look up API key: %w", err)
+	}
+
+	now := s.now().UTC()
+	if key.RevokedAt != nil || (key.ExpiresAt != nil && !now.Before(*key.ExpiresAt)) {
+		return types.APIKey{}, ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// BootstrapAdminKey makes sure the given plaintext key exists with the admin scope,
+// so that the first keys can be issued through the admin endpoints
+func (s *KeysService) BootstrapAdminKey(ctx context.Context, plain string) error {
+	if !strings.HasPrefix(plain, APIKeyPrefix) {
+		return fmt.Errorf("bootstrap API key must start with %q", APIKeyPrefix)
+	}
+	_, err := s.DB.UseAPIKey(ctx, HashToken(plain))
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, d.ErrAPIKeyNotFound) {
+		return fmt.Errorf("could not look up bootstrap API key: %w", err)
+	}
+	key := types.APIKey{
+		Name:   "bootstrap-admin",
+		Prefix: plain[:min(len(plain), len(APIKeyPrefix)+8)],
+		Scopes: []string{types.ScopeAdmin},
+	}
+	if err := s.DB.CreateAPIKey(ctx, &key, HashToken(plain)); err != nil {
+		return fmt.Errorf("could not store bootstrap API key: %w", err)
+	}
+	s.log(ctx).Infof("Created bootstrap admin API key %s", key.ID)
+	return nil
+}
+
+// HasScope reports whether the key grants scope; the admin scope grants every scope
+func HasScope(key types.APIKey, scope string) bool {
+	return slices.Contains(key.Scopes, scope) || slices.Contains(key.Scopes, types.ScopeAdmin)
+}
+
+// HashToken returns the hex encoded SHA-256 of a plaintext API key or login token
+func HashToken(plain string) string {
+	sum := sha256.Sum256([]byte(plain))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey returns a random key and its public prefix
+func generateAPIKey() (plain, prefix string, err error) {
+	token, err := randomToken()
+	if err != nil {
+		return "", "", fmt.Errorf("could not generate API key: %w", err)
+	}
+	plain = APIKeyPrefix + token
+	return plain, plain[:len(APIKeyPrefix)+8], nil
+}
+
+// log returns the request-scoped logger, falling back to the service logger
+func (s *KeysService) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, s.Logger).Sugar()
+}
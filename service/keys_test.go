@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	d "jobs/db"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockAPIKeyStore struct {
+	mock.Mock
+}
+
+func (m *MockAPIKeyStore) CreateAPIKey(ctx context.Context, key *types.APIKey, hash string) error {
+	args := m.Called(ctx, key, hash)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyStore) UseAPIKey(ctx context.Context, hash string) (types.APIKey, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(types.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyStore) ListAPIKeys(ctx context.Context) ([]types.APIKey, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]types.APIKey), args.Error(1)
+}
+
+func (m *MockAPIKeyStore) RevokeAPIKey(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockAPIKeyStore) RotateAPIKey(ctx context.Context, oldID uuid.UUID, oldExpiresAt time.Time, key *types.APIKey, hash string) error {
+	args := m.Called(ctx, oldID, oldExpiresAt, key, hash)
+	return args.Error(0)
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	now := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		plain         string
+		stored        types.APIKey
+		storeErr      error
+		expectedError error
+	}{
+		{name: "Valid key", plain: "jsk_valid", stored: types.APIKey{Scopes: []string{types.ScopeJobsRead}, ExpiresAt: &future}},
+		{name: "Wrong prefix", plain: "valid", expectedError: ErrInvalidAPIKey},
+		{name: "Unknown key", plain: "jsk_unknown", storeErr: d.ErrAPIKeyNotFound, expectedError: ErrInvalidAPIKey},
+		{name: "Expired key", plain: "jsk_expired", stored: types.APIKey{ExpiresAt: &past}, expectedError: ErrInvalidAPIKey},
+		{name: "Revoked key", plain: "jsk_revoked", stored: types.APIKey{RevokedAt: &past}, expectedError: ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			store := new(MockAPIKeyStore)
+			store.On("UseAPIKey", mock.Anything, HashToken(tt.plain)).Return(tt.stored, tt.storeErr)
+			s := NewKeysService(l, store)
+			s.now = func() time.Time { return now }
+
+			key, err := s.AuthenticateAPIKey(context.Background(), tt.plain)
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.stored, key)
+		})
+	}
+}
+
+func TestIssueAndRotateAPIKey(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	now := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	oldID := uuid.New()
+
+	store := new(MockAPIKeyStore)
+	store.On("CreateAPIKey", mock.Anything, mock.AnythingOfType("*types.APIKey"), mock.AnythingOfType("string")).Return(nil)
+	store.On("RotateAPIKey", mock.Anything, oldID, now.Add(time.Hour), mock.AnythingOfType("*types.APIKey"), mock.AnythingOfType("string")).Return(nil)
+	s := NewKeysService(l, store)
+	s.now = func() time.Time { return now }
+
+	issued, err := s.IssueAPIKey(context.Background(), types.IssueAPIKeyInput{Name: "ci", Scopes: []string{types.ScopeSubscribe}})
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(issued.Key, APIKeyPrefix))
+	assert.True(t, strings.HasPrefix(issued.Key, issued.Prefix))
+	assert.Equal(t, HashToken(issued.Key), store.Calls[0].Arguments.String(2))
+
+	rotated, err := s.RotateAPIKey(context.Background(), oldID, time.Hour)
+	assert.NoError(t, err)
+	assert.NotEqual(t, issued.Key, rotated.Key)
+	store.AssertExpectations(t)
+}
+
+func TestHasScope(t *testing.T) {
+	assert.True(t, HasScope(types.APIKey{Scopes: []string{types.ScopeJobsRead}}, types.ScopeJobsRead))
+	assert.False(t, HasScope(types.APIKey{Scopes: []string{types.ScopeJobsRead}}, types.ScopeSubscribe))
+	assert.True(t, HasScope(types.APIKey{Scopes: []string{types.ScopeAdmin}}, types.ScopeSubscribe))
+}
@@ -0,0 +1,24 @@
+package service
+
+import (
+	"strings"
+
+	"jobs/types"
+)
+
+// matchesLocation reports whether a job matches one of the location preferences, as GetInternalJobs does.
+// Any job matches when there are none; a preference constraining a field the job lacks does not match it.
+func matchesLocation(locations []types.LocationPreference, job types.Job) bool {
+	if len(locations) == 0 {
+		return true
+	}
+	for _, l := range locations {
+		if (l.WorkMode == "" || l.WorkMode == job.WorkMode) &&
+			(l.Country == "" || l.Country == job.Country || job.Country == types.Wildcard) &&
+			(l.Region == "" || strings.EqualFold(l.Region, job.Region)) &&
+			(l.City == "" || strings.EqualFold(l.City, job.City)) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestMatchesLocation(t *testing.T) {
+	preferences := []types.LocationPreference{
+		{WorkMode: types.WorkRemote},
+		{WorkMode: types.WorkHybrid, City: "Buenos Aires"},
+	}
+
+	tests := []struct {
+		name     string
+		job      types.Job
+		expected bool
+	}{
+		{name: "Remote anywhere", job: types.Job{Country: "UK", WorkMode: types.WorkRemote}, expected: true},
+		{name: "Hybrid in the city", job: types.Job{Country: "Argentina", City: "buenos aires", WorkMode: types.WorkHybrid}, expected: true},
+		{name: "Hybrid elsewhere", job: types.Job{Country: "Argentina", City: "Córdoba", WorkMode: types.WorkHybrid}},
+		{name: "Onsite in the city", job: types.Job{Country: "Argentina", City: "Buenos Aires", WorkMode: types.WorkOnsite}},
+		{name: "Unknown work mode", job: types.Job{Country: "Argentina", City: "Buenos Aires"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchesLocation(preferences, tt.job))
+		})
+	}
+	assert.True(t, matchesLocation(nil, types.Job{}))
+}
+
+func TestListJobsFiltersLocations(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	locations := []types.LocationPreference{{WorkMode: types.WorkRemote}, {WorkMode: types.WorkHybrid, City: "Buenos Aires"}}
+	sub := types.Subscriber{ID: subscriber.ID, JobTitles: []string{"Backend Developer"}, Countries: []string{"Argentina"}, Locations: locations}
+	mockDB.On("GetSubscriber", mock.Anything, sub.ID).Return(sub, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, sub.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return assert.ObjectsAreEqual(locations, q.Locations)
+	})).Return([]types.Job{}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(0), int64(0), "Argentina").
+		Return([]types.ExternalJob{
+			{Title: "Remote", WorkMode: types.WorkRemote},
+			{Title: "Hybrid", WorkMode: types.WorkHybrid, City: "Buenos Aires"},
+			{Title: "Onsite", WorkMode: types.WorkOnsite, City: "Buenos Aires"},
+			{Title: "Unknown"},
+		}, nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: sub.ID})
+
+	assert.NoError(t, err)
+	var titles []string
+	for _, job := range output.Items {
+		titles = append(titles, job.Title)
+	}
+	assert.Equal(t, []string{"Remote", "Hybrid"}, titles)
+	mockDB.AssertExpectations(t)
+	mockFetcher.AssertExpectations(t)
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"math"
+
+	"jobs/apperr"
+	"jobs/currency"
+	"jobs/types"
+)
+
+// ErrUnsupportedCurrency rejects salaries in a currency missing from the exchange-rate table
+var ErrUnsupportedCurrency = apperr.Validation("unsupported_currency", "The salary currency has no exchange rate")
+
+// rates returns the configured exchange rates, falling back to the embedded ones
+func (s *JobsService) rates() currency.Rates {
+	if s.Rates == nil {
+		return currency.Default()
+	}
+	return s.Rates
+}
+
+// checkCurrency fails with ErrUnsupportedCurrency unless code is empty or has an exchange rate
+func (s *JobsService) checkCurrency(code string) error {
+	if code == "" {
+		return nil
+	}
+	if _, ok := s.rates().Rate(code, code); !ok {
+		return ErrUnsupportedCurrency.WithMessage("The salary currency %s has no exchange rate", code)
+	}
+	return nil
+}
+
+// salaryUnit defaults the currency and pay period of a salary
+func salaryUnit(currency, period string) (string, string) {
+	if currency == "" {
+		currency = types.DefaultCurrency
+	}
+	if period == "" {
+		period = types.DefaultPayPeriod
+	}
+	return currency, period
+}
+
+// salaryFactors returns, for every known currency and pay period, the factor converting a salary of that unit
+// to currency per period. The units are keyed like "EUR/month".
+func (s *JobsService) salaryFactors(currency, period string) map[string]float64 {
+	rates := s.rates()
+	factors := map[string]float64{}
+	for _, from := range rates.Currencies() {
+		rate, ok := rates.Rate(from, currency)
+		if !ok {
+			continue
+		}
+		for _, fromPeriod := range types.Vocabularies["pay_period"] {
+			factors[from+"/"+fromPeriod] = rate * types.PeriodsPerYear[fromPeriod] / types.PeriodsPerYear[period]
+		}
+	}
+	return factors
+}
+
+// providerSalaries converts the query salary bounds to the yearly salaries in the currency of country served by the
+// external provider, widening them so that rounding never drops a job. ok is false when the country currency is unknown.
+func providerSalaries(query types.JobQuery, country string) (minSalary, maxSalary int64, ok bool) {
+	factor, ok := query.SalaryFactors[types.CountryCurrencies[country]+"/"+types.PayYear]
+	if !ok || factor <= 0 {
+		return 0, 0, false
+	}
+	if query.SalaryMin > 0 {
+		minSalary = int64(math.Floor(float64(query.SalaryMin) / factor))
+	}
+	if query.SalaryMax > 0 {
+		maxSalary = int64(math.Ceil(float64(query.SalaryMax) / factor))
+	}
+	return minSalary, maxSalary, true
+}
+
+// matchesSalary applies the salary filters of query to a job, as GetInternalJobs does
+func matchesSalary(query types.JobQuery, job types.Job) bool {
+	if query.SalaryMin == 0 && query.SalaryMax == 0 {
+		return true
+	}
+	factor, ok := query.SalaryFactors[job.SalaryCurrency+"/"+job.SalaryPeriod]
+	if !ok {
+		return false
+	}
+	top := job.SalaryMin
+	if job.SalaryMax != nil {
+		top = *job.SalaryMax
+	}
+	if query.SalaryMin > 0 && float64(top)*factor < float64(query.SalaryMin) {
+		return false
+	}
+	return query.SalaryMax == 0 || float64(job.SalaryMin)*factor <= float64(query.SalaryMax)
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"jobs/currency"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestSalaryFactors(t *testing.T) {
+	service := &JobsService{Rates: &currency.Table{Base: "USD", Rates: map[string]float64{"USD": 1, "EUR": 0.5}}}
+
+	factors := service.salaryFactors("EUR", types.PayMonth)
+
+	assert.Len(t, factors, 10)
+	assert.InDelta(t, 0.5/12, factors["USD/year"], 1e-9)
+	assert.InDelta(t, 1, factors["EUR/month"], 1e-9)
+	assert.InDelta(t, 2080.0/12, factors["EUR/hour"], 1e-9)
+}
+
+func TestMatchesSalary(t *testing.T) {
+	top := int64(5000)
+	query := types.JobQuery{SalaryMin: 3000, SalaryMax: 4000, SalaryFactors: map[string]float64{"USD/year": 1, "EUR/year": 2}}
+
+	tests := []struct {
+		name     string
+		job      types.Job
+		expected bool
+	}{
+		{name: "Within the range", job: types.Job{SalaryMin: 3500, SalaryCurrency: "USD", SalaryPeriod: types.PayYear}, expected: true},
+		{name: "Below the minimum", job: types.Job{SalaryMin: 2000, SalaryCurrency: "USD", SalaryPeriod: types.PayYear}},
+		{name: "Range reaching the minimum", job: types.Job{SalaryMin: 2000, SalaryMax: &top, SalaryCurrency: "USD", SalaryPeriod: types.PayYear}, expected: true},
+		{name: "Converted above the maximum", job: types.Job{SalaryMin: 2500, SalaryCurrency: "EUR", SalaryPeriod: types.PayYear}},
+		{name: "Unknown unit", job: types.Job{SalaryMin: 3500, SalaryCurrency: "JPY", SalaryPeriod: types.PayYear}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, matchesSalary(query, tt.job))
+		})
+	}
+	assert.True(t, matchesSalary(types.JobQuery{}, types.Job{SalaryCurrency: "JPY"}))
+}
+
+func TestListJobsConvertsSalaries(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	// 5000 to 6000 EUR per month are 51521.7 to 61826.1 GBP per year
+	sub := types.Subscriber{ID: subscriber.ID, JobTitles: []string{"Backend Developer"}, Countries: []string{"UK"}, SalaryMin: 5000,
+		SalaryCurrency: "EUR", SalaryPeriod: types.PayMonth}
+	mockDB.On("GetSubscriber", mock.Anything, sub.ID).Return(sub, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, sub.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return q.SalaryCurrency == "EUR" && q.SalaryPeriod == types.PayMonth && q.SalaryMax == 6000 && len(q.SalaryFactors) > 0
+	})).Return([]types.Job{}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(51521), int64(61827), "UK").
+		Return([]types.ExternalJob{{Title: "Junior", Salary: 40000}, {Title: "Senior", Salary: 60000}, {Title: "Lead", Salary: 80000}}, nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: sub.ID, SalaryMax: 6000})
+
+	assert.NoError(t, err)
+	if assert.Len(t, output.Items, 1) {
+		assert.Equal(t, "Senior", output.Items[0].Title)
+		assert.Equal(t, "GBP", output.Items[0].SalaryCurrency)
+	}
+	mockDB.AssertExpectations(t)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestSaveSubscriberRejectsUnknownCurrency(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	service := NewJobsService(l, new(MockDB), new(MockExternalJobsFetcher))
+
+	_, _, err := service.SaveSubscriber(context.Background(), types.SubscriberInput{Email: "jane@example.com", SalaryCurrency: "JPY"})
+
+	assert.ErrorIs(t, err, ErrUnsupportedCurrency)
+}
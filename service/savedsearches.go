@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	d "jobs/db"
+	"jobs/types"
+
+	"github.com/google/uuid"
+)
+
+// ErrSavedSearchNotFound is returned when no saved search has the given ID
+var ErrSavedSearchNotFound = d.ErrSavedSearchNotFound
+
+// CreateSavedSearch records a saved search of a subscriber
+func (s *JobsService) CreateSavedSearch(ctx context.Context, subscriberID uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.CreateSavedSearch")
+	defer span.End()
+
+	search, err := s.DB.CreateSavedSearch(ctx, subscriberID, savedSearchDefaults(input))
+	if err != nil {
+		recordError(span, err)
+		return types.SavedSearch{}, fmt.Errorf("could not create saved search: %w", err)
+	}
+	return search, nil
+}
+
+// GetSavedSearch returns a saved search
+func (s *JobsService) GetSavedSearch(ctx context.Context, id uuid.UUID) (types.SavedSearch, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.GetSavedSearch")
+	defer span.End()
+
+	search, err := s.DB.GetSavedSearch(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return types.SavedSearch{}, err
+	}
+	return search, nil
+}
+
+// ListSavedSearches returns the saved searches of a subscriber by name
+func (s *JobsService) ListSavedSearches(ctx context.Context, subscriberID uuid.UUID) ([]types.SavedSearch, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ListSavedSearches")
+	defer span.End()
+
+	searches, err := s.DB.ListSavedSearches(ctx, subscriberID)
+	if err != nil {
+		recordError(span, err)
+		return nil, fmt.Errorf("could not list saved searches: %w", err)
+	}
+	return searches, nil
+}
+
+// ReplaceSavedSearch overwrites every field of a saved search but its subscriber
+func (s *JobsService) ReplaceSavedSearch(ctx context.Context, id uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ReplaceSavedSearch")
+	defer span.End()
+
+	search, err := s.DB.ReplaceSavedSearch(ctx, id, savedSearchDefaults(input))
+	if err != nil {
+		recordError(span, err)
+		return types.SavedSearch{}, fmt.Errorf("could not replace saved search: %w", err)
+	}
+	return search, nil
+}
+
+// DeleteSavedSearch removes a saved search
+func (s *JobsService) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "JobsService.DeleteSavedSearch")
+	defer span.End()
+
+	if err := s.DB.DeleteSavedSearch(ctx, id); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not delete saved search: %w", err)
+	}
+	return nil
+}
+
+// ListSavedSearchJobs lists the jobs matching the filters of a saved search like ListJobs. Since the last seen job,
+// the search high-water mark applies rather than the subscriber one.
+func (s *JobsService) ListSavedSearchJobs(ctx context.Context, search types.SavedSearch, cursor string, limit int, sinceLastSeen bool) (types.JobList, error) {
+	query := types.JobQuery{
+		SubscriberID:  search.SubscriberID,
+		JobTitles:     search.JobTitles,
+		Countries:     search.Countries,
+		SalaryMin:     search.SalaryMin,
+		Skills:        search.Skills,
+		Text:          search.Keywords,
+		SinceLastSeen: sinceLastSeen,
+		Cursor:        cursor,
+		Limit:         limit,
+	}
+	if sinceLastSeen {
+		query.LastSeenAt = markOrZero(search.LastSeenAt)
+	}
+	return s.ListJobs(ctx, query)
+}
+
+// savedSearchDefaults notifies by email every day unless the input says otherwise
+func savedSearchDefaults(input types.SavedSearchInput) types.SavedSearchInput {
+	if input.Channel == "" {
+		input.Channel = types.ChannelEmail
+	}
+	if input.Frequency == "" {
+		input.Frequency = types.FrequencyDaily
+	}
+	return input
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestCreateSavedSearchDefaults(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	service := NewJobsService(l, mockDB, new(MockExternalJobsFetcher))
+
+	owner := uuid.New()
+	expected := types.SavedSearchInput{Name: "Go", Channel: types.ChannelEmail, Frequency: types.FrequencyDaily}
+	mockDB.On("CreateSavedSearch", mock.Anything, owner, expected).Return(types.SavedSearch{Name: "Go"}, nil)
+
+	_, err := service.CreateSavedSearch(context.Background(), owner, types.SavedSearchInput{Name: "Go"})
+
+	assert.NoError(t, err)
+	mockDB.AssertExpectations(t)
+}
+
+func TestListSavedSearchJobs(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	search := types.SavedSearch{SubscriberID: subscriber.ID, Countries: []string{"UK"}, Skills: []string{"go"}, Keywords: "payments"}
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return assert.ObjectsAreEqual(subscriber.JobTitles, q.JobTitles) && assert.ObjectsAreEqual([]string{"UK"}, q.Countries) &&
+			assert.ObjectsAreEqual([]string{"go"}, q.Skills) && q.Text == "payments"
+	})).Return([]types.Job{}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", mock.Anything, mock.Anything, "UK").
+		Return([]types.ExternalJob{
+			{Title: "Go payments", Salary: 3000, Skills: types.Skills{Skills: []types.Skill{{Name: "Go"}}}},
+			{Title: "Java payments", Salary: 3000, Skills: types.Skills{Skills: []types.Skill{{Name: "Java"}}}},
+		}, nil)
+
+	output, err := service.ListSavedSearchJobs(context.Background(), search, "", 10, false)
+
+	assert.NoError(t, err)
+	if assert.Len(t, output.Items, 1) {
+		assert.Equal(t, "Go payments", output.Items[0].Title)
+	}
+	mockDB.AssertExpectations(t)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"jobs/types"
+)
+
+// stopWords are left out of searches, like the english text search configuration of Postgres does
+var stopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "are": true, "as": true, "at": true, "be": true, "by": true, "for": true,
+	"from": true, "in": true, "is": true, "it": true, "of": true, "on": true, "or": true, "the": true, "to": true,
+	"with": true,
+}
+
+// searchQuery is a parsed text search: every term must match and no excluded term may
+type searchQuery struct {
+	terms    []string
+	excluded []string
+}
+
+// parseSearch reads the websearch syntax used for internal jobs in its simplest form: words, and -word to exclude
+func parseSearch(text string) searchQuery {
+	var q searchQuery
+	for _, field := range strings.Fields(text) {
+		word, exclude := strings.CutPrefix(field, "-")
+		for _, term := range tokenize(word) {
+			if exclude {
+				q.excluded = append(q.excluded, term)
+			} else {
+				q.terms = append(q.terms, term)
+			}
+		}
+	}
+	return q
+}
+
+// tokenize lowercases text, splits it on anything but letters and digits, drops stop words and
+// trims plurals, an approximation of the english stemmer of Postgres
+func tokenize(text string) []string {
+	var tokens []string
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if stopWords[word] {
+			continue
+		}
+		tokens = append(tokens, stem(word))
+	}
+	return tokens
+}
+
+func stem(word string) string {
+	switch {
+	case len(word) > 4 && strings.HasSuffix(word, "ies"):
+		return strings.TrimSuffix(word, "ies") + "y"
+	case len(word) > 3 && strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss"):
+		return strings.TrimSuffix(word, "s")
+	}
+	return word
+}
+
+// Weights of the title and body words in the rank of a job, the defaults of ts_rank for the A and B weights the
+// jobs search column gives the title and description
+const (
+	titleWeight = 1.0
+	bodyWeight  = 0.4
+)
+
+// rank weighs the occurrences of the query terms in the title and body tokens of a job, 0 when a term is missing
+// or an excluded one is present. As in Postgres, a query made of stop words only matches nothing.
+func (q searchQuery) rank(title, body []string) float64 {
+	if len(q.terms) == 0 && len(q.excluded) == 0 {
+		return 0
+	}
+	counts := map[string]float64{}
+	for _, token := range title {
+		counts[token] += titleWeight
+	}
+	for _, token := range body {
+		counts[token] += bodyWeight
+	}
+	for _, term := range q.excluded {
+		if counts[term] > 0 {
+			return 0
+		}
+	}
+	if len(q.terms) == 0 {
+		return 1
+	}
+	rank := 0.0
+	for _, term := range q.terms {
+		if counts[term] == 0 {
+			return 0
+		}
+		rank += counts[term]
+	}
+	return rank
+}
+
+// searchExternalJobs keeps the external jobs matching text in their title or skills, the most relevant first and the
+// others in their order, and highlights the matching title words
+func searchExternalJobs(text string, jobs []types.Job) []types.Job {
+	if strings.TrimSpace(text) == "" {
+		return jobs
+	}
+	q := parseSearch(text)
+	type ranked struct {
+		job  types.Job
+		rank float64
+	}
+	var matched []ranked
+	for _, job := range jobs {
+		if rank := q.rank(tokenize(job.Title), tokenize(strings.Join(job.Skills, " "))); rank > 0 {
+			job.Snippet = q.highlight(job.Title)
+			matched = append(matched, ranked{job: job, rank: rank})
+		}
+	}
+	sort.SliceStable(matched, func(a, b int) bool { return matched[a].rank > matched[b].rank })
+
+	result := make([]types.Job, len(matched))
+	for i, m := range matched {
+		result[i] = m.job
+	}
+	return result
+}
+
+// highlight wraps the words of text matching a query term in <mark> tags, like ts_headline does
+func (q searchQuery) highlight(text string) string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		for _, token := range tokenize(word) {
+			if slices.Contains(q.terms, token) {
+				words[i] = "<mark>" + word + "</mark>"
+				break
+			}
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// matchesSkills reports whether an external job lists one of the skills, ignoring case. Every job matches no skills.
+func matchesSkills(skills []string, job types.Job) bool {
+	if len(skills) == 0 {
+		return true
+	}
+	for _, skill := range skills {
+		for _, listed := range job.Skills {
+			if strings.EqualFold(skill, listed) {
+				return true
+			}
+		}
+	}
+	return false
+}
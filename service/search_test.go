@@ -0,0 +1,51 @@
+package service
+
+import (
+	"testing"
+
+	"jobs/types"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"senior", "go", "developer", "api"}, tokenize("Senior Go developers for the APIs"))
+	assert.Equal(t, []string{"company", "class"}, tokenize("Companies, class"))
+	assert.Empty(t, tokenize("and the of"))
+}
+
+func TestSearchExternalJobs(t *testing.T) {
+	jobs := []types.Job{
+		{Title: "Frontend Developer", Skills: []string{"React", "CSS"}},
+		{Title: "Backend Developer", Skills: []string{"Go", "PostgreSQL"}},
+		{Title: "Go Developer", Skills: []string{"Go", "gRPC"}},
+	}
+
+	tests := []struct {
+		name             string
+		text             string
+		expectedTitles   []string
+		expectedSnippets []string
+	}{
+		{name: "No search keeps every job", text: "  ", expectedTitles: []string{"Frontend Developer", "Backend Developer", "Go Developer"}, expectedSnippets: []string{"", "", ""}},
+		{name: "Skills match", text: "react", expectedTitles: []string{"Frontend Developer"}, expectedSnippets: []string{"Frontend Developer"}},
+		{name: "More occurrences rank first", text: "go", expectedTitles: []string{"Go Developer", "Backend Developer"}, expectedSnippets: []string{"<mark>Go</mark> Developer", "Backend Developer"}},
+		{name: "Every word must match", text: "go developers", expectedTitles: []string{"Go Developer", "Backend Developer"}, expectedSnippets: []string{"<mark>Go</mark> <mark>Developer</mark>", "Backend <mark>Developer</mark>"}},
+		{name: "Excluded words", text: "developer -grpc", expectedTitles: []string{"Frontend Developer", "Backend Developer"}, expectedSnippets: []string{"Frontend <mark>Developer</mark>", "Backend <mark>Developer</mark>"}},
+		{name: "Stop words only match nothing", text: "the", expectedTitles: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var titles, snippets []string
+			for _, job := range searchExternalJobs(tt.text, jobs) {
+				titles = append(titles, job.Title)
+				snippets = append(snippets, job.Snippet)
+			}
+			assert.Equal(t, tt.expectedTitles, titles)
+			if tt.expectedSnippets != nil {
+				assert.Equal(t, tt.expectedSnippets, snippets)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"jobs/apperr"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrSinceLastSeenUnavailable is returned for since=last_seen lists while external jobs are fetched on every request,
+// without the first-seen time they would never be listed
+var ErrSinceLastSeenUnavailable = apperr.InvalidParameter("since", "requires external job ingestion")
+
+// AcknowledgeJobs advances the high-water mark of a subscriber, the jobs first seen until then are no longer new
+func (s *JobsService) AcknowledgeJobs(ctx context.Context, subscriberID uuid.UUID, input types.AcknowledgeInput) (types.SeenMark, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.AcknowledgeJobs")
+	defer span.End()
+
+	mark, err := s.DB.AcknowledgeSubscriberJobs(ctx, subscriberID, acknowledgedUntil(input, time.Now()))
+	if err != nil {
+		recordError(span, err)
+		return types.SeenMark{}, fmt.Errorf("could not acknowledge jobs: %w", err)
+	}
+	span.SetAttributes(attribute.String("jobs.last_seen_at", mark.Format(time.RFC3339Nano)))
+	return types.SeenMark{LastSeenAt: mark}, nil
+}
+
+// AcknowledgeSavedSearchJobs advances the high-water mark of a saved search like AcknowledgeJobs
+func (s *JobsService) AcknowledgeSavedSearchJobs(ctx context.Context, id uuid.UUID, input types.AcknowledgeInput) (types.SeenMark, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.AcknowledgeSavedSearchJobs")
+	defer span.End()
+
+	mark, err := s.DB.AcknowledgeSavedSearchJobs(ctx, id, acknowledgedUntil(input, time.Now()))
+	if err != nil {
+		recordError(span, err)
+		return types.SeenMark{}, fmt.Errorf("could not acknowledge saved search jobs: %w", err)
+	}
+	span.SetAttributes(attribute.String("jobs.last_seen_at", mark.Format(time.RFC3339Nano)))
+	return types.SeenMark{LastSeenAt: mark}, nil
+}
+
+// acknowledgedUntil is the mark an acknowledgement moves to. It is capped at now so that the jobs first seen
+// after the acknowledgement are never skipped.
+func acknowledgedUntil(input types.AcknowledgeInput, now time.Time) time.Time {
+	if input.SeenUntil == nil || input.SeenUntil.After(now) {
+		return now
+	}
+	return *input.SeenUntil
+}
+
+// matchesLastSeen reports whether an ingested external job was first seen after the query high-water mark, every job
+// matches outside of since=last_seen lists
+func matchesLastSeen(query types.JobQuery, job types.Job) bool {
+	if !query.SinceLastSeen {
+		return true
+	}
+	if job.FirstSeenAt == nil {
+		return false
+	}
+	return query.LastSeenAt == nil || job.FirstSeenAt.After(*query.LastSeenAt)
+}
+
+// seenUntil is the mark acknowledging the jobs of a since=last_seen list: the newest first-seen time among them,
+// or the current mark when none is new
+func seenUntil(jobs []types.Job, mark *time.Time) *time.Time {
+	var newest *time.Time
+	for _, job := range jobs {
+		if job.FirstSeenAt != nil && (newest == nil || job.FirstSeenAt.After(*newest)) {
+			newest = job.FirstSeenAt
+		}
+	}
+	if newest == nil && mark != nil && !mark.IsZero() {
+		newest = mark
+	}
+	return newest
+}
+
+// markOrZero returns the high-water mark, the zero time before anything was acknowledged so that every job is new
+func markOrZero(mark *time.Time) *time.Time {
+	if mark == nil {
+		return &time.Time{}
+	}
+	return mark
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListJobsSinceLastSeen(t *testing.T) {
+	mark := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	newest := mark.Add(2 * time.Hour)
+	firstSeen := mark.Add(30 * time.Minute)
+	ingested := types.Job{Source: types.SourceExternal, Title: "Backend Developer", Country: "USA", Company: "Acme", SalaryMin: 3000,
+		SalaryCurrency: "USD", SalaryPeriod: types.PayYear, FirstSeenAt: &firstSeen}
+
+	tests := []struct {
+		name              string
+		internalJobs      []types.Job
+		externalJobs      []types.Job
+		expectedJobs      int
+		expectedSeenUntil *time.Time
+	}{
+		{
+			name:              "New jobs acknowledge until the newest",
+			internalJobs:      internalJobs(2),
+			externalJobs:      []types.Job{ingested},
+			expectedJobs:      3,
+			expectedSeenUntil: &newest,
+		},
+		{
+			name:              "Without new jobs the mark stays",
+			internalJobs:      []types.Job{},
+			externalJobs:      []types.Job{},
+			expectedSeenUntil: &mark,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, _ := setup.SetupLogger()
+			mockDB := new(MockDB)
+			mockFetcher := new(MockExternalJobsFetcher)
+			service := NewJobsService(l, mockDB, mockFetcher)
+			service.StoredExternalJobs = true
+
+			for i := range tt.internalJobs {
+				firstSeen := mark.Add(time.Duration(i+1) * time.Hour)
+				tt.internalJobs[i].FirstSeenAt = &firstSeen
+			}
+			sub := subscriber
+			sub.LastSeenAt = &mark
+			mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(sub, nil)
+			mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil).Maybe()
+			mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+				return q.SinceLastSeen && q.LastSeenAt != nil && q.LastSeenAt.Equal(mark)
+			})).Return(tt.internalJobs, nil)
+			mockDB.On("GetExternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+				return q.SinceLastSeen && q.LastSeenAt != nil && q.LastSeenAt.Equal(mark)
+			}), mock.Anything).Return(tt.externalJobs, nil)
+
+			output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, SinceLastSeen: true})
+
+			assert.NoError(t, err)
+			assert.Len(t, output.Items, tt.expectedJobs)
+			assert.Equal(t, tt.expectedSeenUntil, output.SeenUntil)
+			mockDB.AssertExpectations(t)
+		})
+	}
+}
+
+func TestListJobsSinceLastSeenRequiresIngestion(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	// Live external jobs have no first-seen time, they would never be listed
+	_, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, SinceLastSeen: true})
+
+	assert.ErrorIs(t, err, ErrSinceLastSeenUnavailable)
+	mockDB.AssertNotCalled(t, "GetInternalJobs", mock.Anything, mock.Anything)
+	mockFetcher.AssertNotCalled(t, "FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestListSavedSearchJobsSinceLastSeen(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+	service.StoredExternalJobs = true
+
+	mark := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	sub := subscriber
+	sub.LastSeenAt = &mark
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(sub, nil)
+	// The search never acknowledged any job, the subscriber mark does not apply to it
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return q.SinceLastSeen && q.LastSeenAt != nil && q.LastSeenAt.IsZero()
+	})).Return([]types.Job{}, nil)
+	mockDB.On("GetExternalJobs", mock.Anything, mock.Anything, mock.Anything).Return([]types.Job{}, nil)
+
+	output, err := service.ListSavedSearchJobs(context.Background(), types.SavedSearch{SubscriberID: subscriber.ID}, "", 10, true)
+
+	assert.NoError(t, err)
+	assert.Nil(t, output.SeenUntil)
+	mockDB.AssertExpectations(t)
+}
+
+func TestAcknowledgedUntil(t *testing.T) {
+	now := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name     string
+		input    types.AcknowledgeInput
+		expected time.Time
+	}{
+		{name: "Until now without a mark", input: types.AcknowledgeInput{}, expected: now},
+		{name: "Until the given mark", input: types.AcknowledgeInput{SeenUntil: &past}, expected: past},
+		{name: "Never past now", input: types.AcknowledgeInput{SeenUntil: &future}, expected: now},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, acknowledgedUntil(tt.input, now))
+		})
+	}
+}
@@ -3,64 +3,171 @@ package service
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 
+	"jobs/apperr"
+	"jobs/currency"
 	d "jobs/db"
 	e "jobs/external"
+	"jobs/logging"
 	"jobs/types"
 
 	"github.com/google/uuid"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+var tracer = otel.Tracer("jobs/service")
+
+// ErrExternalJobsUnavailable is returned when the external job provider fails and there are no internal jobs to fall back on
+var ErrExternalJobsUnavailable = apperr.Unavailable("external_jobs_unavailable", "The external job provider is unavailable")
+
 // Service interface for the service methods
 type Service interface {
-	Subscribe(ctx context.Context, input types.SubscribeInput) (types.SubscribeOutput, error)
-	GetJobs(ctx context.Context, input types.JobsInput) (types.JobsOutput, error)
+	// SaveSubscriber creates a subscriber or updates the one with the same email, reporting whether it was created
+	SaveSubscriber(ctx context.Context, input types.SubscriberInput) (types.Subscriber, bool, error)
+	GetSubscriber(ctx context.Context, id uuid.UUID) (types.Subscriber, error)
+	// ImportSubscribers saves a batch of subscribers and reports the outcome of every row
+	ImportSubscribers(ctx context.Context, rows []types.SubscriberImportRow, atomic bool) (types.SubscriberImportOutput, error)
+	ListJobs(ctx context.Context, query types.JobQuery) (types.JobList, error)
+	CreateJob(ctx context.Context, input types.JobInput) (types.Job, error)
+	GetJob(ctx context.Context, id uuid.UUID) (types.Job, error)
+	// ReplaceJob, PatchJob and DeleteJob fail with ErrJobModified unless the job was last updated at version, when set
+	ReplaceJob(ctx context.Context, id uuid.UUID, input types.JobInput, version *time.Time) (types.Job, error)
+	PatchJob(ctx context.Context, id uuid.UUID, patch types.JobPatch, version *time.Time) (types.Job, error)
+	DeleteJob(ctx context.Context, id uuid.UUID, version *time.Time) error
+	CreateCompany(ctx context.Context, input types.CompanyInput) (types.Company, error)
+	GetCompany(ctx context.Context, id uuid.UUID) (types.Company, error)
+	ListCompanies(ctx context.Context) ([]types.Company, error)
+	ReplaceCompany(ctx context.Context, id uuid.UUID, input types.CompanyInput) (types.Company, error)
+	DeleteCompany(ctx context.Context, id uuid.UUID) error
+	CreateSavedSearch(ctx context.Context, subscriberID uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error)
+	GetSavedSearch(ctx context.Context, id uuid.UUID) (types.SavedSearch, error)
+	ListSavedSearches(ctx context.Context, subscriberID uuid.UUID) ([]types.SavedSearch, error)
+	ReplaceSavedSearch(ctx context.Context, id uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error)
+	DeleteSavedSearch(ctx context.Context, id uuid.UUID) error
+	// ListSavedSearchJobs lists a page of the jobs matching a saved search, every job when limit is 0, or only the
+	// ones first seen after its high-water mark
+	ListSavedSearchJobs(ctx context.Context, search types.SavedSearch, cursor string, limit int, sinceLastSeen bool) (types.JobList, error)
+	// AcknowledgeJobs and AcknowledgeSavedSearchJobs advance the high-water mark of a subscriber or saved search
+	AcknowledgeJobs(ctx context.Context, subscriberID uuid.UUID, input types.AcknowledgeInput) (types.SeenMark, error)
+	AcknowledgeSavedSearchJobs(ctx context.Context, id uuid.UUID, input types.AcknowledgeInput) (types.SeenMark, error)
+	// CreateApplication records a subscriber applying to an internal job or an ingested external one
+	CreateApplication(ctx context.Context, subscriberID uuid.UUID, input types.ApplicationInput) (types.Application, error)
+	GetApplication(ctx context.Context, id uuid.UUID) (types.Application, error)
+	ListApplications(ctx context.Context, subscriberID uuid.UUID, status string) ([]types.Application, error)
+	// PatchApplication fails with ErrInvalidTransition unless the status moves forward in the pipeline or to rejected
+	PatchApplication(ctx context.Context, app types.Application, patch types.ApplicationPatch) (types.Application, error)
+	DeleteApplication(ctx context.Context, id uuid.UUID) error
+	ListApplicationEvents(ctx context.Context, id uuid.UUID) ([]types.ApplicationEvent, error)
+	SummarizeApplications(ctx context.Context, subscriberID uuid.UUID) (types.ApplicationSummary, error)
+	// SaveJobAction bookmarks or dismisses a job for a subscriber, action is types.ActionBookmark or types.ActionDismiss
+	SaveJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) (types.JobAction, error)
+	DeleteJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) error
+	ListJobActions(ctx context.Context, action string, subscriberID uuid.UUID) ([]types.JobAction, error)
 }
 
+// defaultExternalCountry is the country the external providers are asked for when no country is preferred
+const defaultExternalCountry = "Argentina"
+
+// WarningExternalJobsUnavailable is listed when only internal jobs could be listed
+const WarningExternalJobsUnavailable = "External jobs are unavailable, only internal jobs are listed"
+
 // JobsService implements the Service interface
 type JobsService struct {
 	DB          d.Database
 	Logger      *zap.Logger
 	JobsFetcher e.ExternalJobsFetcher
+	// Rates convert salaries to the subscriber preferred currency
+	Rates currency.Rates
+	// Sources are the providers ingested by RunExternalIngestion, the JobsFetcher when empty
+	Sources []ExternalSource
+	// StoredExternalJobs lists the ingested external jobs instead of asking the JobsFetcher on every request.
+	// ExternalJobsMaxAge, when set, leaves out the jobs the providers stopped serving that long ago.
+	StoredExternalJobs bool
+	ExternalJobsMaxAge time.Duration
 }
 
-// NewJobsService creates a new instance of JobsService
+// NewJobsService creates a new instance of JobsService using the embedded exchange rates
 func NewJobsService(logger *zap.Logger, conn d.Database, externalJobsFetcher e.ExternalJobsFetcher) *JobsService {
-	return &JobsService{Logger: logger, DB: conn, JobsFetcher: externalJobsFetcher}
+	return &JobsService{Logger: logger, DB: conn, JobsFetcher: externalJobsFetcher, Rates: currency.Default()}
 }
 
-// Subscribe method for JobsService
-func (s *JobsService) Subscribe(ctx context.Context, input types.SubscribeInput) (types.SubscribeOutput, error) {
-	id, err := s.DB.RecordSubscriber(ctx, &input)
+// SaveSubscriber method for JobsService
+func (s *JobsService) SaveSubscriber(ctx context.Context, input types.SubscriberInput) (types.Subscriber, bool, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.SaveSubscriber")
+	defer span.End()
+
+	if err := checkCompanyPreferences(input); err != nil {
+		recordError(span, err)
+		return types.Subscriber{}, false, err
+	}
+	if err := s.checkCurrency(input.SalaryCurrency); err != nil {
+		recordError(span, err)
+		return types.Subscriber{}, false, err
+	}
+	input.SalaryCurrency, input.SalaryPeriod = salaryUnit(input.SalaryCurrency, input.SalaryPeriod)
+	sub, created, err := s.DB.SaveSubscriber(ctx, input)
 	if err != nil {
-		return types.SubscribeOutput{}, fmt.Errorf("could not upsert user to subscriber table: %v", err)
+		recordError(span, err)
+		return types.Subscriber{}, false, fmt.Errorf("could not upsert user to subscriber table: %w", err)
 	}
+	span.SetAttributes(attribute.Bool("subscriber.created", created))
+	return sub, created, nil
+}
 
-	output := types.SubscribeOutput{
-		UserID:    id,
-		Name:      input.Name,
-		TimeStamp: time.Now(),
-		Message:   "User successfully subscribed",
+// GetSubscriber method for JobsService
+func (s *JobsService) GetSubscriber(ctx context.Context, id uuid.UUID) (types.Subscriber, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.GetSubscriber")
+	defer span.End()
+
+	sub, err := s.DB.GetSubscriber(ctx, id)
+	if err != nil {
+		recordError(span, err)
+		return types.Subscriber{}, err
 	}
-	return output, nil
+	return sub, nil
 }
-func (s *JobsService) GetJobs(ctx context.Context, input types.JobsInput) (types.JobsOutput, error) {
+
+// ListJobs lists internal jobs followed by external ones, the records of the same opening merged into one.
+// Filters missing from the query fall back to the subscriber preferences, the followed and blocked companies always
+// come from them. The jobs the subscriber dismissed are left out.
+func (s *JobsService) ListJobs(ctx context.Context, query types.JobQuery) (types.JobList, error) {
+	ctx, span := tracer.Start(ctx, "JobsService.ListJobs")
+	defer span.End()
+
+	if query.SinceLastSeen && !s.StoredExternalJobs {
+		recordError(span, ErrSinceLastSeenUnavailable)
+		return types.JobList{}, ErrSinceLastSeenUnavailable
+	}
+	offset, err := decodeCursor(query.Cursor)
+	if err != nil {
+		recordError(span, err)
+		return types.JobList{}, err
+	}
+	if err := s.applyPreferences(ctx, &query); err != nil {
+		recordError(span, err)
+		return types.JobList{}, err
+	}
+
 	var (
-		internalJobs []uuid.UUID
+		internalJobs []types.Job
 		externalJobs []types.Job
 		wg           sync.WaitGroup
 		errChan      = make(chan error, 2)
 	)
 
-	s.Logger.Sugar().Info("Starting to fetch jobs...")
+	s.log(ctx).Info("Starting to fetch jobs...")
 
 	// Fetch internal and external jobs concurrently
 	wg.Add(2)
-	go s.fetchInternalJobs(ctx, &input, &internalJobs, &wg, errChan)
-	go s.fetchExternalJobs(&input, &externalJobs, &wg, errChan)
+	go s.fetchInternalJobs(ctx, query, &internalJobs, &wg, errChan)
+	go s.fetchExternalJobs(ctx, query, &externalJobs, &wg, errChan)
 
 	// Wait for goroutines to finish and then close errChan
 	go func() {
@@ -68,78 +175,217 @@ func (s *JobsService) GetJobs(ctx context.Context, input types.JobsInput) (types
 		close(errChan)
 	}()
 
-	// Process errors
-	var err error
+	// Process errors, client errors such as an invalid filter win over upstream failures
 	for e := range errChan {
 		if e != nil {
-			if err == nil {
+			if err == nil || isClientError(e) {
 				err = e
 			}
-			s.Logger.Sugar().Errorf("Error fetching jobs: %v", e)
+			s.log(ctx).Errorf("Error fetching jobs: %v", e)
 		}
 	}
 
-	// Set message if external jobs failed
-	message := ""
+	// Warn if external jobs failed
+	var warnings []string
 	if err != nil && len(externalJobs) == 0 && len(internalJobs) > 0 {
-		message = "Warning: failed to fetch external jobs"
+		warnings = append(warnings, WarningExternalJobsUnavailable)
 		err = nil // clear error since we have internal jobs
 	}
 
-	output := types.JobsOutput{
-		InternalJobs: internalJobs,
-		ExternalJobs: externalJobs,
-		Message:      message,
+	span.SetAttributes(
+		attribute.Int("jobs.internal.count", len(internalJobs)),
+		attribute.Int("jobs.external.count", len(externalJobs)),
+	)
+	if err != nil {
+		recordError(span, err)
+		s.log(ctx).Infof("Fetched jobs: internal: %v, external: %v, error: %v", len(internalJobs), len(externalJobs), err)
+		return types.JobList{}, err
 	}
+	s.log(ctx).Infof("Fetched jobs: internal: %v, external: %v", len(internalJobs), len(externalJobs))
 
-	s.Logger.Sugar().Infof("Fetched jobs: internal: %v, external: %v, error: %v", len(output.InternalJobs), len(output.ExternalJobs), err)
-	return output, err
+	jobs, ids, merged := s.dedupJobs(ctx, append(internalJobs, externalJobs...))
+	span.SetAttributes(attribute.Int("jobs.duplicates.count", merged))
+	if jobs, err = s.applyJobActions(ctx, query, jobs, ids); err != nil {
+		recordError(span, err)
+		return types.JobList{}, err
+	}
+	out := types.JobList{List: paginate(jobs, offset, query.Limit), Warnings: warnings}
+	if query.SinceLastSeen {
+		// Computed on every job rather than the page so that every page acknowledges the whole list
+		out.SeenUntil = seenUntil(jobs, query.LastSeenAt)
+	}
+	return out, nil
+}
+
+// applyPreferences fills the filters missing from the query with the subscriber preferences.
+// Salaries are compared in the subscriber preferred currency and pay period, new jobs are new to the subscriber
+// high-water mark unless the query has its own.
+func (s *JobsService) applyPreferences(ctx context.Context, query *types.JobQuery) error {
+	sub, err := s.DB.GetSubscriber(ctx, query.SubscriberID)
+	if err != nil {
+		return fmt.Errorf("could not get subscriber preferences: %w", err)
+	}
+	if len(query.JobTitles) == 0 {
+		query.JobTitles = sub.JobTitles
+	}
+	if len(query.Countries) == 0 {
+		query.Countries = sub.Countries
+	}
+	if query.SalaryMin == 0 {
+		query.SalaryMin = sub.SalaryMin
+	}
+	if len(query.Locations) == 0 {
+		query.Locations = sub.Locations
+	}
+	if query.SinceLastSeen && query.LastSeenAt == nil {
+		query.LastSeenAt = markOrZero(sub.LastSeenAt)
+	}
+	query.FollowedCompanies = sub.FollowedCompanies
+	query.BlockedCompanies = sub.BlockedCompanies
+	query.SalaryCurrency, query.SalaryPeriod = salaryUnit(sub.SalaryCurrency, sub.SalaryPeriod)
+	if query.SalaryMin > 0 || query.SalaryMax > 0 {
+		query.SalaryFactors = s.salaryFactors(query.SalaryCurrency, query.SalaryPeriod)
+	}
+	return nil
 }
 
 // fetchInternalJobs retrieves internal jobs and sends any error to errChan
-func (s *JobsService) fetchInternalJobs(ctx context.Context, input *types.JobsInput, jobs *[]uuid.UUID, wg *sync.WaitGroup, errChan chan<- error) {
+func (s *JobsService) fetchInternalJobs(ctx context.Context, query types.JobQuery, jobs *[]types.Job, wg *sync.WaitGroup, errChan chan<- error) {
 	defer wg.Done()
-	internalJobs, err := s.DB.GetInternalJobs(ctx, input)
+	ctx, span := tracer.Start(ctx, "JobsService.fetchInternalJobs")
+	defer span.End()
+
+	internalJobs, err := s.DB.GetInternalJobs(ctx, query)
 	if err != nil {
-		s.Logger.Sugar().Errorf("Could not get internal jobs: %v", err)
+		recordError(span, err)
+		s.log(ctx).Errorf("Could not get internal jobs: %v", err)
 		errChan <- fmt.Errorf("could not get internal jobs: %w", err)
 		return
 	}
 	*jobs = internalJobs
-	s.Logger.Sugar().Infof("Fetched internal jobs: %v", len(internalJobs))
+	s.log(ctx).Infof("Fetched internal jobs: %v", len(internalJobs))
 }
 
 // fetchExternalJobs retrieves external jobs and sends any error to errChan
-func (s *JobsService) fetchExternalJobs(input *types.JobsInput, jobs *[]types.Job, wg *sync.WaitGroup, errChan chan<- error) {
+func (s *JobsService) fetchExternalJobs(ctx context.Context, query types.JobQuery, jobs *[]types.Job, wg *sync.WaitGroup, errChan chan<- error) {
 	defer wg.Done()
-	externalJobs, err := s.fetchAllExtJobs(input)
+	ctx, span := tracer.Start(ctx, "JobsService.fetchExternalJobs")
+	defer span.End()
+
+	// External jobs are always open
+	if len(query.Statuses) > 0 && !slices.Contains(query.Statuses, types.JobOpen) {
+		return
+	}
+
+	var (
+		externalJobs []types.Job
+		err          error
+	)
+	if s.StoredExternalJobs {
+		externalJobs, err = s.storedExternalJobs(ctx, query)
+	} else {
+		externalJobs, err = s.fetchAllExtJobs(ctx, query)
+	}
+	if err == nil {
+		externalJobs = searchExternalJobs(query.Text, filterExternalJobs(query, externalJobs))
+		externalJobs, err = s.applyCompanyPreferences(ctx, query, externalJobs)
+	}
 	if err != nil {
-		s.Logger.Sugar().Errorf("Could not fetch external jobs: %v", err)
-		errChan <- fmt.Errorf("could not get external jobs: %w", err)
+		recordError(span, err)
+		s.log(ctx).Errorf("Could not fetch external jobs: %v", err)
+		errChan <- ErrExternalJobsUnavailable.Wrap(err)
 		return
 	}
 	*jobs = externalJobs
-	s.Logger.Sugar().Infof("Fetched external jobs: %v", len(externalJobs))
+	s.log(ctx).Infof("Fetched external jobs: %v", len(externalJobs))
 }
 
-func (s *JobsService) fetchAllExtJobs(in *types.JobsInput) ([]types.Job, error) {
+func (s *JobsService) fetchAllExtJobs(ctx context.Context, query types.JobQuery) ([]types.Job, error) {
 	var allJobs []types.Job
 
-	s.Logger.Sugar().Info("Starting to fetch all external jobs...")
+	s.log(ctx).Info("Starting to fetch all external jobs...")
 
-	if in.PreferredCountries == nil {
-		in.PreferredCountries = []string{"Argentina"}
+	countries := expandWildcard(query.Countries, "country")
+	if countries == nil {
+		countries = []string{defaultExternalCountry}
 	}
-	for _, title := range in.JobTitles {
-		for _, country := range in.PreferredCountries {
-			s.Logger.Sugar().Infof("Fetching external jobs for title: %v, country: %v", title, country)
-			jobs, err := s.JobsFetcher.FetchExternalJobs(title, in.SalaryMin, 0, country)
+	for _, title := range expandWildcard(query.JobTitles, "job_title") {
+		for _, country := range countries {
+			// Without a known currency the provider is asked for every salary, filterExternalJobs drops the misses
+			minSalary, maxSalary, _ := providerSalaries(query, country)
+			s.log(ctx).Infof("Fetching external jobs for title: %v, country: %v", title, country)
+			jobs, err := s.JobsFetcher.FetchExternalJobs(ctx, title, minSalary, maxSalary, country)
 			if err != nil {
 				return nil, fmt.Errorf("could not fetch external jobs %v/%v: %v", title, country, err)
 			}
-			allJobs = append(allJobs, jobs...)
+			for _, job := range jobs {
+				allJobs = append(allJobs, fromExternalJob(types.SourceExternal, job, country))
+			}
 		}
 	}
-	s.Logger.Sugar().Info("Finished fetching all external jobs")
+	s.log(ctx).Info("Finished fetching all external jobs")
 	return allJobs, nil
 }
+
+// filterExternalJobs applies the salary, location, skills and high-water mark filters of the query to external jobs,
+// which the provider only filters by title, country and salary
+func filterExternalJobs(query types.JobQuery, jobs []types.Job) []types.Job {
+	var matched []types.Job
+	for _, job := range jobs {
+		if matchesSalary(query, job) && matchesLocation(query.Locations, job) && matchesSkills(query.Skills, job) &&
+			matchesLastSeen(query, job) {
+			matched = append(matched, job)
+		}
+	}
+	return matched
+}
+
+// expandWildcard lists every value of the vocabulary when values hold ALL, the provider only knows specific values
+func expandWildcard(values []string, vocabulary string) []string {
+	if slices.Contains(values, types.Wildcard) {
+		return types.Vocabularies[vocabulary]
+	}
+	return values
+}
+
+// fromExternalJob converts a job served by an external source for country, whose salaries are yearly
+// in the country currency. Its ID is the one it is ingested under from source.
+func fromExternalJob(source string, job types.ExternalJob, country string) types.Job {
+	skills := make([]string, 0, len(job.Skills.Skills))
+	for _, skill := range job.Skills.Skills {
+		skills = append(skills, skill.Name)
+	}
+	converted := types.Job{
+		Source:         types.SourceExternal,
+		Title:          job.Title,
+		Country:        country,
+		Region:         job.Region,
+		City:           job.City,
+		WorkMode:       job.WorkMode,
+		SalaryMin:      int64(job.Salary),
+		SalaryCurrency: types.CountryCurrencies[country],
+		SalaryPeriod:   types.PayYear,
+		Skills:         skills,
+		Company:        job.Company,
+	}
+	id := d.ExternalJobID(source, converted)
+	converted.ID = &id
+	return converted
+}
+
+// isClientError reports whether err was caused by the request rather than by a failing dependency
+func isClientError(err error) bool {
+	e, ok := apperr.From(err)
+	return ok && (e.Kind == apperr.KindNotFound || e.Kind == apperr.KindValidation)
+}
+
+// log returns the request-scoped logger, falling back to the service logger
+func (s *JobsService) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, s.Logger).Sugar()
+}
+
+// recordError marks the span as failed
+func recordError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"fmt"
+	d "jobs/db"
 	"jobs/setup"
 	"jobs/types"
 	"testing"
@@ -18,95 +19,272 @@ type MockDB struct {
 	mock.Mock
 }
 
-func (m *MockDB) GetInternalJobs(ctx context.Context, input *types.JobsInput) ([]uuid.UUID, error) {
+func (m *MockDB) GetInternalJobs(ctx context.Context, query types.JobQuery) ([]types.Job, error) {
+	args := m.Called(ctx, query)
+	return args.Get(0).([]types.Job), args.Error(1)
+}
+
+func (m *MockDB) SaveSubscriber(ctx context.Context, input types.SubscriberInput) (types.Subscriber, bool, error) {
 	args := m.Called(ctx, input)
-	return args.Get(0).([]uuid.UUID), args.Error(1)
+	return args.Get(0).(types.Subscriber), args.Bool(1), args.Error(2)
 }
 
-func (m *MockDB) RecordSubscriber(ctx context.Context, input *types.SubscribeInput) (uuid.UUID, error) {
+func (m *MockDB) SaveSubscribers(ctx context.Context, inputs []types.SubscriberInput, chunkSize int, atomic bool) ([]d.SubscriberSaveResult, error) {
+	args := m.Called(ctx, inputs, chunkSize, atomic)
+	return args.Get(0).([]d.SubscriberSaveResult), args.Error(1)
+}
+
+func (m *MockDB) CreateJob(ctx context.Context, input types.JobInput) (types.Job, error) {
 	args := m.Called(ctx, input)
-	return args.Get(0).(uuid.UUID), args.Error(1)
+	return args.Get(0).(types.Job), args.Error(1)
 }
-func (m *MockDB) Close() error {
-	return nil
+
+func (m *MockDB) GetJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockDB) ReplaceJob(ctx context.Context, id uuid.UUID, input types.JobInput, version *time.Time) (types.Job, error) {
+	args := m.Called(ctx, id, input, version)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockDB) PatchJob(ctx context.Context, id uuid.UUID, patch types.JobPatch, version *time.Time) (types.Job, error) {
+	args := m.Called(ctx, id, patch, version)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockDB) DeleteJob(ctx context.Context, id uuid.UUID, version *time.Time) error {
+	args := m.Called(ctx, id, version)
+	return args.Error(0)
+}
+
+func (m *MockDB) CreateCompany(ctx context.Context, input types.CompanyInput) (types.Company, error) {
+	args := m.Called(ctx, input)
+	return args.Get(0).(types.Company), args.Error(1)
+}
+
+func (m *MockDB) GetCompany(ctx context.Context, id uuid.UUID) (types.Company, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Company), args.Error(1)
+}
+
+func (m *MockDB) ListCompanies(ctx context.Context, ids []uuid.UUID) ([]types.Company, error) {
+	args := m.Called(ctx, ids)
+	return args.Get(0).([]types.Company), args.Error(1)
+}
+
+func (m *MockDB) ReplaceCompany(ctx context.Context, id uuid.UUID, input types.CompanyInput) (types.Company, error) {
+	args := m.Called(ctx, id, input)
+	return args.Get(0).(types.Company), args.Error(1)
+}
+
+func (m *MockDB) DeleteCompany(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDB) ExpireJobs(ctx context.Context, now time.Time, maxAge time.Duration) (int64, error) {
+	args := m.Called(ctx, now, maxAge)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) GetSubscriber(ctx context.Context, id uuid.UUID) (types.Subscriber, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Subscriber), args.Error(1)
+}
+
+func (m *MockDB) CreateSavedSearch(ctx context.Context, subscriberID uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	args := m.Called(ctx, subscriberID, input)
+	return args.Get(0).(types.SavedSearch), args.Error(1)
+}
+
+func (m *MockDB) GetSavedSearch(ctx context.Context, id uuid.UUID) (types.SavedSearch, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.SavedSearch), args.Error(1)
+}
+
+func (m *MockDB) ListSavedSearches(ctx context.Context, subscriberID uuid.UUID) ([]types.SavedSearch, error) {
+	args := m.Called(ctx, subscriberID)
+	return args.Get(0).([]types.SavedSearch), args.Error(1)
+}
+
+func (m *MockDB) ReplaceSavedSearch(ctx context.Context, id uuid.UUID, input types.SavedSearchInput) (types.SavedSearch, error) {
+	args := m.Called(ctx, id, input)
+	return args.Get(0).(types.SavedSearch), args.Error(1)
+}
+
+func (m *MockDB) DeleteSavedSearch(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
+}
+
+func (m *MockDB) AcknowledgeSubscriberJobs(ctx context.Context, id uuid.UUID, seenUntil time.Time) (time.Time, error) {
+	args := m.Called(ctx, id, seenUntil)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockDB) AcknowledgeSavedSearchJobs(ctx context.Context, id uuid.UUID, seenUntil time.Time) (time.Time, error) {
+	args := m.Called(ctx, id, seenUntil)
+	return args.Get(0).(time.Time), args.Error(1)
+}
+
+func (m *MockDB) CreateApplication(ctx context.Context, app types.Application) (types.Application, error) {
+	args := m.Called(ctx, app)
+	return args.Get(0).(types.Application), args.Error(1)
+}
+
+func (m *MockDB) GetApplication(ctx context.Context, id uuid.UUID) (types.Application, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Application), args.Error(1)
+}
+
+func (m *MockDB) ListApplications(ctx context.Context, subscriberID uuid.UUID, status string) ([]types.Application, error) {
+	args := m.Called(ctx, subscriberID, status)
+	return args.Get(0).([]types.Application), args.Error(1)
+}
+
+func (m *MockDB) UpdateApplication(ctx context.Context, fromStatus string, app types.Application, note string) (types.Application, error) {
+	args := m.Called(ctx, fromStatus, app, note)
+	return args.Get(0).(types.Application), args.Error(1)
+}
+
+func (m *MockDB) DeleteApplication(ctx context.Context, id uuid.UUID) error {
+	args := m.Called(ctx, id)
+	return args.Error(0)
 }
 
-// Mock function
-func MockFetchExternalJobs(title string, salaryMin int, minExperience int, country string) ([]types.Job, error) {
-	return nil, nil
+func (m *MockDB) ListApplicationEvents(ctx context.Context, id uuid.UUID) ([]types.ApplicationEvent, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).([]types.ApplicationEvent), args.Error(1)
+}
+
+func (m *MockDB) CountApplications(ctx context.Context, subscriberID uuid.UUID) (map[string]int, error) {
+	args := m.Called(ctx, subscriberID)
+	return args.Get(0).(map[string]int), args.Error(1)
+}
+
+func (m *MockDB) SaveJobAction(ctx context.Context, action string, a types.JobAction) (types.JobAction, error) {
+	args := m.Called(ctx, action, a)
+	return args.Get(0).(types.JobAction), args.Error(1)
+}
+
+func (m *MockDB) DeleteJobAction(ctx context.Context, action string, subscriberID, jobID uuid.UUID) error {
+	args := m.Called(ctx, action, subscriberID, jobID)
+	return args.Error(0)
+}
+
+func (m *MockDB) ListJobActions(ctx context.Context, action string, subscriberID uuid.UUID) ([]types.JobAction, error) {
+	args := m.Called(ctx, action, subscriberID)
+	return args.Get(0).([]types.JobAction), args.Error(1)
+}
+
+func (m *MockDB) GetExternalJob(ctx context.Context, id uuid.UUID) (types.Job, error) {
+	args := m.Called(ctx, id)
+	return args.Get(0).(types.Job), args.Error(1)
+}
+
+func (m *MockDB) ListExternalSearches(ctx context.Context) ([]types.ExternalSearch, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]types.ExternalSearch), args.Error(1)
+}
+
+func (m *MockDB) UpsertExternalJobs(ctx context.Context, source string, jobs []types.Job) (int64, error) {
+	args := m.Called(ctx, source, jobs)
+	return args.Get(0).(int64), args.Error(1)
+}
+
+func (m *MockDB) GetExternalJobs(ctx context.Context, query types.JobQuery, seenAfter time.Time) ([]types.Job, error) {
+	args := m.Called(ctx, query, seenAfter)
+	return args.Get(0).([]types.Job), args.Error(1)
+}
+
+func (m *MockDB) Close() error {
+	return nil
 }
 
 type MockExternalJobsFetcher struct {
 	mock.Mock
 }
 
-func (m *MockExternalJobsFetcher) FetchExternalJobs(name string, minSalary, maxSalary int64, country string) ([]types.Job, error) {
-	args := m.Called(name, minSalary, maxSalary, country)
-	return args.Get(0).([]types.Job), args.Error(1)
+func (m *MockExternalJobsFetcher) FetchExternalJobs(ctx context.Context, name string, minSalary, maxSalary int64, country string) ([]types.ExternalJob, error) {
+	args := m.Called(ctx, name, minSalary, maxSalary, country)
+	return args.Get(0).([]types.ExternalJob), args.Error(1)
+}
+
+// subscriber has the preferences used when a query does not override them
+var subscriber = types.Subscriber{
+	ID:        uuid.MustParse("b2b20e8a-8702-4a44-9ede-3dc9a53e5aa6"),
+	JobTitles: []string{"Backend Developer"},
+	Countries: []string{"USA"},
+	SalaryMin: 1000,
+}
+
+func internalJobs(n int) []types.Job {
+	jobs := make([]types.Job, n)
+	for i := range jobs {
+		id := uuid.New()
+		jobs[i] = types.Job{ID: &id, Source: types.SourceInternal, Title: "Backend Developer", Country: "USA", Company: fmt.Sprintf("Employer %d", i)}
+	}
+	return jobs
 }
 
 // Test cases for JobsService
-func TestGetJobs(t *testing.T) {
+func TestListJobs(t *testing.T) {
 	tests := []struct {
 		name             string
-		internalJobs     []uuid.UUID
+		subscriberErr    error
+		internalJobs     []types.Job
 		internalJobsErr  error
-		externalJobs     []types.Job
+		externalJobs     []types.ExternalJob
 		externalJobsErr  error
-		expectedOutput   types.JobsOutput
+		expectedInternal int
+		expectedExternal int
+		expectedWarnings []string
 		expectedErrorMsg string
+		skipsJobs        bool
 	}{
 		{
-			name:            "Success - Internal and External jobs fetched successfully",
-			internalJobs:    []uuid.UUID{uuid.New()},
-			internalJobsErr: nil,
-			externalJobs:    []types.Job{{Title: "Backend Developer"}},
-			externalJobsErr: nil,
-			expectedOutput: types.JobsOutput{
-				InternalJobs: []uuid.UUID{uuid.New()},
-				ExternalJobs: []types.Job{{Title: "Backend Developer"}},
-				Message:      "",
-			},
-			expectedErrorMsg: "",
+			name:             "Success - Internal and External jobs fetched successfully",
+			internalJobs:     internalJobs(1),
+			externalJobs:     []types.ExternalJob{{Title: "Backend Developer", Salary: 3000, Company: "Initech"}},
+			expectedInternal: 1,
+			expectedExternal: 1,
 		},
 		{
-			name:            "Success - Only internal jobs available",
-			internalJobs:    []uuid.UUID{uuid.New()},
-			internalJobsErr: nil,
-			externalJobs:    nil,
-			externalJobsErr: fmt.Errorf("external service error"),
-			expectedOutput: types.JobsOutput{
-				InternalJobs: []uuid.UUID{uuid.New()},
-				ExternalJobs: nil,
-				Message:      "Warning: failed to fetch external jobs",
-			},
-			expectedErrorMsg: "",
+			name:             "Success - Only internal jobs available",
+			internalJobs:     internalJobs(1),
+			externalJobs:     []types.ExternalJob{},
+			externalJobsErr:  fmt.Errorf("external service error"),
+			expectedInternal: 1,
+			expectedWarnings: []string{WarningExternalJobsUnavailable},
 		},
 		{
-			name:            "Error - Internal jobs fetching fails",
-			internalJobs:    nil,
-			internalJobsErr: fmt.Errorf("database error"),
-			externalJobs:    []types.Job{{Title: "Backend Developer"}},
-			externalJobsErr: nil,
-			expectedOutput: types.JobsOutput{
-				InternalJobs: nil,
-				ExternalJobs: []types.Job{{Title: "Backend Developer"}},
-				Message:      "",
-			},
+			name:             "Error - Internal jobs fetching fails",
+			internalJobs:     nil,
+			internalJobsErr:  fmt.Errorf("database error"),
+			externalJobs:     []types.ExternalJob{{Title: "Backend Developer", Salary: 3000}},
 			expectedErrorMsg: "could not get internal jobs: database error",
 		},
 		{
-			name:            "Error - External jobs fetching fails, internal jobs available",
-			internalJobs:    []uuid.UUID{uuid.New()},
-			internalJobsErr: nil,
-			externalJobs:    nil,
-			externalJobsErr: fmt.Errorf("external service error"),
-			expectedOutput: types.JobsOutput{
-				InternalJobs: []uuid.UUID{uuid.New()},
-				ExternalJobs: nil,
-				Message:      "Warning: failed to fetch external jobs",
-			},
-			expectedErrorMsg: "",
+			name:             "Error - External jobs fetching fails without internal jobs",
+			internalJobs:     []types.Job{},
+			externalJobs:     []types.ExternalJob{},
+			externalJobsErr:  fmt.Errorf("external service error"),
+			expectedErrorMsg: "The external job provider is unavailable",
+		},
+		{
+			name:             "Error - Invalid filters win over external failures",
+			internalJobsErr:  fmt.Errorf("error executing query: %w", d.ErrInvalidValue),
+			externalJobs:     []types.ExternalJob{},
+			externalJobsErr:  fmt.Errorf("external service error"),
+			expectedErrorMsg: d.ErrInvalidValue.Message,
+		},
+		{
+			name:             "Error - Unknown subscriber",
+			subscriberErr:    d.ErrSubscriberNotFound,
+			expectedErrorMsg: "Subscriber not found",
+			skipsJobs:        true,
 		},
 	}
 
@@ -122,11 +300,15 @@ func TestGetJobs(t *testing.T) {
 				JobsFetcher: mockFetcher,
 			}
 
-			mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return(tt.internalJobs, tt.internalJobsErr)
-			mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.externalJobs, tt.externalJobsErr)
+			mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, tt.subscriberErr)
+			mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil).Maybe()
+			if !tt.skipsJobs {
+				mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return(tt.internalJobs, tt.internalJobsErr)
+				mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(tt.externalJobs, tt.externalJobsErr)
+			}
 
-			// Call the GetJobs method
-			output, err := service.GetJobs(context.Background(), types.JobsInput{JobTitles: []string{"Backend Developer"}})
+			// Call the ListJobs method
+			output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID})
 
 			// Assertions
 			if tt.expectedErrorMsg != "" {
@@ -136,12 +318,17 @@ func TestGetJobs(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			// Check that the length of internal and external jobs is as expected
-			assert.Equal(t, len(tt.expectedOutput.InternalJobs), len(output.InternalJobs))
-			assert.Equal(t, len(tt.expectedOutput.ExternalJobs), len(output.ExternalJobs))
+			// Check that the count of jobs per source is as expected
+			bySource := map[string]int{}
+			for _, job := range output.Items {
+				bySource[job.Source]++
+			}
+			assert.Equal(t, tt.expectedInternal, bySource[types.SourceInternal])
+			assert.Equal(t, tt.expectedExternal, bySource[types.SourceExternal])
+			assert.Equal(t, tt.expectedInternal+tt.expectedExternal, output.Total)
 
-			// Verify the message
-			assert.Equal(t, tt.expectedOutput.Message, output.Message)
+			// Verify the warnings
+			assert.Equal(t, tt.expectedWarnings, output.Warnings)
 
 			// Assert that the expectations were met
 			mockDB.AssertExpectations(t)
@@ -149,7 +336,74 @@ func TestGetJobs(t *testing.T) {
 		})
 	}
 }
-func BenchmarkGetJobs(b *testing.B) {
+
+func TestListJobsFilters(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	// Titles come from the query, countries and salary from the subscriber preferences
+	expected := types.JobQuery{
+		SubscriberID:   subscriber.ID,
+		JobTitles:      []string{"Frontend Developer"},
+		Countries:      []string{"USA"},
+		SalaryMin:      1000,
+		SalaryCurrency: types.DefaultCurrency,
+		SalaryPeriod:   types.DefaultPayPeriod,
+		SalaryFactors:  service.salaryFactors(types.DefaultCurrency, types.DefaultPayPeriod),
+	}
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, expected).Return([]types.Job{}, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, "Frontend Developer", int64(1000), int64(0), "USA").
+		Return([]types.ExternalJob{{Title: "Frontend Developer", Salary: 3000, Skills: types.Skills{Skills: []types.Skill{{Name: "React"}}}}}, nil)
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, JobTitles: []string{"Frontend Developer"}})
+
+	want := types.Job{Source: types.SourceExternal, Title: "Frontend Developer", Country: "USA", SalaryMin: 3000, SalaryCurrency: "USD", SalaryPeriod: types.PayYear,
+		Skills: []string{"React"}}
+	id := d.ExternalJobID(types.SourceExternal, want)
+	want.ID = &id
+	assert.NoError(t, err)
+	assert.Equal(t, []types.Job{want}, output.Items)
+	mockDB.AssertExpectations(t)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestListJobsPagination(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	jobs := internalJobs(5)
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
+	mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return(jobs, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return([]types.ExternalJob{}, nil)
+
+	var (
+		cursor string
+		pages  [][]types.Job
+	)
+	for {
+		output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, Limit: 2, Cursor: cursor})
+		assert.NoError(t, err)
+		assert.Equal(t, 5, output.Total)
+		pages = append(pages, output.Items)
+		if output.NextCursor == "" {
+			break
+		}
+		cursor = output.NextCursor
+	}
+	assert.Equal(t, [][]types.Job{jobs[0:2], jobs[2:4], jobs[4:5]}, pages)
+
+	_, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID, Cursor: "not a cursor"})
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func BenchmarkListJobs(b *testing.B) {
 	l, _ := setup.SetupLogger()
 
 	// Mocks
@@ -162,20 +416,21 @@ func BenchmarkGetJobs(b *testing.B) {
 	}
 
 	// Inputs
-	jobUUID := uuid.New()
-	mockInternalJobs := []uuid.UUID{jobUUID}
-	mockExternalJobs := []types.Job{{Title: "Backend Developer"}}
+	mockInternalJobs := internalJobs(1)
+	mockExternalJobs := []types.ExternalJob{{Title: "Backend Developer"}}
 
 	// Simulation
+	mockDB.On("GetSubscriber", mock.Anything, subscriber.ID).Return(subscriber, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, subscriber.ID).Return([]types.JobAction{}, nil)
 	mockDB.On("GetInternalJobs", mock.Anything, mock.Anything).Return(mockInternalJobs, nil)
-	mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockExternalJobs, nil)
+	mockFetcher.On("FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(mockExternalJobs, nil)
 
 	// Run benchmark
 	for i := 0; i < b.N; i++ {
 		startTime := time.Now()
-		_, err := service.GetJobs(context.Background(), types.JobsInput{JobTitles: []string{"Backend Developer"}})
+		_, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: subscriber.ID})
 		if err != nil {
-			b.Fatalf("Error en GetJobs: %v", err)
+			b.Fatalf("Error en ListJobs: %v", err)
 		}
 		duration := time.Since(startTime)
 		b.Logf("Execution took %s", duration)
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"jobs/apperr"
+	d "jobs/db"
+	"jobs/logging"
+	"jobs/mailer"
+	"jobs/types"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+const sessionIssuer = "jobs"
+
+// ErrInvalidSession is returned for unknown, expired or tampered login tokens and session tokens
+var ErrInvalidSession = apperr.Unauthenticated("invalid_token", "Invalid or expired token")
+
+// SessionManager handles passwordless logins and subscriber session tokens
+type SessionManager interface {
+	RequestMagicLink(ctx context.Context, email string) error
+	ExchangeMagicLink(ctx context.Context, token string) (types.SessionOutput, error)
+	ValidateSessionToken(ctx context.Context, token string) (types.SessionClaims, error)
+	RecordImpersonation(ctx context.Context, audit types.ImpersonationAudit) error
+}
+
+// SessionConfig holds the session signing and lifetime settings
+type SessionConfig struct {
+	Secret       []byte
+	TokenTTL     time.Duration
+	MagicLinkTTL time.Duration
+	// MagicLinkURL is the page the emailed link points to, the token is added as a query parameter
+	MagicLinkURL string
+}
+
+// SessionsService implements the SessionManager interface
+type SessionsService struct {
+	DB     d.SessionStore
+	Mailer mailer.Mailer
+	Logger *zap.Logger
+	Config SessionConfig
+	now    func() time.Time
+}
+
+// sessionClaims is the JWT payload of a session token
+type sessionClaims struct {
+	Scope string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+// NewSessionsService creates a new instance of SessionsService
+func NewSessionsService(logger *zap.Logger, store d.SessionStore, m mailer.Mailer, cfg SessionConfig) *SessionsService {
+	return &SessionsService{Logger: logger, DB: store, Mailer: m, Config: cfg, now: time.Now}
+}
+
+// RequestMagicLink emails a single use login link to the subscriber.
+// Unknown emails are ignored so that callers cannot probe which addresses are subscribed.
+func (s *SessionsService) RequestMagicLink(ctx context.Context, email string) error {
+	ctx, span := tracer.Start(ctx, "SessionsService.RequestMagicLink")
+	defer span.End()
+
+	subscriberID, err := s.DB.GetSubscriberIDByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, d.ErrSubscriberNotFound) {
+			s.log(ctx).Infof("Magic link requested for unknown email %s", email)
+			return nil
+		}
+		recordError(span, err)
+		return fmt.Errorf("could not look up subscriber: %w", err)
+	}
+
+	token, err := randomToken()
+	if err != nil {
+		recordError(span, err)
+		return err
+	}
+	expiresAt := s.now().UTC().Add(s.Config.MagicLinkTTL)
+	if err := s.DB.CreateMagicLink(ctx, subscriberID, HashToken(token), expiresAt); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not store magic link: %w", err)
+	}
+
+	link, err := magicLink(s.Config.MagicLinkURL, token)
+	if err != nil {
+		recordError(span, err)
+		return err
+	}
+	body := fmt.Sprintf("Use this link to sign in to Job Seeker, it expires in %s and can only be used once:\n\n%s\n", s.Config.MagicLinkTTL, link)
+	if err := s.Mailer.Send(ctx, email, "Your Job Seeker sign-in link", body); err != nil {
+		recordError(span, err)
+		return fmt.Errorf("could not send magic link: %w", err)
+	}
+	return nil
+}
+
+// ExchangeMagicLink consumes a login token and issues a signed session token for its subscriber
+func (s *SessionsService) ExchangeMagicLink(ctx context.Context, token string) (types.SessionOutput, error) {
+	ctx, span := tracer.Start(ctx, "SessionsService.ExchangeMagicLink")
+	defer span.End()
+
+	subscriberID, err := s.DB.ConsumeMagicLink(ctx, HashToken(token))
+	if err != nil {
+		if errors.Is(err, d.ErrMagicLinkNotFound) {
+			return types.SessionOutput{}, ErrInvalidSession
+		}
+		recordError(span, err)
+		return types.SessionOutput{}, fmt.Errorf("could not consume magic link: %w", err)
+	}
+
+	now := s.now().UTC().Truncate(time.Second)
+	expiresAt := now.Add(s.Config.TokenTTL)
+	claims := sessionClaims{
+		Scope: types.ScopeJobsRead + " " + types.ScopeTrack,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    sessionIssuer,
+			Subject:   subscriberID.String(),
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+		},
+	}
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.Config.Secret)
+	if err != nil {
+		recordError(span, err)
+		return types.SessionOutput{}, fmt.Errorf("could not sign session token: %w", err)
+	}
+
+	s.log(ctx).Infof("Issued session token for subscriber %s", subscriberID)
+	return types.SessionOutput{
+		AccessToken: signed,
+		TokenType:   "Bearer",
+		ExpiresIn:   int64(s.Config.TokenTTL.Seconds()),
+		ExpiresAt:   expiresAt,
+	}, nil
+}
+
+// ValidateSessionToken checks the token signature, issuer and expiry and returns its claims
+func (s *SessionsService) ValidateSessionToken(ctx context.Context, token string) (types.SessionClaims, error) {
+	var claims sessionClaims
+	_, err := jwt.ParseWithClaims(token, &claims, func(*jwt.Token) (interface{}, error) {
+		return s.Config.Secret, nil
+	},
+		jwt.WithValidMethods([]string{jwt.SigningMethodHS256.Alg()}),
+		jwt.WithIssuer(sessionIssuer),
+		jwt.WithExpirationRequired(),
+		jwt.WithTimeFunc(s.now),
+	)
+	if err != nil {
+		return types.SessionClaims{}, ErrInvalidSession
+	}
+
+	subscriberID, err := uuid.Parse(claims.Subject)
+	if err != nil {
+		return types.SessionClaims{}, ErrInvalidSession
+	}
+	return types.SessionClaims{
+		SubscriberID: subscriberID,
+		Scopes:       strings.Fields(claims.Scope),
+		ExpiresAt:    claims.ExpiresAt.Time,
+	}, nil
+}
+
+// RecordImpersonation audits an admin key reading a subscriber's data
+func (s *SessionsService) RecordImpersonation(ctx context.Context, audit types.ImpersonationAudit) error {
+	s.log(ctx).Warnf("API key %s is impersonating subscriber %s on %s %s", audit.APIKeyID, audit.SubscriberID, audit.Method, audit.Path)
+	if err := s.DB.RecordImpersonation(ctx, audit); err != nil {
+		return fmt.Errorf("could not audit impersonation: %w", err)
+	}
+	return nil
+}
+
+// log returns the request-scoped logger, falling back to the service logger
+func (s *SessionsService) log(ctx context.Context) *zap.SugaredLogger {
+	return logging.FromContext(ctx, s.Logger).Sugar()
+}
+
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("could not generate token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func magicLink(base, token string) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", fmt.Errorf("invalid magic link URL: %w", err)
+	}
+	q := u.Query()
+	q.Set("token", token)
+	u.RawQuery = q.Encode()
+	return u.String(), nil
+}
@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	d "jobs/db"
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type MockSessionStore struct {
+	mock.Mock
+}
+
+func (m *MockSessionStore) GetSubscriberIDByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	args := m.Called(ctx, email)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockSessionStore) CreateMagicLink(ctx context.Context, subscriberID uuid.UUID, hash string, expiresAt time.Time) error {
+	args := m.Called(ctx, subscriberID, hash, expiresAt)
+	return args.Error(0)
+}
+
+func (m *MockSessionStore) ConsumeMagicLink(ctx context.Context, hash string) (uuid.UUID, error) {
+	args := m.Called(ctx, hash)
+	return args.Get(0).(uuid.UUID), args.Error(1)
+}
+
+func (m *MockSessionStore) RecordImpersonation(ctx context.Context, audit types.ImpersonationAudit) error {
+	args := m.Called(ctx, audit)
+	return args.Error(0)
+}
+
+type MockMailer struct {
+	mock.Mock
+}
+
+func (m *MockMailer) Send(ctx context.Context, to, subject, body string) error {
+	args := m.Called(ctx, to, subject, body)
+	return args.Error(0)
+}
+
+func TestMagicLinkSessionFlow(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	now := time.Date(2024, time.November, 1, 10, 0, 0, 0, time.UTC)
+	subscriberID := uuid.New()
+	cfg := SessionConfig{
+		Secret:       []byte("0123456789abcdef0123456789abcdef"),
+		TokenTTL:     time.Hour,
+		MagicLinkTTL: 15 * time.Minute,
+		MagicLinkURL: "https://jobs.example.com/login",
+	}
+
+	store := new(MockSessionStore)
+	mailer := new(MockMailer)
+	s := NewSessionsService(l, store, mailer, cfg)
+	s.now = func() time.Time { return now }
+
+	// Request a link and capture the emailed token
+	var token string
+	store.On("GetSubscriberIDByEmail", mock.Anything, "jane@example.com").Return(subscriberID, nil)
+	store.On("CreateMagicLink", mock.Anything, subscriberID, mock.AnythingOfType("string"), now.Add(15*time.Minute)).Return(nil)
+	mailer.On("Send", mock.Anything, "jane@example.com", mock.Anything, mock.MatchedBy(func(body string) bool {
+		i := strings.Index(body, cfg.MagicLinkURL)
+		if i < 0 {
+			return false
+		}
+		u, err := url.Parse(strings.TrimSpace(body[i:]))
+		if err != nil {
+			return false
+		}
+		token = u.Query().Get("token")
+		return token != ""
+	})).Return(nil)
+	assert.NoError(t, s.RequestMagicLink(context.Background(), "jane@example.com"))
+	assert.Equal(t, HashToken(token), store.Calls[1].Arguments.String(2))
+
+	// Exchange it for a session token
+	store.On("ConsumeMagicLink", mock.Anything, HashToken(token)).Return(subscriberID, nil)
+	session, err := s.ExchangeMagicLink(context.Background(), token)
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", session.TokenType)
+	assert.Equal(t, int64(3600), session.ExpiresIn)
+
+	claims, err := s.ValidateSessionToken(context.Background(), session.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, subscriberID, claims.SubscriberID)
+	assert.Equal(t, []string{types.ScopeJobsRead, types.ScopeTrack}, claims.Scopes)
+
+	// Expired and tampered tokens are rejected
+	s.now = func() time.Time { return now.Add(2 * time.Hour) }
+	_, err = s.ValidateSessionToken(context.Background(), session.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidSession)
+
+	s.now = func() time.Time { return now }
+	other := NewSessionsService(l, store, mailer, SessionConfig{Secret: []byte("another-secret-another-secret-00")})
+	_, err = other.ValidateSessionToken(context.Background(), session.AccessToken)
+	assert.ErrorIs(t, err, ErrInvalidSession)
+
+	store.AssertExpectations(t)
+	mailer.AssertExpectations(t)
+}
+
+func TestMagicLinkErrors(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	store := new(MockSessionStore)
+	mailer := new(MockMailer)
+	s := NewSessionsService(l, store, mailer, SessionConfig{Secret: []byte("0123456789abcdef0123456789abcdef")})
+
+	// Unknown emails are silently ignored
+	store.On("GetSubscriberIDByEmail", mock.Anything, "nobody@example.com").Return(uuid.Nil, d.ErrSubscriberNotFound)
+	assert.NoError(t, s.RequestMagicLink(context.Background(), "nobody@example.com"))
+	mailer.AssertNotCalled(t, "Send")
+
+	// Used or expired links cannot be exchanged
+	store.On("ConsumeMagicLink", mock.Anything, HashToken("used")).Return(uuid.Nil, d.ErrMagicLinkNotFound)
+	_, err := s.ExchangeMagicLink(context.Background(), "used")
+	assert.ErrorIs(t, err, ErrInvalidSession)
+}
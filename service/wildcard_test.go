@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"jobs/setup"
+	"jobs/types"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestListJobsExpandsWildcards(t *testing.T) {
+	l, _ := setup.SetupLogger()
+	mockDB := new(MockDB)
+	mockFetcher := new(MockExternalJobsFetcher)
+	service := NewJobsService(l, mockDB, mockFetcher)
+
+	sub := types.Subscriber{ID: subscriber.ID, JobTitles: []string{"Backend Developer"}, Countries: []string{types.Wildcard}}
+	mockDB.On("GetSubscriber", mock.Anything, sub.ID).Return(sub, nil)
+	mockDB.On("ListJobActions", mock.Anything, mock.Anything, sub.ID).Return([]types.JobAction{}, nil)
+	// The wildcard is left to the query, which matches every country
+	mockDB.On("GetInternalJobs", mock.Anything, mock.MatchedBy(func(q types.JobQuery) bool {
+		return assert.ObjectsAreEqual([]string{types.Wildcard}, q.Countries)
+	})).Return([]types.Job{}, nil)
+	for _, country := range types.Vocabularies["country"] {
+		mockFetcher.On("FetchExternalJobs", mock.Anything, "Backend Developer", int64(0), int64(0), country).
+			Return([]types.ExternalJob{{Title: "Backend Developer"}}, nil).Once()
+	}
+
+	output, err := service.ListJobs(context.Background(), types.JobQuery{SubscriberID: sub.ID})
+
+	assert.NoError(t, err)
+	assert.Len(t, output.Items, len(types.Vocabularies["country"]))
+	mockFetcher.AssertNotCalled(t, "FetchExternalJobs", mock.Anything, mock.Anything, mock.Anything, mock.Anything, types.Wildcard)
+	mockDB.AssertExpectations(t)
+	mockFetcher.AssertExpectations(t)
+}
+
+func TestExpandWildcard(t *testing.T) {
+	assert.Equal(t, types.Vocabularies["job_title"], expandWildcard([]string{types.Wildcard}, "job_title"))
+	assert.Equal(t, []string{"UK"}, expandWildcard([]string{"UK"}, "country"))
+	assert.Nil(t, expandWildcard(nil, "country"))
+}
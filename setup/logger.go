@@ -0,0 +1,150 @@
+package setup
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"jobs/config"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Supported values for LogConfig.Environment
+const (
+	EnvironmentDev  = "dev"
+	EnvironmentProd = "prod"
+)
+
+// SetupLogger all necessary stuff to configure logger
+func SetupLogger() (*zap.Logger, error) {
+	logger, _, err := NewLogger(config.Default().Log)
+	return logger, err
+}
+
+// NewLogger builds a logger for the given config.
+// The returned level can be changed at runtime and is shared by all loggers derived from it.
+func NewLogger(cfg config.LogConfig) (*zap.Logger, zap.AtomicLevel, error) {
+	var config zap.Config
+	switch cfg.Environment {
+	case EnvironmentProd:
+		config = zap.NewProductionConfig()
+	case "", EnvironmentDev:
+		config = zap.NewDevelopmentConfig()
+		config.EncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	default:
+		return nil, zap.AtomicLevel{}, fmt.Errorf("unknown environment %q", cfg.Environment)
+	}
+
+	if cfg.Level != "" {
+		level, err := zap.ParseAtomicLevel(cfg.Level)
+		if err != nil {
+			return nil, zap.AtomicLevel{}, fmt.Errorf("invalid log level: %w", err)
+		}
+		config.Level = level
+	}
+
+	switch cfg.Encoding {
+	case "":
+	case "json":
+		config.Encoding = cfg.Encoding
+		config.EncoderConfig.EncodeLevel = zapcore.LowercaseLevelEncoder
+	case "console":
+		config.Encoding = cfg.Encoding
+	default:
+		return nil, zap.AtomicLevel{}, fmt.Errorf("unknown log encoding %q", cfg.Encoding)
+	}
+
+	if !cfg.Sampling {
+		config.Sampling = nil
+	} else if config.Sampling == nil {
+		config.Sampling = &zap.SamplingConfig{Initial: 100, Thereafter: 100}
+	}
+
+	if len(cfg.OutputPaths) > 0 {
+		config.OutputPaths = cfg.OutputPaths
+	}
+
+	var opts []zap.Option
+	if cfg.RedactPII {
+		opts = append(opts, zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &redactCore{Core: core}
+		}))
+	}
+
+	logger, err := config.Build(opts...)
+	if err != nil {
+		return nil, zap.AtomicLevel{}, fmt.Errorf("failed to build logger: %w", err)
+	}
+
+	return logger, config.Level, nil
+}
+
+var emailPattern = regexp.MustCompile(`([A-Za-z0-9._%+\-])[A-Za-z0-9._%+\-]*@([A-Za-z0-9.\-]+\.[A-Za-z]{2,})`)
+
+// sensitiveKeys are field names whose values are always hidden
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"api_key":       true,
+	"authorization": true,
+}
+
+// RedactPII masks email addresses found in s, keeping the first character and the domain
+func RedactPII(s string) string {
+	return emailPattern.ReplaceAllString(s, "$1***@$2")
+}
+
+// redactCore removes PII from log messages and fields before they are encoded
+type redactCore struct {
+	zapcore.Core
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(entry zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(entry.Level) {
+		return ce.AddCore(entry, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(entry zapcore.Entry, fields []zapcore.Field) error {
+	entry.Message = RedactPII(entry.Message)
+	return c.Core.Write(entry, redactFields(fields))
+}
+
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	redacted := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		redacted[i] = redactField(f)
+	}
+	return redacted
+}
+
+func redactField(f zapcore.Field) zapcore.Field {
+	if sensitiveKeys[strings.ToLower(f.Key)] {
+		return zap.String(f.Key, "[REDACTED]")
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, RedactPII(f.String))
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return zap.String(f.Key, RedactPII(s.String()))
+		}
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, RedactPII(err.Error()))
+		}
+	case zapcore.ReflectType:
+		if b, err := json.Marshal(f.Interface); err == nil {
+			return zap.Reflect(f.Key, json.RawMessage(RedactPII(string(b))))
+		}
+	}
+	return f
+}
@@ -0,0 +1,68 @@
+package setup
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"jobs/config"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewLogger(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        config.LogConfig
+		expectedLevel zapcore.Level
+		expectedError string
+	}{
+		{name: "Dev defaults", config: config.LogConfig{Environment: EnvironmentDev}, expectedLevel: zapcore.DebugLevel},
+		{name: "Prod defaults", config: config.LogConfig{Environment: EnvironmentProd, Sampling: true}, expectedLevel: zapcore.InfoLevel},
+		{name: "Explicit level and encoding", config: config.LogConfig{Level: "warn", Encoding: "json"}, expectedLevel: zapcore.WarnLevel},
+		{name: "Unknown environment", config: config.LogConfig{Environment: "staging"}, expectedError: `unknown environment "staging"`},
+		{name: "Unknown encoding", config: config.LogConfig{Encoding: "xml"}, expectedError: `unknown log encoding "xml"`},
+		{name: "Invalid level", config: config.LogConfig{Level: "loud"}, expectedError: "invalid log level"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger, level, err := NewLogger(tt.config)
+			if tt.expectedError != "" {
+				assert.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.NotNil(t, logger)
+			assert.Equal(t, tt.expectedLevel, level.Level())
+
+			// Changing the level at runtime affects the built logger
+			level.SetLevel(zapcore.ErrorLevel)
+			assert.False(t, logger.Core().Enabled(zapcore.WarnLevel))
+		})
+	}
+}
+
+func TestRedactCore(t *testing.T) {
+	core, logs := observer.New(zap.DebugLevel)
+	logger := zap.New(&redactCore{Core: core}).With(zap.String("email", "romina@gmail.com"))
+
+	logger.Info("subscribing romina@gmail.com",
+		zap.String("token", "secret"),
+		zap.Error(errors.New(`duplicate key (email)=(john.doe@example.com)`)),
+		zap.Any("input", map[string]string{"email": "jane@example.org"}),
+	)
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, "subscribing r***@gmail.com", entries[0].Message)
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "r***@gmail.com", fields["email"])
+		assert.Equal(t, "[REDACTED]", fields["token"])
+		assert.Equal(t, "duplicate key (email)=(j***@example.com)", fields["error"])
+		assert.Equal(t, json.RawMessage(`{"email":"j***@example.org"}`), fields["input"])
+	}
+}
@@ -1,7 +1,9 @@
 package types
 
 import (
+	"encoding/json"
 	"encoding/xml"
+	"slices"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,9 +12,17 @@ import (
 type SubscribeInput struct {
 	Name               string   `json:"name" validate:"required"`
 	Email              string   `json:"email" validate:"required,email"`
-	JobTitles          []string `json:"job_titles" validate:"required,min=1,dive,required"`
-	PreferredCountries []string `json:"country" validate:"required,min=1,dive,required"`
+	JobTitles          []string `json:"job_titles" validate:"required,min=1,wildcard,dive,required"`
+	PreferredCountries []string `json:"country" validate:"required,min=1,wildcard,dive,required"`
 	SalaryMin          int64    `json:"salary_min" validate:"required,min=0"`
+	// SalaryCurrency and SalaryPeriod express SalaryMin, USD per year by default
+	SalaryCurrency string `json:"salary_currency,omitempty" validate:"omitempty,iso4217"`
+	SalaryPeriod   string `json:"salary_period,omitempty" validate:"omitempty,enum=pay_period"`
+	// FollowedCompanies are listed first, BlockedCompanies are never listed
+	FollowedCompanies []uuid.UUID `json:"followed_companies,omitempty"`
+	BlockedCompanies  []uuid.UUID `json:"blocked_companies,omitempty"`
+	// Locations list where and how the subscriber wants to work, any job matches when empty
+	Locations []LocationPreference `json:"locations,omitempty" validate:"max=20,dive"`
 }
 
 type SubscribeOutput struct {
@@ -30,22 +40,482 @@ type JobsInput struct {
 }
 
 type JobsOutput struct {
-	InternalJobs []uuid.UUID `json:"internal_jobs" db:"id"`
-	ExternalJobs []Job       `json:"external_jobs"`
-	Message      string      `json:"message,omitempty"`
+	InternalJobs []uuid.UUID   `json:"internal_jobs" db:"id"`
+	ExternalJobs []ExternalJob `json:"external_jobs"`
+	Message      string        `json:"message,omitempty"`
+	// Snippets highlight the text matching q, by internal job ID
+	Snippets map[string]string `json:"snippets,omitempty"`
+	// SeenUntil is the mark to acknowledge after a since=last_seen list
+	SeenUntil *time.Time `json:"seen_until,omitempty"`
 }
 
-type DatabaseConfig struct {
-	Host     string
-	Port     int
-	User     string
-	Password string
-	DBName   string
-	SSLMode  string
+// Job sources
+const (
+	SourceInternal = "internal"
+	SourceExternal = "external"
+)
+
+// Job is the representation of a job shared by every source
+type Job struct {
+	// ID is set for every job, external jobs get one derived from their source, title, country, company and skills
+	// which stays the same while the provider serves them
+	ID          *uuid.UUID `json:"id,omitempty" db:"id"`
+	Source      string     `json:"source" db:"source"`
+	Title       string     `json:"title" db:"title"`
+	Description string     `json:"description,omitempty" db:"description"`
+	Location    string     `json:"location,omitempty" db:"location"`
+	Country     string     `json:"country" db:"country"`
+	// Region and City locate the job in Country, WorkMode is onsite, hybrid or remote.
+	// External jobs only have them when the provider sends them.
+	Region    string `json:"region,omitempty" db:"region"`
+	City      string `json:"city,omitempty" db:"city"`
+	WorkMode  string `json:"work_mode,omitempty" db:"work_mode"`
+	SalaryMin int64  `json:"salary_min" db:"salary_min"`
+	// SalaryMax is only set for salary ranges, the salary is SalaryMin otherwise
+	SalaryMax *int64 `json:"salary_max,omitempty" db:"salary_max"`
+	// SalaryCurrency is an ISO 4217 code and SalaryPeriod what the salary pays for, e.g. USD per year
+	SalaryCurrency string     `json:"salary_currency,omitempty" db:"salary_currency"`
+	SalaryPeriod   string     `json:"salary_period,omitempty" db:"salary_period"`
+	Skills         []string   `json:"skills"`
+	PostedAt       *time.Time `json:"posted_at,omitempty" db:"posted_date"`
+	// CompanyID is only set for internal jobs, Company is the company name when known
+	CompanyID *uuid.UUID `json:"company_id,omitempty" db:"company_id"`
+	Company   string     `json:"company,omitempty" db:"company"`
+	// Status and ExpiresAt are only set for internal jobs
+	Status    string     `json:"status,omitempty" db:"status"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	// Snippet highlights the text matching the search query with <mark> tags
+	Snippet string `json:"snippet,omitempty" db:"snippet"`
+	// UpdatedAt is only set for internal jobs, it versions the job
+	UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+	// FirstSeenAt is when the job was first open, or first ingested for external jobs.
+	// External jobs fetched from the provider on every request do not have it.
+	FirstSeenAt *time.Time `json:"first_seen_at,omitempty" db:"first_seen_at"`
+	// Bookmarked is set when the subscriber the jobs are listed for bookmarked the job
+	Bookmarked bool `json:"bookmarked,omitempty" db:"-"`
+}
+
+// Internal job statuses, only open jobs are listed by default
+const (
+	JobDraft   = "draft"
+	JobOpen    = "open"
+	JobClosed  = "closed"
+	JobExpired = "expired"
+)
+
+// Wildcard is the job_title and country a subscriber picks to match every title or country, it must be the only
+// value of its list. The jobs tagged with it, such as the sample ones, match every title or country.
+const Wildcard = "ALL"
+
+// MixesWildcard reports whether values combines the Wildcard with specific values
+func MixesWildcard(values []string) bool {
+	return len(values) > 1 && slices.Contains(values, Wildcard)
+}
+
+// Vocabularies lists the values accepted for the Postgres enums, keyed by enum type.
+// The Wildcard is a subscriber preference rather than a job value, it is left out.
+var Vocabularies = map[string][]string{
+	"job_title":              {"SSr Java Developer", "Sr Java Developer", "Frontend Developer", "Backend Developer", "Full Stack Developer"},
+	"country":                {"Argentina", "Australia", "USA", "UK"},
+	"pay_period":             {PayHour, PayDay, PayWeek, PayMonth, PayYear},
+	"work_mode":              {WorkOnsite, WorkHybrid, WorkRemote},
+	"notification_channel":   {ChannelEmail, ChannelWebhook},
+	"notification_frequency": {FrequencyInstant, FrequencyDaily, FrequencyWeekly},
+	"application_status":     ApplicationStatuses,
+}
+
+// Work modes of a job
+const (
+	WorkOnsite = "onsite"
+	WorkHybrid = "hybrid"
+	WorkRemote = "remote"
+)
+
+// LocationPreference matches the jobs with its work mode in its location, empty fields match anything.
+// {"work_mode": "remote"} is remote anywhere and {"work_mode": "hybrid", "city": "Buenos Aires"} hybrid in Buenos Aires.
+// Region and City are compared case-insensitively.
+type LocationPreference struct {
+	WorkMode string `json:"work_mode,omitempty" validate:"omitempty,enum=work_mode"`
+	Country  string `json:"country,omitempty" validate:"omitempty,enum=country"`
+	Region   string `json:"region,omitempty" validate:"max=255"`
+	City     string `json:"city,omitempty" validate:"max=255"`
+}
+
+// Pay periods of a salary
+const (
+	PayHour  = "hour"
+	PayDay   = "day"
+	PayWeek  = "week"
+	PayMonth = "month"
+	PayYear  = "year"
+)
+
+// PeriodsPerYear converts salaries between pay periods, assuming full-time work of 40 hours a week
+var PeriodsPerYear = map[string]float64{PayHour: 2080, PayDay: 260, PayWeek: 52, PayMonth: 12, PayYear: 1}
+
+// Salaries are in DefaultCurrency per DefaultPayPeriod unless told otherwise
+const (
+	DefaultCurrency  = "USD"
+	DefaultPayPeriod = PayYear
+)
+
+// CountryCurrencies are the currencies of the yearly salaries served by the external provider, by country
+var CountryCurrencies = map[string]string{"Argentina": "ARS", "Australia": "AUD", "USA": "USD", "UK": "GBP"}
+
+// JobInput publishes an internal job or replaces every field of one
+type JobInput struct {
+	Title       string     `json:"title" validate:"required,enum=job_title"`
+	CompanyID   *uuid.UUID `json:"company_id,omitempty"`
+	Description string     `json:"description"`
+	Location    string     `json:"location" validate:"max=255"`
+	Country     string     `json:"country" validate:"required,enum=country"`
+	Region      string     `json:"region,omitempty" validate:"max=255"`
+	City        string     `json:"city,omitempty" validate:"max=255"`
+	// WorkMode defaults to onsite
+	WorkMode  string `json:"work_mode,omitempty" validate:"omitempty,enum=work_mode"`
+	SalaryMin int64  `json:"salary_min" validate:"min=0"`
+	SalaryMax *int64 `json:"salary_max,omitempty" validate:"omitempty,gtefield=SalaryMin"`
+	// SalaryCurrency and SalaryPeriod default to USD per year
+	SalaryCurrency string `json:"salary_currency,omitempty" validate:"omitempty,iso4217"`
+	SalaryPeriod   string `json:"salary_period,omitempty" validate:"omitempty,enum=pay_period"`
+	// Status defaults to open, expired is reserved to the sweeper
+	Status    string     `json:"status,omitempty" validate:"omitempty,oneof=draft open closed"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// JobPatch changes the fields it sets and keeps the others
+type JobPatch struct {
+	Title          *string    `json:"title,omitempty" validate:"omitempty,enum=job_title"`
+	CompanyID      *uuid.UUID `json:"company_id,omitempty"`
+	Description    *string    `json:"description,omitempty"`
+	Location       *string    `json:"location,omitempty" validate:"omitempty,max=255"`
+	Country        *string    `json:"country,omitempty" validate:"omitempty,enum=country"`
+	Region         *string    `json:"region,omitempty" validate:"omitempty,max=255"`
+	City           *string    `json:"city,omitempty" validate:"omitempty,max=255"`
+	WorkMode       *string    `json:"work_mode,omitempty" validate:"omitempty,enum=work_mode"`
+	SalaryMin      *int64     `json:"salary_min,omitempty" validate:"omitempty,min=0"`
+	SalaryMax      *int64     `json:"salary_max,omitempty" validate:"omitempty,min=0"`
+	SalaryCurrency *string    `json:"salary_currency,omitempty" validate:"omitempty,iso4217"`
+	SalaryPeriod   *string    `json:"salary_period,omitempty" validate:"omitempty,enum=pay_period"`
+	Status         *string    `json:"status,omitempty" validate:"omitempty,oneof=draft open closed"`
+	// ExpiresAt moves the expiration, it cannot be cleared by a patch
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	// ClearCompanyID and ClearSalaryMax are set by an explicit null, which unlinks the company or drops the top of
+	// the salary range
+	ClearCompanyID bool `json:"-"`
+	ClearSalaryMax bool `json:"-"`
+}
+
+// UnmarshalJSON decodes a patch, telling the fields sent as null from the missing ones
+func (p *JobPatch) UnmarshalJSON(b []byte) error {
+	type patch JobPatch
+	if err := json.Unmarshal(b, (*patch)(p)); err != nil {
+		return err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return err
+	}
+	p.ClearCompanyID = string(fields["company_id"]) == "null"
+	p.ClearSalaryMax = string(fields["salary_max"]) == "null"
+	return nil
+}
+
+// JobQuery selects the jobs listed for a subscriber, empty filters fall back to the subscriber preferences
+type JobQuery struct {
+	SubscriberID uuid.UUID
+	JobTitles    []string
+	Countries    []string
+	// SalaryMin and SalaryMax are in SalaryCurrency per SalaryPeriod, the subscriber preferred ones
+	SalaryMin      int64
+	SalaryMax      int64
+	SalaryCurrency string
+	SalaryPeriod   string
+	// SalaryFactors convert a salary of the unit of the key, e.g. "EUR/month", to SalaryCurrency per SalaryPeriod.
+	// Jobs paid in other units never match a salary filter.
+	SalaryFactors map[string]float64
+	PostedAfter   time.Time
+	// Text searches the title and description of internal jobs and the title and skills of external ones,
+	// matching jobs are sorted by relevance
+	Text string
+	// Statuses of the internal jobs to list, only open jobs when empty
+	Statuses []string
+	// FollowedCompanies are listed first and BlockedCompanies left out, both come from the subscriber
+	FollowedCompanies []uuid.UUID
+	BlockedCompanies  []uuid.UUID
+	// Locations keep the jobs matching any of them, in the listed countries
+	Locations []LocationPreference
+	// Skills keep the jobs listing any of them, or mentioning it in their title or description for internal jobs
+	// which do not list skills
+	Skills []string
+	// SinceLastSeen only lists the jobs first seen after LastSeenAt, the subscriber high-water mark when nil.
+	// Jobs without a first-seen time are then left out.
+	SinceLastSeen bool
+	LastSeenAt    *time.Time
+	// BookmarkedFirst lists the jobs the subscriber bookmarked before the others, dismissed jobs are always left out
+	BookmarkedFirst bool
+	// Limit caps the page size, 0 lists every job
+	Limit  int
+	Cursor string
+}
+
+// List is the envelope of every paginated collection
+type List[T any] struct {
+	Items      []T    `json:"items"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      int    `json:"total"`
+}
+
+type JobList struct {
+	List[Job]
+	// Warnings report sources that could not be listed
+	Warnings []string `json:"warnings,omitempty"`
+	// SeenUntil is set when listing the jobs since the last seen one, on every page. It is the newest first-seen
+	// time of the listed jobs, or the high-water mark when none is new, to acknowledge once they are processed.
+	SeenUntil *time.Time `json:"seen_until,omitempty"`
+}
+
+// AcknowledgeInput advances a high-water mark to SeenUntil, now when unset. Marks never move back nor past now.
+type AcknowledgeInput struct {
+	SeenUntil *time.Time `json:"seen_until,omitempty"`
+}
+
+// SeenMark is the high-water mark of a subscriber or saved search, since=last_seen lists the jobs first seen after it
+type SeenMark struct {
+	LastSeenAt time.Time `json:"last_seen_at"`
+}
+
+// Subscriber is a person notified about new jobs
+type Subscriber struct {
+	ID        uuid.UUID `json:"id" db:"id"`
+	Name      string    `json:"name" db:"user_name"`
+	Email     string    `json:"email" db:"email"`
+	JobTitles []string  `json:"job_titles" db:"job_titles"`
+	Countries []string  `json:"countries" db:"preferred_countries"`
+	SalaryMin int64     `json:"salary_min" db:"salary_min"`
+	// SalaryCurrency and SalaryPeriod express SalaryMin and the jobs salaries compared with it
+	SalaryCurrency string `json:"salary_currency" db:"salary_currency"`
+	SalaryPeriod   string `json:"salary_period" db:"salary_period"`
+	// FollowedCompanies are listed first, BlockedCompanies are never listed
+	FollowedCompanies []uuid.UUID `json:"followed_companies,omitempty" db:"followed_companies"`
+	BlockedCompanies  []uuid.UUID `json:"blocked_companies,omitempty" db:"blocked_companies"`
+	// Locations list where and how the subscriber wants to work, any job matches when empty
+	Locations []LocationPreference `json:"locations,omitempty" db:"locations"`
+	// LastSeenAt is the high-water mark of the jobs the subscriber acknowledged
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty" db:"last_seen_at"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// SubscriberInput creates a subscriber or replaces the preferences of the one with the same email
+type SubscriberInput struct {
+	Name      string   `json:"name" validate:"required"`
+	Email     string   `json:"email" validate:"required,email"`
+	JobTitles []string `json:"job_titles" validate:"required,min=1,wildcard,dive,required"`
+	Countries []string `json:"countries" validate:"required,min=1,wildcard,dive,required"`
+	SalaryMin int64    `json:"salary_min" validate:"min=0"`
+	// SalaryCurrency and SalaryPeriod express SalaryMin, USD per year by default
+	SalaryCurrency string `json:"salary_currency,omitempty" validate:"omitempty,iso4217"`
+	SalaryPeriod   string `json:"salary_period,omitempty" validate:"omitempty,enum=pay_period"`
+	// FollowedCompanies are listed first, BlockedCompanies are never listed
+	FollowedCompanies []uuid.UUID `json:"followed_companies,omitempty"`
+	BlockedCompanies  []uuid.UUID `json:"blocked_companies,omitempty"`
+	// Locations list where and how the subscriber wants to work, any job matches when empty
+	Locations []LocationPreference `json:"locations,omitempty" validate:"max=20,dive"`
+}
+
+// Company is an employer publishing jobs
+type Company struct {
+	ID          uuid.UUID `json:"id" db:"id"`
+	Name        string    `json:"name" db:"name"`
+	Website     string    `json:"website,omitempty" db:"website"`
+	Description string    `json:"description,omitempty" db:"description"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CompanyInput creates a company or replaces every field of one
+type CompanyInput struct {
+	Name        string `json:"name" validate:"required,max=255"`
+	Website     string `json:"website,omitempty" validate:"omitempty,url,max=255"`
+	Description string `json:"description,omitempty"`
+}
+
+// Notification channels and frequencies of saved searches
+const (
+	ChannelEmail     = "email"
+	ChannelWebhook   = "webhook"
+	FrequencyInstant = "instant"
+	FrequencyDaily   = "daily"
+	FrequencyWeekly  = "weekly"
+)
+
+// SavedSearch is a named set of job filters of a subscriber, whose new jobs are notified through Channel at Frequency.
+// Empty filters fall back to the subscriber preferences, as in a jobs list.
+type SavedSearch struct {
+	ID           uuid.UUID `json:"id"`
+	SubscriberID uuid.UUID `json:"subscriber_id"`
+	Name         string    `json:"name"`
+	JobTitles    []string  `json:"job_titles"`
+	Countries    []string  `json:"countries"`
+	// SalaryMin is in the subscriber preferred currency and pay period
+	SalaryMin int64    `json:"salary_min"`
+	Skills    []string `json:"skills"`
+	// Keywords search the jobs like the q parameter of a jobs list
+	Keywords   string `json:"keywords,omitempty"`
+	Channel    string `json:"channel"`
+	WebhookURL string `json:"webhook_url,omitempty"`
+	Frequency  string `json:"frequency"`
+	// LastSeenAt is the high-water mark of the jobs acknowledged for this search, independent of the subscriber one
+	LastSeenAt *time.Time `json:"last_seen_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+	UpdatedAt  time.Time  `json:"updated_at"`
+}
+
+// SavedSearchInput creates a saved search or replaces every field of one
+type SavedSearchInput struct {
+	// SubscriberID owns the search, it is only read on creation and defaults to the subscriber of the session
+	SubscriberID uuid.UUID `json:"subscriber_id,omitempty"`
+	Name         string    `json:"name" validate:"required,max=100"`
+	JobTitles    []string  `json:"job_titles,omitempty" validate:"wildcard,dive,required"`
+	Countries    []string  `json:"countries,omitempty" validate:"wildcard,dive,required"`
+	SalaryMin    int64     `json:"salary_min" validate:"min=0"`
+	Skills       []string  `json:"skills,omitempty" validate:"max=20,dive,required,max=50"`
+	Keywords     string    `json:"keywords,omitempty" validate:"max=200"`
+	// Channel defaults to email and Frequency to daily, the webhook channel posts to WebhookURL
+	Channel    string `json:"channel,omitempty" validate:"omitempty,enum=notification_channel"`
+	WebhookURL string `json:"webhook_url,omitempty" validate:"required_if=Channel webhook,omitempty,url,max=2048"`
+	Frequency  string `json:"frequency,omitempty" validate:"omitempty,enum=notification_frequency"`
+}
+
+// Application statuses
+const (
+	ApplicationSaved        = "saved"
+	ApplicationApplied      = "applied"
+	ApplicationInterviewing = "interviewing"
+	ApplicationOffer        = "offer"
+	ApplicationRejected     = "rejected"
+)
+
+// ApplicationStatuses is the pipeline of an application in order, rejected ends it at any stage
+var ApplicationStatuses = []string{ApplicationSaved, ApplicationApplied, ApplicationInterviewing, ApplicationOffer, ApplicationRejected}
+
+// Application tracks a subscriber applying to an internal or external job
+type Application struct {
+	ID           uuid.UUID `json:"id"`
+	SubscriberID uuid.UUID `json:"subscriber_id"`
+	// JobID is the job applied to, of Source. It is nil once the job is deleted, Title, Company and Country keep
+	// describing it as it was when the application was created.
+	JobID   *uuid.UUID `json:"job_id,omitempty"`
+	Source  string     `json:"source"`
+	Title   string     `json:"title"`
+	Company string     `json:"company,omitempty"`
+	Country string     `json:"country"`
+	Status  string     `json:"status"`
+	Notes   string     `json:"notes,omitempty"`
+	// AppliedAt defaults to when the application left the saved status
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// ApplicationInput creates an application, saved unless Status says otherwise
+type ApplicationInput struct {
+	// SubscriberID applies, it defaults to the subscriber of the session
+	SubscriberID uuid.UUID  `json:"subscriber_id,omitempty"`
+	JobID        uuid.UUID  `json:"job_id" validate:"required"`
+	Status       string     `json:"status,omitempty" validate:"omitempty,enum=application_status"`
+	Notes        string     `json:"notes,omitempty" validate:"max=5000"`
+	AppliedAt    *time.Time `json:"applied_at,omitempty"`
+}
+
+// ApplicationPatch changes the fields it sets. A status change is recorded in the history along with Note.
+type ApplicationPatch struct {
+	Status    *string    `json:"status,omitempty" validate:"omitempty,enum=application_status"`
+	Notes     *string    `json:"notes,omitempty" validate:"omitempty,max=5000"`
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+	Note      string     `json:"note,omitempty" validate:"max=1000"`
+}
+
+// ApplicationEvent is a status change of an application, FromStatus is empty for its creation
+type ApplicationEvent struct {
+	ID            uuid.UUID `json:"id"`
+	ApplicationID uuid.UUID `json:"application_id"`
+	FromStatus    string    `json:"from_status,omitempty"`
+	ToStatus      string    `json:"to_status"`
+	Note          string    `json:"note,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// ApplicationSummary counts the applications of a subscriber by status, every status is listed
+type ApplicationSummary struct {
+	Counts map[string]int `json:"counts"`
+	Total  int            `json:"total"`
+}
+
+// Job actions of a subscriber, a job is bookmarked or dismissed but not both
+const (
+	ActionBookmark = "bookmark"
+	ActionDismiss  = "dismiss"
+)
+
+// JobAction is a bookmark or a dismissal of an internal or external job by a subscriber
+type JobAction struct {
+	SubscriberID uuid.UUID `json:"subscriber_id"`
+	JobID        uuid.UUID `json:"job_id"`
+	Source       string    `json:"source"`
+	CreatedAt    time.Time `json:"created_at"`
 }
 
-type ErrorResponse struct {
-	Code    int    `json:"code"`
+// Batch import row statuses
+const (
+	ImportCreated = "created"
+	ImportUpdated = "updated"
+	ImportFailed  = "failed"
+)
+
+// SubscriberImportRow is one row of a batch import. Rows rejected before reaching the service carry Err.
+type SubscriberImportRow struct {
+	// Row is the 1-based position of the row in the upload, the CSV header is not counted
+	Row   int
+	Input SubscriberInput
+	Err   error
+}
+
+// SubscriberImportResult reports what happened to one row of a batch import
+type SubscriberImportResult struct {
+	Row    int          `json:"row"`
+	Email  string       `json:"email,omitempty"`
+	Status string       `json:"status"`
+	ID     *uuid.UUID   `json:"id,omitempty"`
+	Code   string       `json:"code,omitempty"`
+	Reason string       `json:"reason,omitempty"`
+	Errors []FieldError `json:"errors,omitempty"`
+}
+
+type SubscriberImportOutput struct {
+	Created int                      `json:"created"`
+	Updated int                      `json:"updated"`
+	Failed  int                      `json:"failed"`
+	Results []SubscriberImportResult `json:"results"`
+}
+
+// Problem is an RFC 7807 problem details body, served as application/problem+json
+type Problem struct {
+	Type      string       `json:"type"`
+	Title     string       `json:"title"`
+	Status    int          `json:"status"`
+	Detail    string       `json:"detail,omitempty"`
+	Instance  string       `json:"instance,omitempty"`
+	Code      string       `json:"code"`
+	RequestID string       `json:"request_id,omitempty"`
+	Errors    []FieldError `json:"errors,omitempty"`
+}
+
+// FieldError describes why one request field was rejected
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
 	Message string `json:"message"`
 }
 
@@ -53,22 +523,118 @@ type Skill struct {
 	Name string `xml:",chardata"`
 }
 
+// MarshalJSON encodes a skill as its name
+func (s Skill) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Name)
+}
+
+// UnmarshalJSON decodes a skill from its name
+func (s *Skill) UnmarshalJSON(b []byte) error {
+	return json.Unmarshal(b, &s.Name)
+}
+
 type Skills struct {
-	XMLName xml.Name `xml:"skills"`
-	Skills  []Skill  `xml:"skill"`
+	XMLName xml.Name `xml:"skills" json:"-"`
+	Skills  []Skill  `xml:"skill" json:"skills"`
 }
 
-type Job struct {
-	Title  string `xml:"title"`
-	Salary int    `xml:"salary"`
-	Skills Skills `xml:"skills"`
+// ExternalJob is a job as served by the external provider and by V1
+type ExternalJob struct {
+	Title  string `xml:"title" json:"title"`
+	Salary int    `xml:"salary" json:"salary"`
+	Skills Skills `xml:"skills" json:"skills"`
+	// Company is only known when the provider sends it
+	Company string `xml:"company" json:"company,omitempty"`
+	// WorkMode, Region and City are only known when the provider sends them
+	WorkMode string `xml:"work_mode" json:"work_mode,omitempty"`
+	Region   string `xml:"region" json:"region,omitempty"`
+	City     string `xml:"city" json:"city,omitempty"`
+}
+
+// ExternalSearch is a title and country the external providers are asked for, Country is empty for the subscribers
+// without preferred countries
+type ExternalSearch struct {
+	Title   string
+	Country string
 }
 
 type CountryJobs struct {
-	Jobs []Job `xml:"job"`
+	Jobs []ExternalJob `xml:"job"`
 }
 
 type Response struct {
 	XMLName   xml.Name               `xml:"root"`
 	Countries map[string]CountryJobs `xml:",any"`
 }
+
+// API key scopes
+const (
+	ScopeSubscribe = "subscribe"
+	ScopeJobsRead  = "jobs:read"
+	ScopeJobsWrite = "jobs:write"
+	ScopeAdmin     = "admin"
+	// ScopeTrack lets a subscriber, or a key on their behalf, track applications and bookmark or dismiss jobs;
+	// subscriber sessions have it
+	ScopeTrack = "jobs:track"
+	// ScopeImpersonate lets a key read a subscriber's data on their behalf; every use is audited
+	ScopeImpersonate = "admin:impersonate"
+)
+
+type APIKey struct {
+	ID          uuid.UUID  `json:"id" db:"id"`
+	Name        string     `json:"name" db:"name"`
+	Prefix      string     `json:"prefix" db:"prefix"`
+	Scopes      []string   `json:"scopes" db:"scopes"`
+	RotatedFrom *uuid.UUID `json:"rotated_from,omitempty" db:"rotated_from"`
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty" db:"expires_at"`
+	RevokedAt   *time.Time `json:"revoked_at,omitempty" db:"revoked_at"`
+	LastUsedAt  *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+type IssueAPIKeyInput struct {
+	Name      string     `json:"name" validate:"required,max=255"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=subscribe jobs:read jobs:write jobs:track admin admin:impersonate"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+type RotateAPIKeyInput struct {
+	// GracePeriod keeps the previous key valid for a while, e.g. "24h"
+	GracePeriod string `json:"grace_period,omitempty"`
+}
+
+type IssueAPIKeyOutput struct {
+	APIKey
+	// Key is the plaintext key, it is only returned once
+	Key string `json:"key"`
+}
+
+type MagicLinkInput struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type SessionTokenInput struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type SessionOutput struct {
+	AccessToken string    `json:"access_token"`
+	TokenType   string    `json:"token_type"`
+	ExpiresIn   int64     `json:"expires_in"`
+	ExpiresAt   time.Time `json:"expires_at"`
+}
+
+// SessionClaims are the validated claims of a subscriber session token
+type SessionClaims struct {
+	SubscriberID uuid.UUID
+	Scopes       []string
+	ExpiresAt    time.Time
+}
+
+type ImpersonationAudit struct {
+	APIKeyID     uuid.UUID
+	SubscriberID uuid.UUID
+	Method       string
+	Path         string
+	RequestID    string
+}